@@ -24,6 +24,10 @@ func handleCreateBackup(c *fiber.Ctx) error {
 	}
 	c.BodyParser(&body)
 
+	if err := server.CheckOperation(id, "back up", server.StateStarting, server.StateStopping); err != nil {
+		return operationConflict(c, err)
+	}
+
 	backup, err := server.CreateBackup(id, body.Name)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -64,6 +68,12 @@ func handleRestoreBackup(c *fiber.Ctx) error {
 	id := c.Params("id")
 	backupID := c.Params("backupId")
 
+	op, err := beginOperation(c, id, "restore", server.StateRestoring)
+	if err != nil {
+		return operationConflict(c, err)
+	}
+	defer op.End()
+
 	status, _ := server.GetStatus(id)
 	if status == "running" {
 		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
@@ -0,0 +1,47 @@
+package api
+
+import (
+	"cauthon-axis/internal/logger"
+	"cauthon-axis/internal/server"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func handleCloneServer(c *fiber.Ctx) error {
+	id := c.Params("id")
+	var req struct {
+		SourceID string `json:"source_id"`
+		URL      string `json:"url"`
+		Token    string `json:"token"`
+	}
+	if err := c.BodyParser(&req); err != nil || req.SourceID == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false, "error": "source_id required",
+		})
+	}
+	if err := server.ValidateServerID(req.SourceID); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false, "error": "invalid source server id",
+		})
+	}
+	if req.SourceID == id {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false, "error": "a server cannot be cloned into itself",
+		})
+	}
+
+	op, err := beginOperation(c, id, "clone", server.StateInstalling)
+	if err != nil {
+		return operationConflict(c, err)
+	}
+
+	logger.Info("Cloning server %s into %s", req.SourceID, id)
+	src := server.CloneSource{ServerID: req.SourceID, URL: req.URL, Token: req.Token}
+	if err := server.StartClone(id, src, op.End); err != nil {
+		op.End()
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"success": false, "error": err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{"success": true})
+}
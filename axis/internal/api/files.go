@@ -1,8 +1,10 @@
 package api
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
+	"time"
 
 	"cauthon-axis/internal/server"
 
@@ -45,13 +47,14 @@ func handleReadFile(c *fiber.Ctx) error {
 		})
 	}
 
-	content, err := server.ReadFile(id, path)
+	content, etag, err := server.ReadFile(id, path)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"success": false, "error": err.Error(),
 		})
 	}
-	return c.JSON(fiber.Map{"success": true, "data": string(content)})
+	c.Set(fiber.HeaderETag, etag)
+	return c.JSON(fiber.Map{"success": true, "data": string(content), "etag": etag})
 }
 
 func handleSearchFiles(c *fiber.Ctx) error {
@@ -88,14 +91,32 @@ func handleWriteFile(c *fiber.Ctx) error {
 	var body struct {
 		Path    string `json:"path"`
 		Content string `json:"content"`
+		IfMatch string `json:"if_match"`
 	}
 	if err := c.BodyParser(&body); err != nil || body.Path == "" {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "path required"})
 	}
-	if err := server.WriteFile(id, body.Path, []byte(body.Content)); err != nil {
+
+	ifMatch := c.Get(fiber.HeaderIfMatch)
+	if ifMatch == "" {
+		ifMatch = body.IfMatch
+	}
+
+	etag, err := server.WriteFile(id, body.Path, []byte(body.Content), ifMatch)
+	if errors.Is(err, server.ErrETagMismatch) {
+		current, currentETag, _ := server.ReadFile(id, body.Path)
+		if currentETag != "" {
+			c.Set(fiber.HeaderETag, currentETag)
+		}
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"success": false, "error": err.Error(), "data": string(current), "etag": currentETag,
+		})
+	}
+	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": err.Error()})
 	}
-	return c.JSON(fiber.Map{"success": true})
+	c.Set(fiber.HeaderETag, etag)
+	return c.JSON(fiber.Map{"success": true, "etag": etag})
 }
 
 func handleUploadFile(c *fiber.Ctx) error {
@@ -253,6 +274,23 @@ func handleBulkDelete(c *fiber.Ctx) error {
 	return c.JSON(fiber.Map{"success": true, "data": fiber.Map{"deleted": deleted}})
 }
 
+func handleDeleteMatching(c *fiber.Ctx) error {
+	id := c.Params("id")
+	var body struct {
+		Pattern   string `json:"pattern"`
+		OlderThan int    `json:"older_than"`
+	}
+	if err := c.BodyParser(&body); err != nil || body.Pattern == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "pattern required"})
+	}
+
+	deleted, err := server.DeleteMatching(id, body.Pattern, time.Duration(body.OlderThan)*time.Second)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"success": true, "data": fiber.Map{"deleted": len(deleted), "paths": deleted}})
+}
+
 func handleBulkCopy(c *fiber.Ctx) error {
 	id := c.Params("id")
 	var body struct {
@@ -310,6 +348,12 @@ func handleInstallModpack(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "url required"})
 	}
 
+	op, err := beginOperation(c, id, "install modpack", server.StateInstalling)
+	if err != nil {
+		return operationConflict(c, err)
+	}
+	defer op.End()
+
 	result, err := server.InstallModpack(id, body)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": err.Error()})
@@ -0,0 +1,41 @@
+package api
+
+import (
+	"errors"
+	"time"
+
+	"cauthon-axis/internal/server"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const maxOperationWait = 300
+
+// beginOperation takes the power lock for id. Callers may pass ?wait=<seconds>
+// to queue behind a running operation instead of failing straight away.
+func beginOperation(c *fiber.Ctx, id, op string, state server.PowerState) (*server.Operation, error) {
+	wait := c.QueryInt("wait", 0)
+	if wait > maxOperationWait {
+		wait = maxOperationWait
+	}
+	return server.BeginOperation(id, op, state, time.Duration(wait)*time.Second)
+}
+
+func operationConflict(c *fiber.Ctx, err error) error {
+	var conflict *server.OperationConflictError
+	if errors.As(err, &conflict) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"success":   false,
+			"error":     err.Error(),
+			"state":     conflict.State,
+			"operation": conflict.Current,
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"success": false, "error": err.Error(),
+	})
+}
+
+func handleServerState(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{"success": true, "data": server.GetPowerState(c.Params("id"))})
+}
@@ -2,6 +2,7 @@ package api
 
 import (
 	"strings"
+	"time"
 
 	"cauthon-axis/internal/config"
 	"cauthon-axis/internal/logger"
@@ -22,28 +23,20 @@ func NewServer() *fiber.App {
 	app.Get("/api/health", handleHealth)
 	app.Post("/api/pair", handlePairing)
 	app.Get("/api/system", requirePanelAuth, handleSystemInfo)
+	app.Post("/api/sync", requirePanelAuth, handleSync)
+	app.Post("/api/sync/apply", requirePanelAuth, handleSyncApply)
 
-	app.Use("/api/servers/:id/ws", func(c *fiber.Ctx) error {
-		cfg := config.Get()
-		token := c.Query("token")
-		if token != cfg.Panel.Token {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
-		}
-		if err := server.ValidateServerID(c.Params("id")); err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid server id"})
-		}
-		if websocket.IsWebSocketUpgrade(c) {
-			return c.Next()
-		}
-		return fiber.ErrUpgradeRequired
-	})
+	app.Use("/api/servers/:id/ws", requireWebSocketAuth)
 	app.Get("/api/servers/:id/ws", websocket.New(handleServerLogs))
+	app.Use("/api/servers/:id/shell", requireWebSocketAuth)
+	app.Get("/api/servers/:id/shell", websocket.New(handleServerShell))
 
 	servers := app.Group("/api/servers", requirePanelAuth)
 	servers.Post("/", handleCreateServer)
 	servers.Use("/:id", validateServerID)
 	servers.Use("/:id/*", validateServerID)
 	servers.Get("/:id/status", handleServerStatus)
+	servers.Get("/:id/state", handleServerState)
 	servers.Get("/:id/logs", handleGetLogs)
 	servers.Get("/:id/logs/full", handleGetFullLog)
 	servers.Get("/:id/logs/search", handleSearchLogs)
@@ -55,6 +48,7 @@ func NewServer() *fiber.App {
 	servers.Post("/:id/kill", handleKillServer)
 	servers.Post("/:id/restart", handleRestartServer)
 	servers.Post("/:id/reinstall", handleReinstallServer)
+	servers.Get("/:id/install", handleGetInstallLog)
 	servers.Delete("/:id", handleDeleteServer)
 	servers.Get("/:id/files", handleListFiles)
 	servers.Get("/:id/files/hashes", handleListFilesWithHashes)
@@ -70,9 +64,13 @@ func NewServer() *fiber.App {
 	servers.Post("/:id/files/decompress", handleDecompressPath)
 	servers.Get("/:id/files/download", handleDownloadFile)
 	servers.Post("/:id/files/bulk-delete", handleBulkDelete)
+	servers.Post("/:id/files/delete-matching", handleDeleteMatching)
 	servers.Post("/:id/files/bulk-copy", handleBulkCopy)
 	servers.Post("/:id/files/bulk-compress", handleBulkCompress)
 	servers.Post("/:id/files/download-url", handleDownloadURL)
+	servers.Get("/:id/trash", handleListTrash)
+	servers.Post("/:id/trash/restore", handleRestoreTrash)
+	servers.Post("/:id/trash/purge", handlePurgeTrash)
 	servers.Post("/:id/modpack/install", handleInstallModpack)
 	servers.Get("/:id/backups", handleListBackups)
 	servers.Post("/:id/backups", handleCreateBackup)
@@ -83,6 +81,12 @@ func NewServer() *fiber.App {
 	servers.Get("/:id/archive/download", handleDownloadArchive)
 	servers.Delete("/:id/archive", handleDeleteArchive)
 	servers.Post("/:id/import", handleImportServer)
+	servers.Get("/:id/import", handleImportStatus)
+	servers.Get("/:id/sync/manifest", handleSyncManifest)
+	servers.Get("/:id/sync/file", handleSyncFile)
+	servers.Post("/:id/sync", handleStartSync)
+	servers.Get("/:id/sync", handleSyncStatus)
+	servers.Post("/:id/clone", handleCloneServer)
 
 	return app
 }
@@ -165,6 +169,12 @@ func handleCreateServer(c *fiber.Ctx) error {
 		})
 	}
 
+	op, err := beginOperation(c, cfg.ID, "install", server.StateInstalling)
+	if err != nil {
+		return operationConflict(c, err)
+	}
+	defer op.End()
+
 	logger.Info("Creating server %s (%s)", cfg.ID, cfg.Name)
 	if err := server.Create(cfg); err != nil {
 		logger.Error("Create server %s failed: %v", cfg.ID, err)
@@ -174,6 +184,7 @@ func handleCreateServer(c *fiber.Ctx) error {
 	}
 
 	logger.Info("Server %s created, starting...", cfg.ID)
+	op.Transition(server.StateStarting)
 	if err := server.Start(cfg.ID); err != nil {
 		logger.Error("Server %s start failed: %v", cfg.ID, err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -196,7 +207,7 @@ func handleServerStatus(c *fiber.Ctx) error {
 		})
 	}
 	stats, _ := server.GetStats(id)
-	data := fiber.Map{"status": status}
+	data := fiber.Map{"status": status, "state": server.GetPowerState(id)}
 	if stats != nil {
 		data["stats"] = fiber.Map{
 			"memory":       stats.MemoryUsage,
@@ -214,6 +225,21 @@ func handleServerStatus(c *fiber.Ctx) error {
 	return c.JSON(fiber.Map{"success": true, "data": data})
 }
 
+func requireWebSocketAuth(c *fiber.Ctx) error {
+	cfg := config.Get()
+	token := c.Query("token")
+	if token != cfg.Panel.Token {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+	if err := server.ValidateServerID(c.Params("id")); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid server id"})
+	}
+	if websocket.IsWebSocketUpgrade(c) {
+		return c.Next()
+	}
+	return fiber.ErrUpgradeRequired
+}
+
 func handleGetLogs(c *fiber.Ctx) error {
 	id := c.Params("id")
 	lines := c.QueryInt("lines", 100)
@@ -235,13 +261,27 @@ func handleGetFullLog(c *fiber.Ctx) error {
 
 func handleSearchLogs(c *fiber.Ctx) error {
 	id := c.Params("id")
-	pattern := c.Query("pattern")
-	regex := c.QueryBool("regex", false)
-	limit := c.QueryInt("limit", 100)
-	since := int64(c.QueryInt("since", 0))
-	matches, err := server.SearchLogs(id, pattern, regex, limit, since)
+	opts := server.LogSearchOptions{
+		Pattern: c.Query("pattern"),
+		Regex:   c.QueryBool("regex", false),
+		Limit:   c.QueryInt("limit", 100),
+		Since:   int64(c.QueryInt("since", 0)),
+		Until:   int64(c.QueryInt("until", 0)),
+		Context: c.QueryInt("context", 0),
+	}
+	if opts.Limit <= 0 {
+		opts.Limit = 100
+	} else if opts.Limit > 1000 {
+		opts.Limit = 1000
+	}
+	if opts.Context < 0 {
+		opts.Context = 0
+	} else if opts.Context > 10 {
+		opts.Context = 10
+	}
+	matches, err := server.SearchLogs(id, opts)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
 	}
 	return c.JSON(fiber.Map{"matches": matches})
 }
@@ -265,14 +305,25 @@ func handleReadLogFile(c *fiber.Ctx) error {
 	return c.Send(content)
 }
 
+const maxCommandCapture = 10000
+
 func handleSendCommand(c *fiber.Ctx) error {
 	id := c.Params("id")
 	var body struct {
-		Command string `json:"command"`
+		Command   string `json:"command"`
+		CaptureMs int    `json:"capture_ms"`
 	}
 	if err := c.BodyParser(&body); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid body"})
 	}
+	if body.CaptureMs > 0 {
+		wait := time.Duration(min(body.CaptureMs, maxCommandCapture)) * time.Millisecond
+		lines, err := server.CaptureCommand(id, body.Command, wait)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.JSON(fiber.Map{"success": true, "output": lines})
+	}
 	if err := server.SendCommand(id, body.Command); err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
@@ -281,6 +332,11 @@ func handleSendCommand(c *fiber.Ctx) error {
 
 func handleStartServer(c *fiber.Ctx) error {
 	id := c.Params("id")
+	op, err := beginOperation(c, id, "start", server.StateStarting)
+	if err != nil {
+		return operationConflict(c, err)
+	}
+	defer op.End()
 	logger.Info("Starting server %s", id)
 
 	var cfg server.ServerConfig
@@ -288,6 +344,7 @@ func handleStartServer(c *fiber.Ctx) error {
 		cfg.ID = id
 		if server.IsDataDirEmpty(id) && cfg.InstallScript != "" {
 			logger.Info("Data empty, running full install for %s", id)
+			op.Transition(server.StateInstalling)
 			if createErr := server.Create(cfg); createErr != nil {
 				logger.Error("Failed to create server %s: %v", id, createErr)
 				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -306,6 +363,7 @@ func handleStartServer(c *fiber.Ctx) error {
 		}
 	}
 
+	op.Transition(server.StateStarting)
 	if err := server.Start(id); err != nil {
 		logger.Error("Start server %s failed: %v", id, err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -331,6 +389,12 @@ func handleStopServer(c *fiber.Ctx) error {
 		timeout = stopCfg.StopTimeout
 	}
 
+	op, err := beginOperation(c, id, "stop", server.StateStopping)
+	if err != nil {
+		return operationConflict(c, err)
+	}
+	defer op.End()
+
 	logger.Info("Stopping server %s (timeout: %ds, command: %s)", id, timeout, stopCfg.StopCommand)
 	if err := server.StopWithConfig(id, timeout, stopCfg.StopCommand, stopCfg.StopSignal); err != nil {
 		logger.Error("Stop server %s failed: %v", id, err)
@@ -344,6 +408,9 @@ func handleStopServer(c *fiber.Ctx) error {
 
 func handleKillServer(c *fiber.Ctx) error {
 	id := c.Params("id")
+	if err := server.CheckOperation(id, "kill", server.StateStarting, server.StateStopping); err != nil {
+		return operationConflict(c, err)
+	}
 	logger.Info("Killing server %s", id)
 	if err := server.Kill(id); err != nil {
 		logger.Error("Kill server %s failed: %v", id, err)
@@ -370,6 +437,12 @@ func handleRestartServer(c *fiber.Ctx) error {
 		timeout = stopCfg.StopTimeout
 	}
 
+	op, err := beginOperation(c, id, "restart", server.StateStopping)
+	if err != nil {
+		return operationConflict(c, err)
+	}
+	defer op.End()
+
 	logger.Info("Restarting server %s (timeout: %ds, command: %s)", id, timeout, stopCfg.StopCommand)
 	if err := server.RestartWithConfig(id, timeout, stopCfg.StopCommand, stopCfg.StopSignal); err != nil {
 		logger.Error("Restart server %s failed: %v", id, err)
@@ -393,6 +466,12 @@ func handleReinstallServer(c *fiber.Ctx) error {
 	id := c.Params("id")
 	cfg.ID = id
 
+	op, err := beginOperation(c, id, "reinstall", server.StateInstalling)
+	if err != nil {
+		return operationConflict(c, err)
+	}
+	defer op.End()
+
 	logger.Info("Reinstalling server %s", id)
 	if err := server.Reinstall(cfg); err != nil {
 		logger.Error("Reinstall server %s failed: %v", id, err)
@@ -402,6 +481,7 @@ func handleReinstallServer(c *fiber.Ctx) error {
 	}
 
 	logger.Info("Server %s reinstalled, starting...", id)
+	op.Transition(server.StateStarting)
 	if err := server.Start(id); err != nil {
 		logger.Error("Server %s start failed: %v", id, err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -413,8 +493,29 @@ func handleReinstallServer(c *fiber.Ctx) error {
 	return c.JSON(fiber.Map{"success": true, "message": "Server reinstalled and started"})
 }
 
+func handleGetInstallLog(c *fiber.Ctx) error {
+	id := c.Params("id")
+	record, log, err := server.GetInstallLog(id)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false, "error": "No install log found",
+		})
+	}
+	return c.JSON(fiber.Map{"success": true, "data": fiber.Map{
+		"record":     record,
+		"log":        log,
+		"installing": server.IsInstalling(id),
+	}})
+}
+
 func handleDeleteServer(c *fiber.Ctx) error {
 	id := c.Params("id")
+	op, err := beginOperation(c, id, "delete", server.StateStopping)
+	if err != nil {
+		return operationConflict(c, err)
+	}
+	defer op.End()
+
 	logger.Info("Deleting server %s", id)
 	if err := server.Delete(id); err != nil {
 		logger.Error("Delete server %s failed: %v", id, err)
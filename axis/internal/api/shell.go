@@ -0,0 +1,111 @@
+package api
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"cauthon-axis/internal/server"
+
+	"github.com/gofiber/contrib/websocket"
+)
+
+const (
+	shellIdleDefault = 10 * time.Minute
+	shellIdleMax     = time.Hour
+)
+
+// handleServerShell opens an interactive shell in the server's container. The
+// panel decides who may use it; Axis only enforces the idle timeout.
+func handleServerShell(c *websocket.Conn) {
+	s := newWSSession(c)
+	s.keepAlive()
+	defer s.close()
+
+	idle := shellIdleDefault
+	if secs, err := strconv.Atoi(c.Query("idle_timeout")); err == nil && secs > 0 {
+		idle = time.Duration(secs) * time.Second
+	}
+	if idle > shellIdleMax {
+		idle = shellIdleMax
+	}
+
+	shell, err := server.OpenShell(s.serverID)
+	if err != nil {
+		s.send(map[string]interface{}{"type": "error", "error": err.Error()})
+		return
+	}
+	defer shell.Close()
+
+	if cols, rows := c.Query("cols"), c.Query("rows"); cols != "" && rows != "" {
+		w, _ := strconv.Atoi(cols)
+		h, _ := strconv.Atoi(rows)
+		if w > 0 && h > 0 {
+			shell.Resize(uint(w), uint(h))
+		}
+	}
+
+	activity := make(chan struct{}, 1)
+	go func() {
+		timer := time.NewTimer(idle)
+		defer timer.Stop()
+		for {
+			select {
+			case <-s.done:
+				return
+			case <-activity:
+				if !timer.Stop() {
+					<-timer.C
+				}
+				timer.Reset(idle)
+			case <-timer.C:
+				s.send(map[string]interface{}{"type": "error", "error": "session closed after being idle"})
+				shell.Close()
+				return
+			}
+		}
+	}()
+
+	go func() {
+		buf := make([]byte, 32*1024)
+		for {
+			n, err := shell.Read(buf)
+			if n > 0 {
+				if s.send(map[string]interface{}{"type": "output", "data": string(buf[:n])}) != nil {
+					break
+				}
+			}
+			if err != nil {
+				s.send(map[string]interface{}{"type": "exit", "code": shell.ExitCode()})
+				break
+			}
+		}
+		s.close()
+		c.Close()
+	}()
+
+	for {
+		_, msg, err := c.ReadMessage()
+		if err != nil {
+			return
+		}
+		var req consoleRequest
+		if json.Unmarshal(msg, &req) != nil {
+			continue
+		}
+		select {
+		case activity <- struct{}{}:
+		default:
+		}
+		switch req.Type {
+		case "input":
+			if _, err := shell.Write([]byte(req.Data)); err != nil {
+				return
+			}
+		case "resize":
+			if req.Cols > 0 && req.Rows > 0 {
+				shell.Resize(req.Cols, req.Rows)
+			}
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"cauthon-axis/internal/logger"
+	"cauthon-axis/internal/server"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type syncRequest struct {
+	Servers       []server.ServerConfig `json:"servers"`
+	RemoveOrphans []string              `json:"remove_orphans"`
+	Recreate      []string              `json:"recreate"`
+}
+
+func handleSync(c *fiber.Ctx) error {
+	var req syncRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false, "error": "Invalid request body",
+		})
+	}
+
+	report, err := server.ComputeDrift(req.Servers)
+	if err != nil {
+		logger.Error("Sync failed: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false, "error": err.Error(),
+		})
+	}
+	return c.JSON(fiber.Map{"success": true, "data": report})
+}
+
+func handleSyncApply(c *fiber.Ctx) error {
+	var req syncRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false, "error": "Invalid request body",
+		})
+	}
+
+	result, err := server.ApplyDrift(req.Servers, req.RemoveOrphans, req.Recreate)
+	if err != nil {
+		logger.Error("Sync apply failed: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false, "error": err.Error(),
+		})
+	}
+
+	report, _ := server.ComputeDrift(req.Servers)
+	return c.JSON(fiber.Map{"success": true, "data": fiber.Map{
+		"result": result,
+		"drift":  report,
+	}})
+}
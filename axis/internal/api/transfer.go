@@ -3,7 +3,6 @@ package api
 import (
 	"io"
 	"mime/multipart"
-	"net/http"
 	"os"
 	"path/filepath"
 
@@ -13,11 +12,40 @@ import (
 	"github.com/gofiber/fiber/v2"
 )
 
+// archiveClone returns the clone an archive request is for, from the clone
+// query parameter. Requests without it are for a transfer.
+func archiveClone(c *fiber.Ctx) (string, error) {
+	cloneID := c.Query("clone")
+	if cloneID == "" {
+		return "", nil
+	}
+	if err := server.ValidateServerID(cloneID); err != nil {
+		return "", c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false, "error": "invalid clone ID",
+		})
+	}
+	return cloneID, nil
+}
+
 func handleCreateArchive(c *fiber.Ctx) error {
 	id := c.Params("id")
+	cloneID, err := archiveClone(c)
+	if err != nil {
+		return err
+	}
+	opName := "transfer"
+	if cloneID != "" {
+		opName = "clone"
+	}
+	op, err := beginOperation(c, id, opName, server.StateTransferring)
+	if err != nil {
+		return operationConflict(c, err)
+	}
+	defer op.End()
+
 	logger.Transfer("Creating archive for server %s", id)
 
-	archivePath, err := server.ArchiveServer(id)
+	archivePath, err := server.ArchiveServer(id, cloneID)
 	if err != nil {
 		logger.Error("Failed to create archive for %s: %v", id, err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -26,20 +54,32 @@ func handleCreateArchive(c *fiber.Ctx) error {
 	}
 
 	info, _ := os.Stat(archivePath)
+	checksum, err := server.FileChecksum(archivePath)
+	if err != nil {
+		logger.Error("Failed to checksum archive for %s: %v", id, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false, "error": err.Error(),
+		})
+	}
 	logger.Success("Archive created for %s: %d bytes", id, info.Size())
 	return c.JSON(fiber.Map{
 		"success": true,
 		"data": fiber.Map{
-			"size": info.Size(),
+			"size":     info.Size(),
+			"checksum": checksum,
 		},
 	})
 }
 
 func handleDownloadArchive(c *fiber.Ctx) error {
 	id := c.Params("id")
+	cloneID, err := archiveClone(c)
+	if err != nil {
+		return err
+	}
 	logger.Transfer("Download archive requested for server %s", id)
 
-	path, err := server.GetArchivePath(id)
+	path, err := server.GetArchivePath(id, cloneID)
 	if err != nil {
 		logger.Error("Archive not found for %s: %v", id, err)
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
@@ -47,16 +87,20 @@ func handleDownloadArchive(c *fiber.Ctx) error {
 		})
 	}
 
-	c.Set("Content-Disposition", "attachment; filename=\""+id+"-transfer.tar.gz\"")
+	c.Set("Content-Disposition", "attachment; filename=\""+filepath.Base(path)+"\"")
 	c.Set("Content-Type", "application/gzip")
 	return c.SendFile(path)
 }
 
 func handleDeleteArchive(c *fiber.Ctx) error {
 	id := c.Params("id")
+	cloneID, err := archiveClone(c)
+	if err != nil {
+		return err
+	}
 	logger.Transfer("Deleting archive for server %s", id)
 
-	if err := server.DeleteArchive(id); err != nil {
+	if err := server.DeleteArchive(id, cloneID); err != nil {
 		logger.Error("Failed to delete archive for %s: %v", id, err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"success": false, "error": err.Error(),
@@ -68,12 +112,19 @@ func handleDeleteArchive(c *fiber.Ctx) error {
 
 func handleImportServer(c *fiber.Ctx) error {
 	id := c.Params("id")
+	op, err := beginOperation(c, id, "import", server.StateTransferring)
+	if err != nil {
+		return operationConflict(c, err)
+	}
 
 	var req struct {
-		URL   string `json:"url"`
-		Token string `json:"token"`
+		URL      string `json:"url"`
+		Token    string `json:"token"`
+		Checksum string `json:"checksum"`
+		Size     int64  `json:"size"`
 	}
 	if err := c.BodyParser(&req); err != nil || req.URL == "" {
+		defer op.End()
 		file, err := c.FormFile("archive")
 		if err != nil {
 			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
@@ -83,42 +134,40 @@ func handleImportServer(c *fiber.Ctx) error {
 		return handleImportFromFile(c, id, file)
 	}
 
-	return handleImportFromURL(c, id, req.URL, req.Token)
-}
-
-func handleImportFromURL(c *fiber.Ctx, id, url, token string) error {
 	logger.Transfer("Import from URL started for server %s", id)
-	logger.Transfer("Fetching archive from %s", url)
-	logger.Transfer("Using token: %s...", token[:min(len(token), 10)])
+	logger.Transfer("Fetching archive from %s", req.URL)
 
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+	src := server.ImportSource{URL: req.URL, Token: req.Token, Checksum: req.Checksum, Size: req.Size}
+	if err := server.StartImport(id, src, op.End); err != nil {
+		op.End()
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
 			"success": false, "error": err.Error(),
 		})
 	}
-	if token != "" {
-		req.Header.Set("Authorization", "Bearer "+token)
-	}
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{"success": true})
+}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		logger.Error("Failed to fetch archive for %s: %v", id, err)
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"success": false, "error": "failed to fetch archive: " + err.Error(),
+func handleImportStatus(c *fiber.Ctx) error {
+	status, ok := server.GetImport(c.Params("id"))
+	if !ok {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false, "error": "no import for this server",
 		})
 	}
-	defer resp.Body.Close()
+	return c.JSON(fiber.Map{"success": true, "data": status})
+}
 
-	if resp.StatusCode >= 400 {
-		logger.Error("Source returned status %d for %s", resp.StatusCode, id)
+func handleImportFromFile(c *fiber.Ctx, id string, file *multipart.FileHeader) error {
+	logger.Transfer("Import from file started for server %s", id)
+	logger.Transfer("Received archive: %d bytes", file.Size)
+
+	src, err := file.Open()
+	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"success": false, "error": "source node returned status " + resp.Status,
+			"success": false, "error": err.Error(),
 		})
 	}
-
-	logger.Transfer("Downloading archive for %s: %d bytes", id, resp.ContentLength)
+	defer src.Close()
 
 	tmpPath := filepath.Join(os.TempDir(), id+"-import.tar.gz")
 	dst, err := os.Create(tmpPath)
@@ -128,16 +177,14 @@ func handleImportFromURL(c *fiber.Ctx, id, url, token string) error {
 		})
 	}
 
-	written, err := io.Copy(dst, resp.Body)
-	dst.Close()
-	if err != nil {
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
 		os.Remove(tmpPath)
-		logger.Error("Failed to download archive for %s: %v", id, err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"success": false, "error": err.Error(),
 		})
 	}
-	logger.Transfer("Downloaded %d bytes for %s", written, id)
+	dst.Close()
 
 	logger.Transfer("Extracting archive for %s", id)
 	if err := server.ImportServer(id, tmpPath); err != nil {
@@ -153,45 +200,65 @@ func handleImportFromURL(c *fiber.Ctx, id, url, token string) error {
 	return c.JSON(fiber.Map{"success": true})
 }
 
-func handleImportFromFile(c *fiber.Ctx, id string, file *multipart.FileHeader) error {
-	logger.Transfer("Import from file started for server %s", id)
-	logger.Transfer("Received archive: %d bytes", file.Size)
-
-	src, err := file.Open()
+func handleSyncManifest(c *fiber.Ctx) error {
+	id := c.Params("id")
+	entries, err := server.BuildManifest(id)
 	if err != nil {
+		logger.Error("Failed to build manifest for %s: %v", id, err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"success": false, "error": err.Error(),
 		})
 	}
-	defer src.Close()
+	return c.JSON(fiber.Map{"success": true, "data": entries})
+}
 
-	tmpPath := filepath.Join(os.TempDir(), id+"-import.tar.gz")
-	dst, err := os.Create(tmpPath)
+func handleSyncFile(c *fiber.Ctx) error {
+	id := c.Params("id")
+	f, info, err := server.OpenSyncFile(id, c.Query("path"))
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"success": false, "error": err.Error(),
 		})
 	}
+	c.Set("Content-Type", "application/octet-stream")
+	return c.SendStream(f, int(info.Size()))
+}
 
-	if _, err := io.Copy(dst, src); err != nil {
-		dst.Close()
-		os.Remove(tmpPath)
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"success": false, "error": err.Error(),
+func handleStartSync(c *fiber.Ctx) error {
+	id := c.Params("id")
+	var req struct {
+		URL   string `json:"url"`
+		Token string `json:"token"`
+		Final bool   `json:"final"`
+	}
+	if err := c.BodyParser(&req); err != nil || req.URL == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false, "error": "url required",
 		})
 	}
-	dst.Close()
 
-	logger.Transfer("Extracting archive for %s", id)
-	if err := server.ImportServer(id, tmpPath); err != nil {
-		os.Remove(tmpPath)
-		logger.Error("Failed to import server %s: %v", id, err)
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+	op, err := beginOperation(c, id, "sync", server.StateTransferring)
+	if err != nil {
+		return operationConflict(c, err)
+	}
+
+	logger.Transfer("Sync started for server %s from %s (final: %v)", id, req.URL, req.Final)
+	src := server.SyncSource{URL: req.URL, Token: req.Token, Final: req.Final}
+	if err := server.StartSync(id, src, op.End); err != nil {
+		op.End()
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
 			"success": false, "error": err.Error(),
 		})
 	}
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{"success": true})
+}
 
-	os.Remove(tmpPath)
-	logger.Success("Import complete for server %s", id)
-	return c.JSON(fiber.Map{"success": true})
+func handleSyncStatus(c *fiber.Ctx) error {
+	status, ok := server.GetSync(c.Params("id"))
+	if !ok {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false, "error": "no sync for this server",
+		})
+	}
+	return c.JSON(fiber.Map{"success": true, "data": status})
 }
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"cauthon-axis/internal/server"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func handleListTrash(c *fiber.Ctx) error {
+	id := c.Params("id")
+	entries, err := server.ListTrash(id)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"success": true, "data": entries})
+}
+
+func handleRestoreTrash(c *fiber.Ctx) error {
+	id := c.Params("id")
+	var body struct {
+		IDs []string `json:"ids"`
+	}
+	if err := c.BodyParser(&body); err != nil || len(body.IDs) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "ids required"})
+	}
+
+	restored, err := server.RestoreTrash(id, body.IDs)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"success": true, "data": fiber.Map{"restored": restored}})
+}
+
+func handlePurgeTrash(c *fiber.Ctx) error {
+	id := c.Params("id")
+	var body struct {
+		IDs []string `json:"ids"`
+		All bool     `json:"all"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid request body"})
+	}
+	if len(body.IDs) == 0 && !body.All {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "ids or all required"})
+	}
+	if body.All {
+		body.IDs = nil
+	}
+
+	purged, err := server.PurgeTrash(id, body.IDs)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"success": true, "data": fiber.Map{"purged": purged}})
+}
@@ -1,8 +1,8 @@
 package api
 
 import (
-	"bufio"
-	"io"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -19,27 +19,156 @@ const (
 	pingInterval   = 30 * time.Second
 	pongTimeout    = 60 * time.Second
 	writeTimeout   = 10 * time.Second
+
+	consoleProtocol    = 2
+	consoleReplayLines = 100
+	consoleBatchLines  = 200
+	consoleBatchMin    = 20 * time.Millisecond
+	consoleBatchMax    = time.Second
+	consoleBatchWindow = 50 * time.Millisecond
 )
 
+type wsSession struct {
+	c         *websocket.Conn
+	serverID  string
+	done      chan struct{}
+	closeOnce sync.Once
+	writeMu   sync.Mutex
+}
+
+func newWSSession(c *websocket.Conn) *wsSession {
+	return &wsSession{c: c, serverID: c.Params("id"), done: make(chan struct{})}
+}
+
+func (s *wsSession) close() { s.closeOnce.Do(func() { close(s.done) }) }
+
+func (s *wsSession) send(v interface{}) error {
+	msg, _ := json.Marshal(v)
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	s.c.SetWriteDeadline(time.Now().Add(writeTimeout))
+	return s.c.WriteMessage(websocket.TextMessage, msg)
+}
+
+func (s *wsSession) keepAlive() {
+	s.c.SetReadDeadline(time.Now().Add(pongTimeout))
+	s.c.SetPongHandler(func(string) error {
+		s.c.SetReadDeadline(time.Now().Add(pongTimeout))
+		return nil
+	})
+
+	go func() {
+		ticker := time.NewTicker(pingInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.done:
+				return
+			case <-ticker.C:
+				s.writeMu.Lock()
+				s.c.SetWriteDeadline(time.Now().Add(writeTimeout))
+				err := s.c.WriteMessage(websocket.PingMessage, nil)
+				s.writeMu.Unlock()
+				if err != nil {
+					s.close()
+					return
+				}
+			}
+		}
+	}()
+}
+
+// streamStatus sends the current status, power state and stats, then pushes
+// changes and running stats until the session ends.
+func (s *wsSession) streamStatus() {
+	lastStatus, _ := server.GetStatus(s.serverID)
+	s.send(map[string]interface{}{"type": "status", "status": lastStatus})
+	if lastStatus == "running" {
+		if stats, err := server.GetStats(s.serverID); err == nil {
+			s.send(map[string]interface{}{"type": "stats", "stats": stats})
+		}
+	}
+
+	info := server.GetPowerState(s.serverID)
+	s.send(map[string]interface{}{"type": "state", "state": info.State, "operation": info.Operation})
+	stateCh := server.WatchState(s.serverID)
+
+	go func() {
+		ticker := time.NewTicker(statusInterval)
+		defer ticker.Stop()
+		defer server.UnwatchState(s.serverID, stateCh)
+		for {
+			select {
+			case <-s.done:
+				return
+			case change := <-stateCh:
+				s.send(map[string]interface{}{
+					"type":      "state",
+					"state":     change.To,
+					"from":      change.From,
+					"operation": change.Operation,
+					"error":     change.Error,
+					"at":        change.At,
+				})
+			case <-ticker.C:
+				status, _ := server.GetStatus(s.serverID)
+				if status != lastStatus {
+					lastStatus = status
+					s.send(map[string]interface{}{"type": "status", "status": status})
+				}
+				if status == "running" {
+					if stats, err := server.GetStats(s.serverID); err == nil {
+						s.send(map[string]interface{}{"type": "stats", "stats": stats})
+					}
+				}
+			}
+		}
+	}()
+}
+
+type consoleRequest struct {
+	Type    string   `json:"type"`
+	Command string   `json:"command"`
+	Data    string   `json:"data"`
+	Cols    uint     `json:"cols"`
+	Rows    uint     `json:"rows"`
+	Levels  []string `json:"levels"`
+	Pattern string   `json:"pattern"`
+}
+
+// handleInput forwards commands, raw keystrokes and resizes to the server's
+// shared TTY attach.
+func (s *wsSession) handleInput(req consoleRequest) {
+	var err error
+	switch req.Type {
+	case "command":
+		err = server.SendCommand(s.serverID, req.Command)
+	case "input":
+		err = server.WriteConsoleInput(s.serverID, []byte(req.Data))
+	case "resize":
+		err = server.ResizeConsole(s.serverID, req.Cols, req.Rows)
+	default:
+		return
+	}
+	if err != nil && req.Type != "command" {
+		s.send(map[string]interface{}{"type": "error", "error": err.Error()})
+	}
+}
+
 func handleServerLogs(c *websocket.Conn) {
-	serverID := c.Params("id")
-	done := make(chan struct{})
-	var closeOnce sync.Once
-	var writeMu sync.Mutex
-	var statusMu sync.Mutex
-	closeDone := func() { closeOnce.Do(func() { close(done) }) }
+	if c.Query("protocol") == strconv.Itoa(consoleProtocol) {
+		handleConsoleV2(c)
+		return
+	}
+
+	s := newWSSession(c)
+	s.keepAlive()
 
 	var logCount int
 	var logWindowStart time.Time
 	var throttleNotified bool
 	var throttleMu sync.Mutex
 
-	c.SetReadDeadline(time.Now().Add(pongTimeout))
-	c.SetPongHandler(func(string) error {
-		c.SetReadDeadline(time.Now().Add(pongTimeout))
-		return nil
-	})
-
 	sendLog := func(line string) {
 		throttleMu.Lock()
 		now := time.Now()
@@ -53,11 +182,7 @@ func handleServerLogs(c *websocket.Conn) {
 			if !throttleNotified {
 				throttleNotified = true
 				throttleMu.Unlock()
-				msg, _ := json.Marshal(map[string]interface{}{"type": "log", "data": "\x1b[36m[Birdactyl] Log output throttled - too many messages\x1b[0m"})
-				writeMu.Lock()
-				c.SetWriteDeadline(time.Now().Add(writeTimeout))
-				c.WriteMessage(websocket.TextMessage, msg)
-				writeMu.Unlock()
+				s.send(map[string]interface{}{"type": "log", "data": "\x1b[36m[Birdactyl] Log output throttled - too many messages\x1b[0m"})
 				return
 			}
 			throttleMu.Unlock()
@@ -65,66 +190,24 @@ func handleServerLogs(c *websocket.Conn) {
 		}
 		throttleMu.Unlock()
 
-		msg, _ := json.Marshal(map[string]interface{}{"type": "log", "data": line})
-		writeMu.Lock()
-		c.SetWriteDeadline(time.Now().Add(writeTimeout))
-		c.WriteMessage(websocket.TextMessage, msg)
-		writeMu.Unlock()
+		s.send(map[string]interface{}{"type": "log", "data": line})
 	}
 
-	broadcastCh := server.SubscribeLogs(serverID)
-	defer server.UnsubscribeLogs(serverID, broadcastCh)
+	broadcastCh := server.SubscribeLogs(s.serverID)
+	defer server.UnsubscribeLogs(s.serverID, broadcastCh)
 
-	if logs, err := server.GetLogs(serverID, "100", false); err == nil {
-		scanner := bufio.NewScanner(logs)
-		for scanner.Scan() {
-			line := scanner.Bytes()
-			if len(line) > 8 {
-				line = line[8:]
-			}
-			msg, _ := json.Marshal(map[string]interface{}{"type": "log", "data": string(line)})
-			c.WriteMessage(websocket.TextMessage, msg)
+	if lines, err := server.GetLogLines(s.serverID, consoleReplayLines); err == nil {
+		for _, line := range lines {
+			s.send(map[string]interface{}{"type": "log", "data": line})
 		}
-		logs.Close()
 	}
 
-	initialStatus, _ := server.GetStatus(serverID)
-	lastStatus := initialStatus
-
-	statusMsg, _ := json.Marshal(map[string]interface{}{"type": "status", "status": initialStatus})
-	c.WriteMessage(websocket.TextMessage, statusMsg)
-
-	if initialStatus == "running" {
-		if stats, err := server.GetStats(serverID); err == nil {
-			statsMsg, _ := json.Marshal(map[string]interface{}{"type": "stats", "stats": stats})
-			c.WriteMessage(websocket.TextMessage, statsMsg)
-		}
-	}
-
-	go func() {
-		ticker := time.NewTicker(pingInterval)
-		defer ticker.Stop()
-		for {
-			select {
-			case <-done:
-				return
-			case <-ticker.C:
-				writeMu.Lock()
-				c.SetWriteDeadline(time.Now().Add(writeTimeout))
-				if err := c.WriteMessage(websocket.PingMessage, nil); err != nil {
-					writeMu.Unlock()
-					closeDone()
-					return
-				}
-				writeMu.Unlock()
-			}
-		}
-	}()
+	s.streamStatus()
 
 	go func() {
 		for {
 			select {
-			case <-done:
+			case <-s.done:
 				return
 			case line, ok := <-broadcastCh:
 				if !ok {
@@ -135,112 +218,141 @@ func handleServerLogs(c *websocket.Conn) {
 		}
 	}()
 
-	go func() {
-		var currentLogs io.ReadCloser
-		var logDone chan struct{}
-		wasStreaming := false
+	for {
+		c.SetReadDeadline(time.Now().Add(pongTimeout))
+		_, msg, err := c.ReadMessage()
+		if err != nil {
+			s.close()
+			return
+		}
+		var req consoleRequest
+		if json.Unmarshal(msg, &req) == nil {
+			s.handleInput(req)
+		}
+	}
+}
 
-		defer func() {
-			if logDone != nil {
-				close(logDone)
-			}
-			if currentLogs != nil {
-				currentLogs.Close()
+// streamTTY forwards raw terminal output once the log replay is done.
+func (s *wsSession) streamTTY(ch chan []byte) {
+	for {
+		select {
+		case <-s.done:
+			return
+		case chunk := <-ch:
+			if s.send(map[string]interface{}{"type": "output", "data": string(chunk)}) != nil {
+				return
 			}
-		}()
+		}
+	}
+}
+
+func splitLevels(v string) []string {
+	if v == "" {
+		return nil
+	}
+	return strings.Split(v, ",")
+}
+
+// handleConsoleV2 serves the sequenced console protocol described in
+// docs/panel/console-protocol.md.
+func handleConsoleV2(c *websocket.Conn) {
+	s := newWSSession(c)
+	s.keepAlive()
+
+	batch := consoleBatchWindow
+	if ms, err := strconv.Atoi(c.Query("batch_ms")); err == nil {
+		batch = time.Duration(ms) * time.Millisecond
+	}
+	if batch < consoleBatchMin {
+		batch = consoleBatchMin
+	} else if batch > consoleBatchMax {
+		batch = consoleBatchMax
+	}
+
+	var filterMu sync.Mutex
+	filter, err := server.NewConsoleFilter(splitLevels(c.Query("levels")), c.Query("pattern"))
+	if err != nil {
+		s.send(map[string]interface{}{"type": "error", "error": "invalid pattern: " + err.Error()})
+		return
+	}
+
+	watch := server.WatchConsole(s.serverID)
+	defer server.UnwatchConsole(s.serverID, watch)
+
+	tty := c.Query("tty") == "1"
+	var ttyCh chan []byte
+	var ttyErr error
+	if tty {
+		ttyCh, ttyErr = server.SubscribeTTY(s.serverID)
+		defer server.UnsubscribeTTY(s.serverID, ttyCh)
+	}
+
+	seq, _ := strconv.ParseUint(c.Query("seq"), 10, 64)
+	cursor := server.OpenConsoleCursor(s.serverID, c.Query("epoch"), seq, consoleReplayLines)
+	s.send(map[string]interface{}{
+		"type":     "hello",
+		"protocol": consoleProtocol,
+		"epoch":    cursor.Epoch,
+		"seq":      cursor.Head,
+		"resumed":  cursor.Resumed,
+		"batch_ms": batch.Milliseconds(),
+		"tty":      tty,
+	})
+	if ttyErr != nil {
+		s.send(map[string]interface{}{"type": "error", "error": "tty attach failed: " + ttyErr.Error()})
+	}
+
+	s.streamStatus()
 
+	go func() {
+		defer s.close()
+		pos := cursor.Seq
 		for {
-			select {
-			case <-done:
+			if tty && pos >= cursor.Head {
+				s.streamTTY(ttyCh)
 				return
-			default:
 			}
-
-			status, _ := server.GetStatus(serverID)
-			shouldStream := status == "running"
-
-			if shouldStream && !wasStreaming {
-				if currentLogs != nil {
-					currentLogs.Close()
-					currentLogs = nil
+			entries, dropped, next := server.ReadConsole(s.serverID, pos, consoleBatchLines)
+			if dropped > 0 {
+				if s.send(map[string]interface{}{"type": "dropped", "from": pos + 1, "to": pos + dropped, "count": dropped}) != nil {
+					return
 				}
-				if logDone != nil {
-					close(logDone)
+			}
+
+			filterMu.Lock()
+			f := filter
+			filterMu.Unlock()
+			lines := make([]server.ConsoleEntry, 0, len(entries))
+			for _, e := range entries {
+				if f.Match(e.Line) {
+					lines = append(lines, e)
 				}
-				logDone = make(chan struct{})
-
-				logs, err := server.GetLogs(serverID, "50", true)
-				if err == nil {
-					currentLogs = logs
-					go func(r io.ReadCloser, stopCh chan struct{}) {
-						defer r.Close()
-						scanner := bufio.NewScanner(r)
-						for scanner.Scan() {
-							select {
-							case <-done:
-								return
-							case <-stopCh:
-								return
-							default:
-							}
-							line := scanner.Bytes()
-							if len(line) > 8 {
-								line = line[8:]
-							}
-							sendLog(string(line))
-						}
-						wasStreaming = false
-					}(logs, logDone)
+			}
+			if len(lines) > 0 {
+				if s.send(map[string]interface{}{"type": "logs", "seq": next, "lines": lines}) != nil {
+					return
 				}
-			} else if !shouldStream && wasStreaming {
-				if currentLogs != nil {
-					currentLogs.Close()
-					currentLogs = nil
+			}
+			pos = next
+
+			if tty {
+				if len(entries) == 0 {
+					s.streamTTY(ttyCh)
+					return
 				}
-				if logDone != nil {
-					close(logDone)
-					logDone = nil
+				continue
+			}
+			if len(entries) < consoleBatchLines {
+				select {
+				case <-s.done:
+					return
+				case <-watch:
 				}
 			}
-			wasStreaming = shouldStream
-			time.Sleep(1 * time.Second)
-		}
-	}()
-
-	go func() {
-		ticker := time.NewTicker(statusInterval)
-		defer ticker.Stop()
-		for {
 			select {
-			case <-done:
+			case <-s.done:
 				return
-			case <-ticker.C:
-				status, _ := server.GetStatus(serverID)
-				statusMu.Lock()
-				changed := status != lastStatus
-				if changed {
-					lastStatus = status
-				}
-				statusMu.Unlock()
-
-				if changed {
-					msg, _ := json.Marshal(map[string]interface{}{"type": "status", "status": status})
-					writeMu.Lock()
-					c.SetWriteDeadline(time.Now().Add(writeTimeout))
-					c.WriteMessage(websocket.TextMessage, msg)
-					writeMu.Unlock()
-				}
-
-				if status == "running" {
-					stats, err := server.GetStats(serverID)
-					if err == nil {
-						msg, _ := json.Marshal(map[string]interface{}{"type": "stats", "stats": stats})
-						writeMu.Lock()
-						c.SetWriteDeadline(time.Now().Add(writeTimeout))
-						c.WriteMessage(websocket.TextMessage, msg)
-						writeMu.Unlock()
-					}
-				}
+			case <-time.After(batch):
 			}
 		}
 	}()
@@ -249,15 +361,25 @@ func handleServerLogs(c *websocket.Conn) {
 		c.SetReadDeadline(time.Now().Add(pongTimeout))
 		_, msg, err := c.ReadMessage()
 		if err != nil {
-			closeDone()
+			s.close()
 			return
 		}
-		var cmd struct {
-			Type    string `json:"type"`
-			Command string `json:"command"`
+		var req consoleRequest
+		if json.Unmarshal(msg, &req) != nil {
+			continue
 		}
-		if json.Unmarshal(msg, &cmd) == nil && cmd.Type == "command" {
-			server.SendCommand(serverID, cmd.Command)
+		switch req.Type {
+		case "filter":
+			f, err := server.NewConsoleFilter(req.Levels, req.Pattern)
+			if err != nil {
+				s.send(map[string]interface{}{"type": "error", "error": "invalid pattern: " + err.Error()})
+				continue
+			}
+			filterMu.Lock()
+			filter = f
+			filterMu.Unlock()
+		default:
+			s.handleInput(req)
 		}
 	}
 }
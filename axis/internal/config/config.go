@@ -12,6 +12,8 @@ var ErrConfigGenerated = errors.New("config file generated")
 type Config struct {
 	Panel   PanelConfig   `yaml:"panel"`
 	Node    NodeConfig    `yaml:"node"`
+	Trash   TrashConfig   `yaml:"trash"`
+	Console ConsoleConfig `yaml:"console"`
 	Logging LoggingConfig `yaml:"logging"`
 }
 
@@ -25,11 +27,29 @@ type LoggingConfig struct {
 }
 
 type NodeConfig struct {
-	Listen    string `yaml:"listen"`
-	DataDir   string `yaml:"data_dir"`
-	BackupDir string `yaml:"backup_dir"`
-	DisplayIP string `yaml:"display_ip"`
-	SFTPPort  int    `yaml:"sftp_port"`
+	Listen        string `yaml:"listen"`
+	DataDir       string `yaml:"data_dir"`
+	BackupDir     string `yaml:"backup_dir"`
+	TrashDir      string `yaml:"trash_dir"`
+	InstallLogDir string `yaml:"install_log_dir"`
+	StateDir      string `yaml:"state_dir"`
+	ConsoleLogDir string `yaml:"console_log_dir"`
+	DisplayIP     string `yaml:"display_ip"`
+	SFTPPort      int    `yaml:"sftp_port"`
+}
+
+// TrashConfig limits are pointers so an explicit 0, which turns the limit
+// off, can be told apart from a missing key.
+type TrashConfig struct {
+	RetentionHours *int `yaml:"retention_hours"`
+	MaxSizeMB      *int `yaml:"max_size_mb"`
+	SFTP           bool `yaml:"sftp"`
+}
+
+type ConsoleConfig struct {
+	MaxFileSizeMB int `yaml:"max_file_size_mb"`
+	MaxFiles      int `yaml:"max_files"`
+	MaxAgeDays    int `yaml:"max_age_days"`
 }
 
 var cfg *Config
@@ -64,9 +84,38 @@ func Load(path string) (*Config, error) {
 	if cfg.Node.BackupDir == "" {
 		cfg.Node.BackupDir = "/var/lib/birdactyl/backups"
 	}
+	if cfg.Node.TrashDir == "" {
+		cfg.Node.TrashDir = "/var/lib/birdactyl/trash"
+	}
+	if cfg.Node.InstallLogDir == "" {
+		cfg.Node.InstallLogDir = "/var/lib/birdactyl/install_logs"
+	}
+	if cfg.Node.StateDir == "" {
+		cfg.Node.StateDir = "/var/lib/birdactyl/state"
+	}
+	if cfg.Node.ConsoleLogDir == "" {
+		cfg.Node.ConsoleLogDir = "/var/lib/birdactyl/console"
+	}
 	if cfg.Node.SFTPPort == 0 {
 		cfg.Node.SFTPPort = 2022
 	}
+	if cfg.Trash.RetentionHours == nil {
+		hours := 168
+		cfg.Trash.RetentionHours = &hours
+	}
+	if cfg.Trash.MaxSizeMB == nil {
+		size := 1024
+		cfg.Trash.MaxSizeMB = &size
+	}
+	if cfg.Console.MaxFileSizeMB == 0 {
+		cfg.Console.MaxFileSizeMB = 10
+	}
+	if cfg.Console.MaxFiles == 0 {
+		cfg.Console.MaxFiles = 5
+	}
+	if cfg.Console.MaxAgeDays == 0 {
+		cfg.Console.MaxAgeDays = 14
+	}
 
 	return cfg, nil
 }
@@ -97,9 +146,23 @@ node:
   listen: "0.0.0.0:8443"
   data_dir: "/var/lib/birdactyl/servers"
   backup_dir: "/var/lib/birdactyl/backups"
+  trash_dir: "/var/lib/birdactyl/trash"
+  install_log_dir: "/var/lib/birdactyl/install_logs"
+  state_dir: "/var/lib/birdactyl/state"
+  console_log_dir: "/var/lib/birdactyl/console"
   display_ip: ""
   sftp_port: 2022
 
+trash:
+  retention_hours: 168
+  max_size_mb: 1024
+  sftp: false
+
+console:
+  max_file_size_mb: 10
+  max_files: 5
+  max_age_days: 14
+
 logging:
   file: "logs/axis.log"
 `
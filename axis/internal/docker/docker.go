@@ -201,21 +201,49 @@ func GetContainerStats(ctx context.Context, id string) (*types.StatsJSON, error)
 	return &stats, nil
 }
 
-func SendCommand(ctx context.Context, id string, command string) error {
-	hijacked, err := Client.ContainerAttach(ctx, id, container.AttachOptions{
-		Stdin:  true,
-		Stream: true,
+// AttachConsole attaches to a container's TTY. The detach sequence is set to
+// something no client will type so raw keystrokes like ctrl-p pass through.
+func AttachConsole(ctx context.Context, id string) (types.HijackedResponse, error) {
+	return Client.ContainerAttach(ctx, id, container.AttachOptions{
+		Stdin:      true,
+		Stdout:     true,
+		Stderr:     true,
+		Stream:     true,
+		DetachKeys: "ctrl-@,ctrl-@,ctrl-@",
+	})
+}
+
+func ResizeTTY(ctx context.Context, id string, rows, cols uint) error {
+	return Client.ContainerResize(ctx, id, container.ResizeOptions{Height: rows, Width: cols})
+}
+
+func ExecShell(ctx context.Context, id string, cmd []string) (string, types.HijackedResponse, error) {
+	created, err := Client.ContainerExecCreate(ctx, id, container.ExecOptions{
+		Cmd:          cmd,
+		Tty:          true,
+		AttachStdin:  true,
+		AttachStdout: true,
+		AttachStderr: true,
+		Env:          []string{"TERM=xterm-256color"},
 	})
 	if err != nil {
-		return err
+		return "", types.HijackedResponse{}, err
 	}
-	defer hijacked.Close()
-
-	_, err = hijacked.Conn.Write([]byte(command + "\n"))
+	hijacked, err := Client.ContainerExecAttach(ctx, created.ID, container.ExecAttachOptions{Tty: true})
 	if err != nil {
-		return err
+		return "", types.HijackedResponse{}, err
 	}
+	return created.ID, hijacked, nil
+}
 
-	time.Sleep(100 * time.Millisecond)
-	return nil
+func ResizeExec(ctx context.Context, execID string, rows, cols uint) error {
+	return Client.ContainerExecResize(ctx, execID, container.ResizeOptions{Height: rows, Width: cols})
+}
+
+func ExecExitCode(ctx context.Context, execID string) (int, error) {
+	info, err := Client.ContainerExecInspect(ctx, execID)
+	if err != nil {
+		return 0, err
+	}
+	return info.ExitCode, nil
 }
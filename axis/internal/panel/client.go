@@ -90,3 +90,87 @@ func (c *Client) ValidateSFTPCredentials(serverID, password string) error {
 
 	return nil
 }
+
+func (c *Client) ReportInstallFinished(serverID string, success bool, exitCode int, durationMs int64, errMsg string) error {
+	payload := map[string]interface{}{
+		"success":     success,
+		"exit_code":   exitCode,
+		"duration_ms": durationMs,
+		"error":       errMsg,
+	}
+
+	body, _ := json.Marshal(payload)
+	req, err := http.NewRequest("POST", c.panelURL+"/api/v1/internal/servers/"+serverID+"/install", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.token)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to connect to panel: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("panel returned status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+func (c *Client) SendReconcileReport(report interface{}) error {
+	body, _ := json.Marshal(report)
+	req, err := http.NewRequest("POST", c.panelURL+"/api/v1/internal/nodes/reconcile", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.token)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to connect to panel: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("panel returned status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+func (c *Client) ReportServerState(serverID, from, to, operation, errMsg string, at int64) error {
+	payload := map[string]interface{}{
+		"from":      from,
+		"state":     to,
+		"operation": operation,
+		"error":     errMsg,
+		"at":        at,
+	}
+
+	body, _ := json.Marshal(payload)
+	req, err := http.NewRequest("POST", c.panelURL+"/api/v1/internal/servers/"+serverID+"/state", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.token)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to connect to panel: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("panel returned status %d", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -2,6 +2,7 @@ package server
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
@@ -205,24 +206,44 @@ func GetBackupPath(serverID, backupID string) (string, error) {
 	return path, nil
 }
 
-func ArchiveServer(serverID string) (string, error) {
-	cfg := config.Get()
-	archiveDir := filepath.Join(cfg.Node.BackupDir, "transfers")
-	if err := os.MkdirAll(archiveDir, 0755); err != nil {
+// archiveFile returns where a server archive is kept. A transfer uses one
+// archive per server; each clone gets its own, named after the clone, so
+// concurrent clones of one server never share a file.
+func archiveFile(serverID, cloneID string) string {
+	name := fmt.Sprintf("%s-transfer.tar.gz", serverID)
+	if cloneID != "" {
+		name = fmt.Sprintf("%s-clone-%s.tar.gz", serverID, cloneID)
+	}
+	return filepath.Join(config.Get().Node.BackupDir, "transfers", name)
+}
+
+// ArchiveServer packs the server's files for a transfer, or for the clone
+// cloneID when it is set.
+func ArchiveServer(serverID, cloneID string) (string, error) {
+	archivePath := archiveFile(serverID, cloneID)
+	if err := os.MkdirAll(filepath.Dir(archivePath), 0755); err != nil {
 		return "", err
 	}
 
-	archivePath := filepath.Join(archiveDir, fmt.Sprintf("%s-transfer.tar.gz", serverID))
 	srcDir := serverDataDir(serverID)
 
 	if _, err := os.Stat(srcDir); os.IsNotExist(err) {
 		return "", fmt.Errorf("server data directory not found")
 	}
 
-	cmd := exec.Command("tar", "-czf", archivePath, "-C", srcDir, ".")
+	args := []string{"-czf", archivePath, "-C", srcDir, "."}
+	if cloneID != "" {
+		args = append([]string{"--warning=no-file-changed"}, args...)
+	}
+	cmd := exec.Command("tar", args...)
 	if err := cmd.Run(); err != nil {
-		os.Remove(archivePath)
-		return "", fmt.Errorf("failed to create archive: %v", err)
+		// A clone may archive a running server, so tar exiting with 1
+		// because files changed while it read them is expected there.
+		var exitErr *exec.ExitError
+		if cloneID == "" || !errors.As(err, &exitErr) || exitErr.ExitCode() != 1 {
+			os.Remove(archivePath)
+			return "", fmt.Errorf("failed to create archive: %v", err)
+		}
 	}
 
 	return archivePath, nil
@@ -267,33 +288,45 @@ func RestoreBackup(serverID, backupID string) error {
 	return nil
 }
 
-func GetArchivePath(serverID string) (string, error) {
-	cfg := config.Get()
-	path := filepath.Join(cfg.Node.BackupDir, "transfers", fmt.Sprintf("%s-transfer.tar.gz", serverID))
+func GetArchivePath(serverID, cloneID string) (string, error) {
+	path := archiveFile(serverID, cloneID)
 	if _, err := os.Stat(path); err != nil {
 		return "", fmt.Errorf("archive not found")
 	}
 	return path, nil
 }
 
-func DeleteArchive(serverID string) error {
-	cfg := config.Get()
-	path := filepath.Join(cfg.Node.BackupDir, "transfers", fmt.Sprintf("%s-transfer.tar.gz", serverID))
-	return os.Remove(path)
+func DeleteArchive(serverID, cloneID string) error {
+	return os.Remove(archiveFile(serverID, cloneID))
 }
 
+// ImportServer extracts a transfer archive next to the server directory and
+// only swaps it in once extraction succeeded, so a retried import never sees
+// a half-extracted tree.
 func ImportServer(serverID string, archivePath string) error {
 	destDir := serverDataDir(serverID)
+	stagingDir := destDir + ".import"
 
-	if err := os.MkdirAll(destDir, 0755); err != nil {
+	os.RemoveAll(stagingDir)
+	if err := os.MkdirAll(stagingDir, 0755); err != nil {
 		return fmt.Errorf("failed to create server directory: %v", err)
 	}
 
-	cmd := exec.Command("tar", "-xzf", archivePath, "-C", destDir)
+	cmd := exec.Command("tar", "-xzf", archivePath, "-C", stagingDir)
 	if err := cmd.Run(); err != nil {
+		os.RemoveAll(stagingDir)
 		return fmt.Errorf("failed to extract archive: %v", err)
 	}
 
+	if err := os.RemoveAll(destDir); err != nil {
+		os.RemoveAll(stagingDir)
+		return fmt.Errorf("failed to replace server directory: %v", err)
+	}
+	if err := os.Rename(stagingDir, destDir); err != nil {
+		os.RemoveAll(stagingDir)
+		return fmt.Errorf("failed to replace server directory: %v", err)
+	}
+
 	uid, _ := strconv.Atoi(GetServerUID())
 	filepath.Walk(destDir, func(path string, info os.FileInfo, err error) error {
 		if err == nil {
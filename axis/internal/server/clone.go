@@ -0,0 +1,178 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"cauthon-axis/internal/logger"
+)
+
+// CloneSource is the server a clone copies its files from. URL is the
+// source node's /api/servers/:id base when the source lives on another
+// node and empty when it is on this one.
+type CloneSource struct {
+	ServerID string
+	URL      string
+	Token    string
+}
+
+// StartClone fills the empty data directory of a new server with the files
+// of the source in the background. It runs as an install of kind "clone",
+// so its log and result reach the panel like any other install. finished
+// runs when the clone ends.
+func StartClone(serverID string, src CloneSource, finished func()) error {
+	if !IsDataDirEmpty(serverID) {
+		return fmt.Errorf("server already has files")
+	}
+
+	go func() {
+		defer finished()
+		run := beginInstall(serverID, "clone")
+		err := runClone(serverID, src)
+		if err != nil {
+			logger.Error("Clone of %s into %s failed: %v", src.ServerID, serverID, err)
+			installLog(serverID, fmt.Sprintf("Clone failed: %v", err))
+		} else {
+			logger.Success("Cloned %s into %s", src.ServerID, serverID)
+			installLog(serverID, "Clone completed successfully")
+		}
+		run.finish(err)
+	}()
+	return nil
+}
+
+func runClone(serverID string, src CloneSource) error {
+	if src.URL == "" {
+		installLog(serverID, fmt.Sprintf("Copying files from server %s...", src.ServerID))
+		return copyServerFiles(src.ServerID, serverID)
+	}
+
+	archive := src.URL + "/archive?clone=" + serverID
+	installLog(serverID, "Creating archive on the source node...")
+	var info struct {
+		Size     int64  `json:"size"`
+		Checksum string `json:"checksum"`
+	}
+	if err := cloneRequest("POST", archive, src.Token, &info); err != nil {
+		return fmt.Errorf("failed to create archive: %v", err)
+	}
+	defer func() {
+		if err := cloneRequest("DELETE", archive, src.Token, nil); err != nil {
+			logger.Warn("Failed to delete clone archive of %s: %v", src.ServerID, err)
+		}
+	}()
+
+	installLog(serverID, fmt.Sprintf("Downloading archive (%d bytes)...", info.Size))
+	job := &transferJob{}
+	return job.runImport(serverID, ImportSource{
+		URL:      src.URL + "/archive/download?clone=" + serverID,
+		Token:    src.Token,
+		Checksum: info.Checksum,
+		Size:     info.Size,
+	})
+}
+
+// copyServerFiles copies one server's files into another on this node. The
+// copy is staged next to the destination and renamed into place, so a
+// failed copy leaves the destination empty.
+func copyServerFiles(sourceID, destID string) error {
+	base := serverDataDir(sourceID)
+	if _, err := os.Stat(base); err != nil {
+		return fmt.Errorf("source server data directory not found")
+	}
+	destDir := serverDataDir(destID)
+	staging := destDir + ".import"
+	os.RemoveAll(staging)
+	uid, _ := strconv.Atoi(GetServerUID())
+
+	err := filepath.WalkDir(base, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(base, p)
+		target := filepath.Join(staging, rel)
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+
+		switch {
+		case d.IsDir():
+			if err := os.MkdirAll(target, info.Mode().Perm()|0700); err != nil {
+				return err
+			}
+		case d.Type()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(p)
+			if err != nil {
+				return nil
+			}
+			if err := os.Symlink(link, target); err != nil {
+				return err
+			}
+			os.Lchown(target, uid, uid)
+			return nil
+		case d.Type().IsRegular():
+			if err := copyFile(p, target); err != nil {
+				return fmt.Errorf("%s: %v", rel, err)
+			}
+			os.Chmod(target, info.Mode().Perm())
+		default:
+			return nil
+		}
+		os.Chown(target, uid, uid)
+		os.Chtimes(target, info.ModTime(), info.ModTime())
+		return nil
+	})
+	if err != nil {
+		os.RemoveAll(staging)
+		return err
+	}
+
+	if err := os.RemoveAll(destDir); err != nil {
+		os.RemoveAll(staging)
+		return fmt.Errorf("failed to replace server directory: %v", err)
+	}
+	if err := os.Rename(staging, destDir); err != nil {
+		os.RemoveAll(staging)
+		return fmt.Errorf("failed to replace server directory: %v", err)
+	}
+	return nil
+}
+
+// cloneRequest calls the source node of a clone and decodes the data field
+// of its reply into out.
+func cloneRequest(method, url, token string, out interface{}) error {
+	req, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		return err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := transferClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Error string          `json:"error"`
+		Data  json.RawMessage `json:"data"`
+	}
+	json.NewDecoder(resp.Body).Decode(&result)
+	if resp.StatusCode >= 400 {
+		if result.Error != "" {
+			return fmt.Errorf("source node: %s", result.Error)
+		}
+		return fmt.Errorf("source node returned status %s", resp.Status)
+	}
+	if out != nil && len(result.Data) > 0 {
+		return json.Unmarshal(result.Data, out)
+	}
+	return nil
+}
@@ -0,0 +1,412 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"gopkg.in/yaml.v3"
+)
+
+type ConfigFile struct {
+	Path     string            `json:"path"`
+	Parser   string            `json:"parser"`
+	Template string            `json:"template"`
+	Replace  map[string]string `json:"replace"`
+}
+
+var placeholderRegex = regexp.MustCompile(`\{\{\s*([a-zA-Z0-9_.\-]+)\s*\}\}`)
+
+func applyConfigFiles(cfg *ServerConfig) {
+	if len(cfg.ConfigFiles) == 0 {
+		return
+	}
+
+	values := templateValues(cfg)
+	base := serverDataDir(cfg.ID)
+	uid, _ := strconv.Atoi(serverUID)
+
+	for _, file := range cfg.ConfigFiles {
+		target := filepath.Join(base, filepath.Clean("/"+file.Path))
+		if !strings.HasPrefix(target, base+string(filepath.Separator)) {
+			BroadcastLog(cfg.ID, fmt.Sprintf("Skipping config file %s: invalid path", file.Path))
+			continue
+		}
+
+		if err := applyConfigFile(base, target, file, values, uid); err != nil {
+			BroadcastLog(cfg.ID, fmt.Sprintf("Failed to apply config file %s: %v", file.Path, err))
+		}
+	}
+}
+
+// prepareConfigDir creates the parents of target below base, refusing any
+// that is a symlink, so a config file can never be written outside the
+// server's data directory.
+func prepareConfigDir(base, target string, uid int) error {
+	rel, err := filepath.Rel(base, filepath.Dir(target))
+	if err != nil {
+		return err
+	}
+	dir := base
+	if rel == "." {
+		return nil
+	}
+	for _, part := range strings.Split(rel, string(filepath.Separator)) {
+		dir = filepath.Join(dir, part)
+		info, err := os.Lstat(dir)
+		if os.IsNotExist(err) {
+			if err := os.Mkdir(dir, 0755); err != nil {
+				return err
+			}
+			os.Lchown(dir, uid, uid)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			return fmt.Errorf("%s is a symlink", part)
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("%s is not a directory", part)
+		}
+	}
+	return nil
+}
+
+func readConfigTarget(target string) ([]byte, error) {
+	f, err := os.OpenFile(target, os.O_RDONLY|syscall.O_NOFOLLOW, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
+
+func writeConfigTarget(target string, data []byte, uid int) error {
+	f, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC|syscall.O_NOFOLLOW, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	f.Chown(uid, uid)
+	return f.Close()
+}
+
+func applyConfigFile(base, target string, file ConfigFile, values map[string]string, uid int) error {
+	if err := prepareConfigDir(base, target, uid); err != nil {
+		return err
+	}
+
+	if file.Parser == "" || file.Parser == "file" {
+		return writeConfigTarget(target, []byte(renderTemplate(file.Template, values)), uid)
+	}
+
+	existing, err := readConfigTarget(target)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	replace := make(map[string]string, len(file.Replace))
+	for k, v := range file.Replace {
+		replace[k] = renderTemplate(v, values)
+	}
+
+	var out []byte
+	switch file.Parser {
+	case "properties":
+		out = patchProperties(existing, replace)
+	case "ini":
+		out = patchINI(existing, replace, false)
+	case "toml":
+		out = patchINI(existing, replace, true)
+	case "yaml":
+		out, err = patchYAML(existing, replace)
+	case "json":
+		out, err = patchJSON(existing, replace)
+	default:
+		return fmt.Errorf("unsupported parser %q", file.Parser)
+	}
+	if err != nil {
+		return err
+	}
+	return writeConfigTarget(target, out, uid)
+}
+
+func templateValues(cfg *ServerConfig) map[string]string {
+	values := make(map[string]string, len(cfg.Variables)*3+8)
+	for k, v := range cfg.Variables {
+		values[k] = v
+		values["env."+k] = v
+		values["server.build.env."+k] = v
+	}
+
+	values["server.name"] = cfg.Name
+	values["server.build.memory"] = strconv.Itoa(cfg.Memory)
+	values["server.build.cpu"] = strconv.Itoa(cfg.CPU)
+	values["server.build.disk"] = strconv.Itoa(cfg.Disk)
+	values["server.build.default.ip"] = anyIP
+	if len(cfg.Ports) > 0 {
+		values["server.build.default.ip"] = cfg.Ports[0].BindIP()
+		values["server.build.default.port"] = strconv.Itoa(cfg.Ports[0].Container)
+		values["server.build.default.host_port"] = strconv.Itoa(cfg.Ports[0].Host)
+	}
+	return values
+}
+
+func renderTemplate(tmpl string, values map[string]string) string {
+	return placeholderRegex.ReplaceAllStringFunc(tmpl, func(m string) string {
+		key := placeholderRegex.FindStringSubmatch(m)[1]
+		if v, ok := values[key]; ok {
+			return v
+		}
+		return m
+	})
+}
+
+func splitLines(data []byte) []string {
+	if len(data) == 0 {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+}
+
+func patchProperties(data []byte, replace map[string]string) []byte {
+	lines := splitLines(data)
+	done := make(map[string]bool, len(replace))
+
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "!") {
+			continue
+		}
+		idx := strings.IndexAny(trimmed, "=:")
+		if idx < 0 {
+			continue
+		}
+		key := strings.TrimSpace(trimmed[:idx])
+		if v, ok := replace[key]; ok {
+			lines[i] = key + "=" + v
+			done[key] = true
+		}
+	}
+
+	for _, key := range sortedKeys(replace) {
+		if !done[key] {
+			lines = append(lines, key+"="+replace[key])
+		}
+	}
+	return []byte(strings.Join(lines, "\n") + "\n")
+}
+
+func patchINI(data []byte, replace map[string]string, toml bool) []byte {
+	lines := splitLines(data)
+	done := make(map[string]bool, len(replace))
+	sectionEnd := make(map[string]int)
+	section := ""
+	sectionEnd[""] = 0
+
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]") {
+			section = strings.TrimSpace(strings.Trim(trimmed, "[]"))
+			sectionEnd[section] = i + 1
+			continue
+		}
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, ";") {
+			continue
+		}
+		sectionEnd[section] = i + 1
+
+		idx := strings.Index(trimmed, "=")
+		if idx < 0 {
+			continue
+		}
+		key := strings.TrimSpace(trimmed[:idx])
+		path := key
+		if section != "" {
+			path = section + "." + key
+		}
+		if v, ok := replace[path]; ok {
+			eq := strings.Index(line, "=")
+			prefix := line[:eq+1]
+			if strings.HasSuffix(line[:eq], " ") {
+				prefix += " "
+			}
+			lines[i] = prefix + iniValue(v, toml)
+			done[path] = true
+		}
+	}
+
+	for _, path := range sortedKeys(replace) {
+		if done[path] {
+			continue
+		}
+		sec, key := "", path
+		if idx := strings.LastIndex(path, "."); idx >= 0 {
+			sec, key = path[:idx], path[idx+1:]
+		}
+		entry := key + " = " + iniValue(replace[path], toml)
+		if !toml {
+			entry = key + "=" + replace[path]
+		}
+
+		pos, ok := sectionEnd[sec]
+		if !ok {
+			lines = append(lines, "", "["+sec+"]", entry)
+			sectionEnd[sec] = len(lines)
+			continue
+		}
+		lines = append(lines[:pos], append([]string{entry}, lines[pos:]...)...)
+		for s, end := range sectionEnd {
+			if end >= pos && s != sec {
+				sectionEnd[s] = end + 1
+			}
+		}
+		sectionEnd[sec] = pos + 1
+	}
+	return []byte(strings.Join(lines, "\n") + "\n")
+}
+
+func iniValue(v string, toml bool) string {
+	if !toml {
+		return v
+	}
+	if v == "true" || v == "false" {
+		return v
+	}
+	if _, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return v
+	}
+	if _, err := strconv.ParseFloat(v, 64); err == nil {
+		return v
+	}
+	if strings.HasPrefix(v, "[") || strings.HasPrefix(v, "{") || strings.HasPrefix(v, "\"") || strings.HasPrefix(v, "'") {
+		return v
+	}
+	return strconv.Quote(v)
+}
+
+func patchYAML(data []byte, replace map[string]string) ([]byte, error) {
+	var doc yaml.Node
+	if len(bytes.TrimSpace(data)) > 0 {
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, err
+		}
+	}
+	if doc.Kind == 0 {
+		doc.Kind = yaml.DocumentNode
+		doc.Content = []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("top level of yaml file is not a mapping")
+	}
+
+	for _, path := range sortedKeys(replace) {
+		node := root
+		parts := strings.Split(path, ".")
+		for i, part := range parts {
+			var child *yaml.Node
+			for j := 0; j+1 < len(node.Content); j += 2 {
+				if node.Content[j].Value == part {
+					child = node.Content[j+1]
+					break
+				}
+			}
+			last := i == len(parts)-1
+			if child == nil {
+				child = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+				if last {
+					child = &yaml.Node{Kind: yaml.ScalarNode}
+				}
+				node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: part}, child)
+			}
+			if last {
+				child.Kind = yaml.ScalarNode
+				child.Tag = ""
+				child.Style = 0
+				child.Content = nil
+				child.Value = replace[path]
+				break
+			}
+			if child.Kind != yaml.MappingNode {
+				child.Kind = yaml.MappingNode
+				child.Tag = "!!map"
+				child.Value = ""
+				child.Content = nil
+			}
+			node = child
+		}
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, err
+	}
+	enc.Close()
+	return buf.Bytes(), nil
+}
+
+func patchJSON(data []byte, replace map[string]string) ([]byte, error) {
+	root := map[string]interface{}{}
+	if len(bytes.TrimSpace(data)) > 0 {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		if err := dec.Decode(&root); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, path := range sortedKeys(replace) {
+		node := root
+		parts := strings.Split(path, ".")
+		for _, part := range parts[:len(parts)-1] {
+			child, ok := node[part].(map[string]interface{})
+			if !ok {
+				child = map[string]interface{}{}
+				node[part] = child
+			}
+			node = child
+		}
+		node[parts[len(parts)-1]] = jsonValue(replace[path])
+	}
+
+	out, err := json.MarshalIndent(root, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(out, '\n'), nil
+}
+
+func jsonValue(v string) interface{} {
+	var parsed interface{}
+	dec := json.NewDecoder(strings.NewReader(v))
+	dec.UseNumber()
+	if err := dec.Decode(&parsed); err == nil && !dec.More() {
+		return parsed
+	}
+	return v
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
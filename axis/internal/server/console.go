@@ -0,0 +1,434 @@
+package server
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"cauthon-axis/internal/config"
+	"cauthon-axis/internal/docker"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+const consoleLogName = "console.log"
+
+type consoleLog struct {
+	mu     sync.Mutex
+	file   *os.File
+	size   int64
+	lastTS time.Time
+}
+
+var (
+	consoleLogs       = make(map[string]*consoleLog)
+	consoleLogsMu     sync.Mutex
+	consoleCaptures   = make(map[string]string)
+	consoleCapturesMu sync.Mutex
+)
+
+func init() {
+	go consoleSupervisor()
+	go consoleJanitor()
+}
+
+func consoleDir(serverID string) string {
+	return filepath.Join(config.Get().Node.ConsoleLogDir, serverID)
+}
+
+func getConsoleLog(serverID string) *consoleLog {
+	consoleLogsMu.Lock()
+	defer consoleLogsMu.Unlock()
+	if l, ok := consoleLogs[serverID]; ok {
+		return l
+	}
+	l := &consoleLog{}
+	l.lastTS = lastConsoleTimestamp(filepath.Join(consoleDir(serverID), consoleLogName))
+	consoleLogs[serverID] = l
+	return l
+}
+
+func (l *consoleLog) append(serverID string, ts time.Time, text string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		dir := consoleDir(serverID)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return
+		}
+		f, err := os.OpenFile(filepath.Join(dir, consoleLogName), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			return
+		}
+		info, _ := f.Stat()
+		l.file = f
+		if info != nil {
+			l.size = info.Size()
+		}
+	}
+
+	n, _ := fmt.Fprintf(l.file, "%s %s\n", ts.UTC().Format(time.RFC3339Nano), text)
+	l.size += int64(n)
+	if ts.After(l.lastTS) {
+		l.lastTS = ts
+	}
+
+	if max := int64(config.Get().Console.MaxFileSizeMB) * 1024 * 1024; max > 0 && l.size >= max {
+		l.rotate(serverID)
+	}
+}
+
+func (l *consoleLog) rotate(serverID string) {
+	if l.file != nil {
+		l.file.Close()
+		l.file = nil
+	}
+	l.size = 0
+
+	dir := consoleDir(serverID)
+	archive := filepath.Join(dir, fmt.Sprintf("console-%s.log", time.Now().UTC().Format("20060102-150405.000")))
+	if err := os.Rename(filepath.Join(dir, consoleLogName), archive); err != nil {
+		return
+	}
+	go func() {
+		compressConsoleArchive(archive)
+		pruneConsoleArchives(serverID)
+	}()
+}
+
+func (l *consoleLog) lastTime() time.Time {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.lastTS
+}
+
+func compressConsoleArchive(path string) {
+	src, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer src.Close()
+
+	tmp := path + ".gz.tmp"
+	dst, err := os.Create(tmp)
+	if err != nil {
+		return
+	}
+	gz := gzip.NewWriter(dst)
+	_, copyErr := io.Copy(gz, src)
+	closeErr := gz.Close()
+	dst.Close()
+	if copyErr != nil || closeErr != nil || os.Rename(tmp, path+".gz") != nil {
+		os.Remove(tmp)
+		return
+	}
+	os.Remove(path)
+}
+
+// consoleArchives returns rotated console logs oldest first.
+func consoleArchives(serverID string) []string {
+	entries, err := os.ReadDir(consoleDir(serverID))
+	if err != nil {
+		return nil
+	}
+	var files []string
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, "console-") || !(strings.HasSuffix(name, ".log") || strings.HasSuffix(name, ".log.gz")) {
+			continue
+		}
+		if strings.HasSuffix(name, ".log") && fileExists(filepath.Join(consoleDir(serverID), name+".gz")) {
+			continue
+		}
+		files = append(files, filepath.Join(consoleDir(serverID), name))
+	}
+	sort.Strings(files)
+	return files
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func pruneConsoleArchives(serverID string) {
+	cfg := config.Get().Console
+	archives := consoleArchives(serverID)
+	if cfg.MaxFiles > 0 && len(archives) > cfg.MaxFiles {
+		for _, path := range archives[:len(archives)-cfg.MaxFiles] {
+			os.Remove(path)
+		}
+		archives = archives[len(archives)-cfg.MaxFiles:]
+	}
+	if cfg.MaxAgeDays > 0 {
+		cutoff := time.Now().Add(-time.Duration(cfg.MaxAgeDays) * 24 * time.Hour)
+		for _, path := range archives {
+			if info, err := os.Stat(path); err == nil && info.ModTime().Before(cutoff) {
+				os.Remove(path)
+			}
+		}
+	}
+}
+
+func consoleJanitor() {
+	ticker := time.NewTicker(time.Hour)
+	for range ticker.C {
+		entries, err := os.ReadDir(config.Get().Node.ConsoleLogDir)
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			if e.IsDir() && ValidateServerID(e.Name()) == nil {
+				pruneConsoleArchives(e.Name())
+			}
+		}
+	}
+}
+
+func removeConsoleLogs(serverID string) {
+	consoleLogsMu.Lock()
+	if l, ok := consoleLogs[serverID]; ok {
+		l.mu.Lock()
+		if l.file != nil {
+			l.file.Close()
+		}
+		l.mu.Unlock()
+		delete(consoleLogs, serverID)
+	}
+	consoleLogsMu.Unlock()
+	dropConsoleStream(serverID)
+	os.RemoveAll(consoleDir(serverID))
+}
+
+func parseConsoleLine(line string) (time.Time, string) {
+	if i := strings.IndexByte(line, ' '); i > 0 {
+		if ts, err := time.Parse(time.RFC3339Nano, line[:i]); err == nil {
+			return ts, line[i+1:]
+		}
+	}
+	return time.Time{}, line
+}
+
+func openConsoleFile(path string) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(path, ".gz") {
+		return f, nil
+	}
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{gz, f}, nil
+}
+
+func newLineScanner(r io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	return scanner
+}
+
+func scanConsoleFile(path string, fn func(ts time.Time, text string) bool) bool {
+	r, err := openConsoleFile(path)
+	if err != nil {
+		return true
+	}
+	defer r.Close()
+	scanner := newLineScanner(r)
+	for scanner.Scan() {
+		ts, text := parseConsoleLine(scanner.Text())
+		if !fn(ts, text) {
+			return false
+		}
+	}
+	return true
+}
+
+// eachConsoleLine walks the stored console history oldest first until fn
+// returns false.
+func eachConsoleLine(serverID string, fn func(ts time.Time, text string) bool) {
+	for _, path := range consoleArchives(serverID) {
+		if !scanConsoleFile(path, fn) {
+			return
+		}
+	}
+	scanConsoleFile(filepath.Join(consoleDir(serverID), consoleLogName), fn)
+}
+
+type consoleRecord struct {
+	ts   time.Time
+	text string
+}
+
+func tailConsoleLines(serverID string, n int) []string {
+	records := tailConsoleRecords(serverID, n)
+	lines := make([]string, len(records))
+	for i, r := range records {
+		lines[i] = r.text
+	}
+	return lines
+}
+
+func tailConsoleRecords(serverID string, n int) []consoleRecord {
+	if n <= 0 {
+		return nil
+	}
+	files := append(consoleArchives(serverID), filepath.Join(consoleDir(serverID), consoleLogName))
+	var result []consoleRecord
+	for i := len(files) - 1; i >= 0 && len(result) < n; i-- {
+		ring := make([]consoleRecord, 0, n)
+		scanConsoleFile(files[i], func(ts time.Time, text string) bool {
+			if len(ring) == n {
+				ring = ring[1:]
+			}
+			ring = append(ring, consoleRecord{ts, text})
+			return true
+		})
+		need := n - len(result)
+		if len(ring) > need {
+			ring = ring[len(ring)-need:]
+		}
+		result = append(ring, result...)
+	}
+	return result
+}
+
+func lastConsoleTimestamp(path string) time.Time {
+	f, err := os.Open(path)
+	if err != nil {
+		return time.Time{}
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return time.Time{}
+	}
+	offset := info.Size() - 64*1024
+	if offset < 0 {
+		offset = 0
+	}
+	f.Seek(offset, io.SeekStart)
+	var last time.Time
+	scanner := newLineScanner(f)
+	for scanner.Scan() {
+		if ts, _ := parseConsoleLine(scanner.Text()); !ts.IsZero() {
+			last = ts
+		}
+	}
+	return last
+}
+
+// emitConsoleLine stores a line and hands it to every console listener.
+func emitConsoleLine(serverID string, ts time.Time, text string) {
+	stream := getConsoleStream(serverID)
+	getConsoleLog(serverID).append(serverID, ts, text)
+	stream.push(ts, text)
+	publishLine(serverID, text)
+}
+
+func ensureConsoleCapture(serverID, containerID string) {
+	consoleCapturesMu.Lock()
+	if consoleCaptures[serverID] == containerID {
+		consoleCapturesMu.Unlock()
+		return
+	}
+	consoleCaptures[serverID] = containerID
+	consoleCapturesMu.Unlock()
+
+	go captureConsole(serverID, containerID)
+	go reattachTTY(serverID)
+}
+
+func captureConsole(serverID, containerID string) {
+	defer func() {
+		consoleCapturesMu.Lock()
+		if consoleCaptures[serverID] == containerID {
+			delete(consoleCaptures, serverID)
+		}
+		consoleCapturesMu.Unlock()
+	}()
+
+	ctx := context.Background()
+	info, err := docker.Client.ContainerInspect(ctx, containerID)
+	if err != nil {
+		return
+	}
+
+	since := getConsoleLog(serverID).lastTime()
+	opts := container.LogsOptions{ShowStdout: true, ShowStderr: true, Follow: true, Timestamps: true}
+	if !since.IsZero() {
+		opts.Since = fmt.Sprintf("%d.%09d", since.Unix(), since.Nanosecond())
+	}
+
+	logs, err := docker.Client.ContainerLogs(ctx, containerID, opts)
+	if err != nil {
+		return
+	}
+	defer logs.Close()
+
+	var r io.Reader = logs
+	if info.Config != nil && !info.Config.Tty {
+		pr, pw := io.Pipe()
+		go func() {
+			_, err := stdcopy.StdCopy(pw, pw, logs)
+			pw.CloseWithError(err)
+		}()
+		r = pr
+	}
+
+	scanner := newLineScanner(r)
+	for scanner.Scan() {
+		ts, text := parseConsoleLine(strings.TrimRight(scanner.Text(), "\r"))
+		if ts.IsZero() {
+			ts = time.Now()
+		} else if !since.IsZero() && !ts.After(since) {
+			continue
+		}
+		emitConsoleLine(serverID, ts, text)
+	}
+}
+
+func consoleSupervisor() {
+	ticker := time.NewTicker(2 * time.Second)
+	for range ticker.C {
+		if docker.Client == nil || config.Get() == nil {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		containers, err := docker.Client.ContainerList(ctx, container.ListOptions{
+			Filters: filters.NewArgs(filters.Arg("name", "birdactyl-"), filters.Arg("status", "running")),
+		})
+		cancel()
+		if err != nil {
+			continue
+		}
+		for _, c := range containers {
+			for _, n := range c.Names {
+				n = strings.TrimPrefix(n, "/")
+				if strings.HasPrefix(n, "birdactyl-install-") {
+					continue
+				}
+				if id, ok := strings.CutPrefix(n, "birdactyl-"); ok && ValidateServerID(id) == nil {
+					ensureConsoleCapture(id, c.ID)
+				}
+			}
+		}
+	}
+}
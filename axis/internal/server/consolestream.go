@@ -0,0 +1,207 @@
+package server
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const consoleBufferSize = 2000
+
+type ConsoleEntry struct {
+	Seq  uint64 `json:"seq"`
+	Time int64  `json:"time"`
+	Line string `json:"line"`
+}
+
+// consoleStream keeps the most recent console lines in memory with a sequence
+// number so websocket clients can resume where they left off. The epoch
+// changes whenever the stream is rebuilt, which invalidates old sequences.
+type consoleStream struct {
+	mu       sync.Mutex
+	epoch    string
+	head     uint64
+	entries  []ConsoleEntry
+	watchers map[chan struct{}]struct{}
+}
+
+var (
+	consoleStreams   = make(map[string]*consoleStream)
+	consoleStreamsMu sync.Mutex
+)
+
+func getConsoleStream(serverID string) *consoleStream {
+	consoleStreamsMu.Lock()
+	defer consoleStreamsMu.Unlock()
+	if s, ok := consoleStreams[serverID]; ok {
+		return s
+	}
+	s := &consoleStream{
+		epoch:    strconv.FormatInt(time.Now().UnixNano(), 36),
+		watchers: make(map[chan struct{}]struct{}),
+	}
+	for _, r := range tailConsoleRecords(serverID, consoleBufferSize) {
+		s.head++
+		s.entries = append(s.entries, ConsoleEntry{Seq: s.head, Time: r.ts.UnixMilli(), Line: r.text})
+	}
+	consoleStreams[serverID] = s
+	return s
+}
+
+func dropConsoleStream(serverID string) {
+	consoleStreamsMu.Lock()
+	delete(consoleStreams, serverID)
+	consoleStreamsMu.Unlock()
+}
+
+func (s *consoleStream) push(ts time.Time, text string) {
+	s.mu.Lock()
+	s.head++
+	if len(s.entries) == consoleBufferSize {
+		copy(s.entries, s.entries[1:])
+		s.entries = s.entries[:len(s.entries)-1]
+	}
+	s.entries = append(s.entries, ConsoleEntry{Seq: s.head, Time: ts.UnixMilli(), Line: text})
+	for ch := range s.watchers {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+	s.mu.Unlock()
+}
+
+// ConsoleCursor describes where a client starts reading. Resumed is false when
+// the requested epoch is unknown and the client gets a fresh replay instead.
+type ConsoleCursor struct {
+	Epoch   string
+	Head    uint64
+	Seq     uint64
+	Resumed bool
+}
+
+// OpenConsoleCursor resumes after seq when epoch matches the live stream, or
+// starts replay lines before the head otherwise.
+func OpenConsoleCursor(serverID, epoch string, seq uint64, replay int) ConsoleCursor {
+	s := getConsoleStream(serverID)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cur := ConsoleCursor{Epoch: s.epoch, Head: s.head}
+	if epoch != "" && epoch == s.epoch && seq <= s.head {
+		cur.Seq = seq
+		cur.Resumed = true
+		return cur
+	}
+	if uint64(replay) < s.head {
+		cur.Seq = s.head - uint64(replay)
+	}
+	if len(s.entries) > 0 && cur.Seq+1 < s.entries[0].Seq {
+		cur.Seq = s.entries[0].Seq - 1
+	}
+	return cur
+}
+
+// ReadConsole returns up to max entries after seq. Dropped counts entries that
+// were evicted from the buffer before they could be read.
+func ReadConsole(serverID string, seq uint64, max int) (entries []ConsoleEntry, dropped uint64, next uint64) {
+	s := getConsoleStream(serverID)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.entries) == 0 || seq >= s.head {
+		return nil, 0, s.head
+	}
+	first := s.entries[0].Seq
+	if seq+1 < first {
+		dropped = first - seq - 1
+		seq = first - 1
+	}
+	start := int(seq + 1 - first)
+	end := len(s.entries)
+	if end-start > max {
+		end = start + max
+	}
+	entries = append([]ConsoleEntry(nil), s.entries[start:end]...)
+	return entries, dropped, entries[len(entries)-1].Seq
+}
+
+func WatchConsole(serverID string) chan struct{} {
+	ch := make(chan struct{}, 1)
+	s := getConsoleStream(serverID)
+	s.mu.Lock()
+	s.watchers[ch] = struct{}{}
+	s.mu.Unlock()
+	return ch
+}
+
+func UnwatchConsole(serverID string, ch chan struct{}) {
+	s := getConsoleStream(serverID)
+	s.mu.Lock()
+	delete(s.watchers, ch)
+	s.mu.Unlock()
+}
+
+var consoleLevelRe = regexp.MustCompile(`(?i)\b(trace|debug|info|warn|warning|error|severe|fatal)\b`)
+
+// ConsoleFilter drops lines that do not match the requested levels or
+// pattern. Lines without a recognisable level count as info.
+type ConsoleFilter struct {
+	levels map[string]bool
+	re     *regexp.Regexp
+}
+
+func NewConsoleFilter(levels []string, pattern string) (*ConsoleFilter, error) {
+	f := &ConsoleFilter{}
+	for _, l := range levels {
+		l = normalizeConsoleLevel(l)
+		if l == "" {
+			continue
+		}
+		if f.levels == nil {
+			f.levels = make(map[string]bool)
+		}
+		f.levels[l] = true
+	}
+	if pattern != "" {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+		f.re = re
+	}
+	return f, nil
+}
+
+func normalizeConsoleLevel(l string) string {
+	switch strings.ToLower(strings.TrimSpace(l)) {
+	case "trace", "debug":
+		return "debug"
+	case "info":
+		return "info"
+	case "warn", "warning":
+		return "warn"
+	case "error", "severe", "fatal":
+		return "error"
+	}
+	return ""
+}
+
+func (f *ConsoleFilter) Match(line string) bool {
+	if f == nil {
+		return true
+	}
+	plain := stripANSI(line)
+	if f.levels != nil {
+		level := "info"
+		if m := consoleLevelRe.FindString(plain); m != "" {
+			level = normalizeConsoleLevel(m)
+		}
+		if !f.levels[level] {
+			return false
+		}
+	}
+	return f.re == nil || f.re.MatchString(plain)
+}
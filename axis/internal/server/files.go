@@ -1,19 +1,27 @@
 package server
 
 import (
+	"crypto/sha256"
 	"crypto/sha512"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"net"
 	"net/http"
 	"net/url"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 )
 
+var ErrETagMismatch = errors.New("file has been modified since it was read")
+
+var fileWriteMu sync.Mutex
+
 type FileEntry struct {
 	Name    string `json:"name"`
 	Size    int64  `json:"size"`
@@ -116,26 +124,63 @@ func computeSHA512(filePath string) (string, error) {
 	return hex.EncodeToString(h.Sum(nil)), nil
 }
 
-func ReadFile(serverID, subPath string) ([]byte, error) {
+func ReadFile(serverID, subPath string) ([]byte, string, error) {
 	base := serverDataDir(serverID)
 	target := filepath.Join(base, filepath.Clean("/"+subPath))
 
 	if !strings.HasPrefix(target, base) {
-		return nil, fmt.Errorf("invalid path")
+		return nil, "", fmt.Errorf("invalid path")
 	}
 
 	info, err := os.Stat(target)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 	if info.IsDir() {
-		return nil, fmt.Errorf("cannot read directory")
+		return nil, "", fmt.Errorf("cannot read directory")
 	}
 	if info.Size() > 5*1024*1024 {
-		return nil, fmt.Errorf("file too large")
+		return nil, "", fmt.Errorf("file too large")
 	}
 
-	return os.ReadFile(target)
+	content, err := os.ReadFile(target)
+	if err != nil {
+		return nil, "", err
+	}
+	return content, fileETag(content, info.ModTime()), nil
+}
+
+func fileETag(content []byte, modTime time.Time) string {
+	sum := sha256.Sum256(content)
+	return fmt.Sprintf("\"%s-%x\"", hex.EncodeToString(sum[:16]), modTime.UnixNano())
+}
+
+func currentETag(target string) (string, error) {
+	info, err := os.Stat(target)
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("cannot write to directory")
+	}
+	content, err := os.ReadFile(target)
+	if err != nil {
+		return "", err
+	}
+	return fileETag(content, info.ModTime()), nil
+}
+
+func etagMatches(ifMatch, etag string) bool {
+	if ifMatch == "*" {
+		return etag != ""
+	}
+	for _, candidate := range strings.Split(ifMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if strings.Trim(candidate, "\"") == strings.Trim(etag, "\"") {
+			return true
+		}
+	}
+	return false
 }
 
 func CreateFolder(serverID, subPath string) error {
@@ -149,15 +194,31 @@ func CreateFolder(serverID, subPath string) error {
 	return os.MkdirAll(target, 0755)
 }
 
-func WriteFile(serverID, subPath string, content []byte) error {
+func WriteFile(serverID, subPath string, content []byte, ifMatch string) (string, error) {
 	base := serverDataDir(serverID)
 	target := filepath.Join(base, filepath.Clean("/"+subPath))
 
 	if !strings.HasPrefix(target, base) {
-		return fmt.Errorf("invalid path")
+		return "", fmt.Errorf("invalid path")
+	}
+
+	fileWriteMu.Lock()
+	defer fileWriteMu.Unlock()
+
+	if ifMatch != "" {
+		etag, err := currentETag(target)
+		if err != nil && !os.IsNotExist(err) {
+			return "", err
+		}
+		if !etagMatches(ifMatch, etag) {
+			return etag, ErrETagMismatch
+		}
 	}
 
-	return os.WriteFile(target, content, 0644)
+	if err := os.WriteFile(target, content, 0644); err != nil {
+		return "", err
+	}
+	return currentETag(target)
 }
 
 func WriteFileStream(serverID, subPath string, r io.Reader) error {
@@ -213,7 +274,7 @@ func DeletePath(serverID, subPath string) error {
 		return fmt.Errorf("invalid path")
 	}
 
-	return os.RemoveAll(target)
+	return moveToTrash(serverID, target)
 }
 
 func MovePath(serverID, srcPath, destPath string) error {
@@ -273,13 +334,98 @@ func BulkDelete(serverID string, paths []string) (int, error) {
 		if !strings.HasPrefix(target, base) || target == base {
 			continue
 		}
-		if err := os.RemoveAll(target); err == nil {
+		if err := moveToTrash(serverID, target); err == nil {
 			deleted++
 		}
 	}
 	return deleted, nil
 }
 
+// DeleteMatching moves every file whose path relative to the server root
+// matches pattern to the trash. "**" matches any number of directories and
+// olderThan, when set, spares files modified more recently.
+func DeleteMatching(serverID, pattern string, olderThan time.Duration) ([]string, error) {
+	pattern = strings.Trim(filepath.ToSlash(filepath.Clean("/"+pattern)), "/")
+	if !globHasLiteral(pattern[strings.LastIndex(pattern, "/")+1:]) {
+		return nil, fmt.Errorf("pattern would match every file")
+	}
+	if _, err := path.Match(strings.ReplaceAll(pattern, "**", "*"), ""); err != nil {
+		return nil, fmt.Errorf("invalid pattern: %w", err)
+	}
+
+	base := serverDataDir(serverID)
+	cutoff := time.Now().Add(-olderThan)
+	var matched []string
+	filepath.Walk(base, func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(base, p)
+		if err != nil || !matchGlob(strings.Split(pattern, "/"), strings.Split(filepath.ToSlash(rel), "/")) {
+			return nil
+		}
+		if olderThan > 0 && info.ModTime().After(cutoff) {
+			return nil
+		}
+		matched = append(matched, p)
+		return nil
+	})
+
+	deleted := make([]string, 0, len(matched))
+	for _, p := range matched {
+		if err := moveToTrash(serverID, p); err == nil {
+			deleted = append(deleted, strings.TrimPrefix(p, base))
+		}
+	}
+	return deleted, nil
+}
+
+// globHasLiteral reports whether a glob segment names anything beyond
+// wildcards, character classes and dots, so "*.*" or "[!.]*" count as
+// matching every file.
+func globHasLiteral(segment string) bool {
+	for i := 0; i < len(segment); i++ {
+		switch segment[i] {
+		case '*', '?', '.':
+		case '[':
+			end := strings.IndexByte(segment[i+1:], ']')
+			if end < 0 {
+				return true
+			}
+			i += end + 1
+		case '\\':
+			if i+1 < len(segment) && segment[i+1] != '.' {
+				return true
+			}
+			i++
+		default:
+			return true
+		}
+	}
+	return false
+}
+
+func matchGlob(pattern, parts []string) bool {
+	if len(pattern) == 0 {
+		return len(parts) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(parts); i++ {
+			if matchGlob(pattern[1:], parts[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(parts) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(pattern[0], parts[0]); !ok {
+		return false
+	}
+	return matchGlob(pattern[1:], parts[1:])
+}
+
 func BulkCopy(serverID string, paths []string, destDir string) (int, error) {
 	base := serverDataDir(serverID)
 	dest := filepath.Join(base, filepath.Clean("/"+destDir))
@@ -0,0 +1,308 @@
+package server
+
+import (
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"cauthon-axis/internal/logger"
+)
+
+// ManifestEntry describes one file or directory of a server for a delta
+// sync. Paths are relative and slash separated. Symlinks are left out.
+type ManifestEntry struct {
+	Path    string `json:"path"`
+	Size    int64  `json:"size"`
+	ModTime int64  `json:"mod_time"`
+	Mode    uint32 `json:"mode"`
+	IsDir   bool   `json:"is_dir,omitempty"`
+	SHA512  string `json:"sha512,omitempty"`
+}
+
+// SyncSource is the node a delta sync pulls from. URL is the source's
+// /api/servers/:id base. A final sync runs with the server stopped, so any
+// file that changes while it is copied fails it.
+type SyncSource struct {
+	URL   string
+	Token string
+	Final bool
+}
+
+const syncTempPrefix = ".axis-sync-"
+
+type hashCacheEntry struct {
+	size    int64
+	modTime int64
+	sum     string
+}
+
+// manifestHashes remembers file hashes per server so a repeated manifest
+// only hashes files that changed since the last one.
+var (
+	manifestHashes   = make(map[string]map[string]hashCacheEntry)
+	manifestHashesMu sync.Mutex
+)
+
+// BuildManifest lists every file and directory of the server with its size,
+// modification time and SHA-512.
+func BuildManifest(serverID string) ([]ManifestEntry, error) {
+	base := serverDataDir(serverID)
+	if _, err := os.Stat(base); err != nil {
+		return nil, fmt.Errorf("server data directory not found")
+	}
+
+	manifestHashesMu.Lock()
+	previous := manifestHashes[serverID]
+	manifestHashesMu.Unlock()
+	hashes := make(map[string]hashCacheEntry)
+
+	var entries []ManifestEntry
+	err := filepath.WalkDir(base, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || p == base {
+			return err
+		}
+		if d.Type()&fs.ModeSymlink != 0 || !(d.IsDir() || d.Type().IsRegular()) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		rel, _ := filepath.Rel(base, p)
+		entry := ManifestEntry{
+			Path:    filepath.ToSlash(rel),
+			ModTime: info.ModTime().UnixNano(),
+			Mode:    uint32(info.Mode().Perm()),
+			IsDir:   d.IsDir(),
+		}
+		if !d.IsDir() {
+			entry.Size = info.Size()
+			cached, ok := previous[entry.Path]
+			if ok && cached.size == entry.Size && cached.modTime == entry.ModTime {
+				entry.SHA512 = cached.sum
+			} else if entry.SHA512, err = computeSHA512(p); err != nil {
+				return nil
+			}
+			hashes[entry.Path] = hashCacheEntry{size: entry.Size, modTime: entry.ModTime, sum: entry.SHA512}
+		}
+		entries = append(entries, entry)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	manifestHashesMu.Lock()
+	manifestHashes[serverID] = hashes
+	manifestHashesMu.Unlock()
+	return entries, nil
+}
+
+func forgetManifest(serverID string) {
+	manifestHashesMu.Lock()
+	delete(manifestHashes, serverID)
+	manifestHashesMu.Unlock()
+}
+
+// OpenSyncFile opens a regular file of the server for a delta sync.
+func OpenSyncFile(serverID, subPath string) (*os.File, os.FileInfo, error) {
+	p, err := GetFilePath(serverID, subPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	info, err := os.Lstat(p)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !info.Mode().IsRegular() {
+		return nil, nil, fmt.Errorf("not a regular file")
+	}
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, nil, err
+	}
+	return f, info, nil
+}
+
+// StartSync copies the files that differ from the source node into the
+// server directory in the background and removes the ones the source no
+// longer has. finished runs when the sync ends.
+func StartSync(serverID string, src SyncSource, finished func()) error {
+	return syncs.start(serverID, "sync", JobScanning, func(j *transferJob) error {
+		return j.runSync(serverID, src)
+	}, finished)
+}
+
+// GetSync returns the progress of the server's latest sync.
+func GetSync(serverID string) (JobStatus, bool) {
+	return syncs.get(serverID)
+}
+
+func (j *transferJob) runSync(serverID string, src SyncSource) error {
+	var remote []ManifestEntry
+	if err := syncRequest(src, "/sync/manifest", func(r io.Reader) error {
+		var result struct {
+			Data []ManifestEntry `json:"data"`
+		}
+		if err := json.NewDecoder(r).Decode(&result); err != nil {
+			return err
+		}
+		remote = result.Data
+		return nil
+	}); err != nil {
+		return fmt.Errorf("failed to fetch manifest: %v", err)
+	}
+
+	base := serverDataDir(serverID)
+	if err := os.MkdirAll(base, 0755); err != nil {
+		return err
+	}
+	uid, _ := strconv.Atoi(GetServerUID())
+
+	local := make(map[string]fs.FileInfo)
+	filepath.WalkDir(base, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || p == base {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			rel, _ := filepath.Rel(base, p)
+			local[filepath.ToSlash(rel)] = info
+		}
+		return nil
+	})
+
+	wanted := make(map[string]bool, len(remote))
+	var copies []ManifestEntry
+	var total int64
+	for _, e := range remote {
+		clean := path.Clean("/" + e.Path)[1:]
+		if clean == "" || clean != e.Path {
+			return fmt.Errorf("invalid path in manifest: %s", e.Path)
+		}
+		wanted[e.Path] = true
+		info, ok := local[e.Path]
+		if e.IsDir {
+			if ok && !info.IsDir() {
+				os.RemoveAll(filepath.Join(base, e.Path))
+				ok = false
+			}
+			if !ok {
+				if err := os.MkdirAll(filepath.Join(base, e.Path), os.FileMode(e.Mode)|0700); err != nil {
+					return err
+				}
+				os.Chown(filepath.Join(base, e.Path), uid, uid)
+			}
+			continue
+		}
+		if ok && info.Mode().IsRegular() && info.Size() == e.Size && info.ModTime().UnixNano() == e.ModTime {
+			continue
+		}
+		if ok && !info.Mode().IsRegular() {
+			os.RemoveAll(filepath.Join(base, e.Path))
+		}
+		copies = append(copies, e)
+		total += e.Size
+	}
+
+	var extra []string
+	for p := range local {
+		if !wanted[p] {
+			extra = append(extra, p)
+		}
+	}
+	sort.Slice(extra, func(a, b int) bool { return len(extra[a]) > len(extra[b]) })
+	for _, p := range extra {
+		os.RemoveAll(filepath.Join(base, filepath.FromSlash(p)))
+	}
+
+	j.setTotals(total, int64(len(copies)))
+	j.set(JobCopying, "")
+	logger.Transfer("Syncing %d files (%d bytes) for %s, removed %d", len(copies), total, serverID, len(extra))
+
+	for _, e := range copies {
+		if err := j.syncFile(src, base, e, uid); err != nil {
+			if src.Final {
+				return fmt.Errorf("%s: %v", e.Path, err)
+			}
+			logger.Warn("Pre-sync of %s for %s skipped: %v", e.Path, serverID, err)
+		}
+		j.filesDone.Add(1)
+	}
+	return nil
+}
+
+// syncFile downloads one file next to its destination and renames it into
+// place. During a pre-sync a file that changed on the source since the
+// manifest is kept, but with a zero modification time so the next sync
+// copies it again.
+func (j *transferJob) syncFile(src SyncSource, base string, e ManifestEntry, uid int) error {
+	dest := filepath.Join(base, filepath.FromSlash(e.Path))
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+	tmp := filepath.Join(filepath.Dir(dest), syncTempPrefix+filepath.Base(dest))
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, os.FileMode(e.Mode)|0600)
+	if err != nil {
+		return err
+	}
+
+	h := sha512.New()
+	err = syncRequest(src, "/sync/file?path="+url.QueryEscape(e.Path), func(r io.Reader) error {
+		_, err := io.Copy(io.MultiWriter(f, h, j), r)
+		return err
+	})
+	f.Close()
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	modTime := time.Unix(0, e.ModTime)
+	if sum := hex.EncodeToString(h.Sum(nil)); sum != e.SHA512 {
+		if src.Final {
+			os.Remove(tmp)
+			return fmt.Errorf("checksum mismatch")
+		}
+		modTime = time.Unix(0, 0)
+	}
+
+	os.Chmod(tmp, os.FileMode(e.Mode))
+	os.Chown(tmp, uid, uid)
+	os.Chtimes(tmp, modTime, modTime)
+	if err := os.Rename(tmp, dest); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+func syncRequest(src SyncSource, endpoint string, read func(io.Reader) error) error {
+	req, err := http.NewRequest("GET", src.URL+endpoint, nil)
+	if err != nil {
+		return err
+	}
+	if src.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+src.Token)
+	}
+	resp, err := transferClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("source node returned status %s", resp.Status)
+	}
+	return read(resp.Body)
+}
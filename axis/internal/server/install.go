@@ -0,0 +1,183 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"cauthon-axis/internal/config"
+	"cauthon-axis/internal/logger"
+	"cauthon-axis/internal/panel"
+)
+
+type InstallRecord struct {
+	Kind       string `json:"kind"`
+	Success    bool   `json:"success"`
+	ExitCode   int    `json:"exit_code"`
+	Error      string `json:"error,omitempty"`
+	StartedAt  int64  `json:"started_at"`
+	FinishedAt int64  `json:"finished_at"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+type installExitError struct {
+	code int
+}
+
+func (e *installExitError) Error() string {
+	return fmt.Sprintf("install script exited with code %d", e.code)
+}
+
+type installRun struct {
+	serverID string
+	kind     string
+	started  time.Time
+	file     *os.File
+	mu       sync.Mutex
+}
+
+const installReportAttempts = 6
+
+var (
+	installRuns   = make(map[string]*installRun)
+	installRunsMu sync.Mutex
+)
+
+func installLogDir() string {
+	return config.Get().Node.InstallLogDir
+}
+
+func installLogPath(serverID string) string {
+	return filepath.Join(installLogDir(), serverID+".log")
+}
+
+func installRecordPath(serverID string) string {
+	return filepath.Join(installLogDir(), serverID+".json")
+}
+
+func beginInstall(serverID, kind string) *installRun {
+	run := &installRun{serverID: serverID, kind: kind, started: time.Now()}
+	if err := os.MkdirAll(installLogDir(), 0755); err == nil {
+		if f, err := os.Create(installLogPath(serverID)); err == nil {
+			run.file = f
+			fmt.Fprintf(f, "[%s] Install (%s) started\n", run.started.Format(time.RFC3339), kind)
+		}
+	}
+
+	installRunsMu.Lock()
+	installRuns[serverID] = run
+	installRunsMu.Unlock()
+	return run
+}
+
+func (r *installRun) write(line string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file != nil {
+		fmt.Fprintln(r.file, stripANSI(line))
+	}
+}
+
+func (r *installRun) finish(err error) {
+	installRunsMu.Lock()
+	if installRuns[r.serverID] == r {
+		delete(installRuns, r.serverID)
+	}
+	installRunsMu.Unlock()
+
+	finished := time.Now()
+	record := InstallRecord{
+		Kind:       r.kind,
+		Success:    err == nil,
+		StartedAt:  r.started.Unix(),
+		FinishedAt: finished.Unix(),
+		DurationMs: finished.Sub(r.started).Milliseconds(),
+	}
+	if err != nil {
+		record.Error = err.Error()
+		record.ExitCode = -1
+		var exitErr *installExitError
+		if errors.As(err, &exitErr) {
+			record.ExitCode = exitErr.code
+		}
+	}
+
+	r.mu.Lock()
+	if r.file != nil {
+		status := "succeeded"
+		if err != nil {
+			status = "failed: " + err.Error()
+		}
+		fmt.Fprintf(r.file, "[%s] Install %s (exit code %d, %s)\n", finished.Format(time.RFC3339), status, record.ExitCode, finished.Sub(r.started).Round(time.Millisecond))
+		r.file.Close()
+		r.file = nil
+	}
+	r.mu.Unlock()
+
+	if data, err := json.Marshal(record); err == nil {
+		os.WriteFile(installRecordPath(r.serverID), data, 0644)
+	}
+
+	go reportInstallFinished(r.serverID, record.Success, record.ExitCode, record.DurationMs, record.Error)
+}
+
+// reportInstallFinished tells the panel an install ended, retrying with
+// backoff so a panel that is briefly unreachable does not leave the server
+// stuck installing.
+func reportInstallFinished(serverID string, success bool, exitCode int, durationMs int64, errMsg string) {
+	delay := 2 * time.Second
+	for attempt := 1; ; attempt++ {
+		err := panel.NewClient().ReportInstallFinished(serverID, success, exitCode, durationMs, errMsg)
+		if err == nil {
+			return
+		}
+		if attempt == installReportAttempts {
+			logger.Warn("Failed to report install result for %s: %v", serverID, err)
+			return
+		}
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+func installLog(serverID, message string) {
+	installRunsMu.Lock()
+	run := installRuns[serverID]
+	installRunsMu.Unlock()
+	if run != nil && strings.TrimSpace(message) != "" {
+		run.write(message)
+	}
+	BroadcastLog(serverID, message)
+}
+
+func GetInstallLog(serverID string) (*InstallRecord, string, error) {
+	data, err := os.ReadFile(installLogPath(serverID))
+	if err != nil {
+		return nil, "", err
+	}
+
+	var record *InstallRecord
+	if raw, err := os.ReadFile(installRecordPath(serverID)); err == nil {
+		record = &InstallRecord{}
+		if json.Unmarshal(raw, record) != nil {
+			record = nil
+		}
+	}
+	return record, string(data), nil
+}
+
+func IsInstalling(serverID string) bool {
+	installRunsMu.Lock()
+	defer installRunsMu.Unlock()
+	return installRuns[serverID] != nil
+}
+
+func removeInstallLog(serverID string) {
+	os.Remove(installLogPath(serverID))
+	os.Remove(installRecordPath(serverID))
+}
@@ -8,7 +8,6 @@ import (
 	"io"
 	"os"
 	"path/filepath"
-	"regexp"
 	"strings"
 	"sync"
 	"time"
@@ -72,7 +71,10 @@ func BroadcastLog(serverID, message string) {
 
 	line := fmt.Sprintf("\033[36m[Birdactyl Axis]\033[0m %s", message)
 	logger.Server(serverID, message)
+	emitConsoleLine(serverID, time.Now(), line)
+}
 
+func publishLine(serverID, line string) {
 	logSubscribersMu.RLock()
 	subs := logSubscribers[serverID]
 	logSubscribersMu.RUnlock()
@@ -116,27 +118,7 @@ func GetLogs(serverID string, tail string, follow bool) (io.ReadCloser, error) {
 }
 
 func GetLogLines(serverID string, lines int) ([]string, error) {
-	logs, err := GetLogs(serverID, fmt.Sprintf("%d", lines), false)
-	if err != nil {
-		return nil, err
-	}
-	defer logs.Close()
-	var result []string
-	scanner := bufio.NewScanner(logs)
-	for scanner.Scan() {
-		line := scanner.Text()
-		if len(line) > 8 {
-			line = line[8:]
-		}
-		result = append(result, line)
-	}
-	return result, nil
-}
-
-type LogMatch struct {
-	Line       string `json:"line"`
-	LineNumber int    `json:"line_number"`
-	Timestamp  int64  `json:"timestamp"`
+	return tailConsoleLines(serverID, lines), nil
 }
 
 type LogFileInfo struct {
@@ -146,59 +128,15 @@ type LogFileInfo struct {
 }
 
 func GetFullLog(serverID string) ([]byte, error) {
-	logs, err := GetLogs(serverID, "all", false)
-	if err != nil {
-		return nil, err
-	}
-	defer logs.Close()
 	var buf bytes.Buffer
-	scanner := bufio.NewScanner(logs)
-	for scanner.Scan() {
-		line := scanner.Bytes()
-		if len(line) > 8 {
-			line = line[8:]
-		}
-		buf.Write(line)
+	eachConsoleLine(serverID, func(_ time.Time, text string) bool {
+		buf.WriteString(text)
 		buf.WriteByte('\n')
-	}
+		return true
+	})
 	return buf.Bytes(), nil
 }
 
-func SearchLogs(serverID, pattern string, regex bool, limit int, since int64) ([]LogMatch, error) {
-	logs, err := GetLogs(serverID, "all", false)
-	if err != nil {
-		return nil, err
-	}
-	defer logs.Close()
-	var matches []LogMatch
-	var re *regexp.Regexp
-	if regex {
-		re, err = regexp.Compile(pattern)
-		if err != nil {
-			return nil, err
-		}
-	}
-	scanner := bufio.NewScanner(logs)
-	lineNum := 0
-	for scanner.Scan() && len(matches) < limit {
-		lineNum++
-		line := scanner.Text()
-		if len(line) > 8 {
-			line = line[8:]
-		}
-		matched := false
-		if regex && re != nil {
-			matched = re.MatchString(line)
-		} else {
-			matched = strings.Contains(strings.ToLower(line), strings.ToLower(pattern))
-		}
-		if matched {
-			matches = append(matches, LogMatch{Line: line, LineNumber: lineNum, Timestamp: time.Now().UnixMilli()})
-		}
-	}
-	return matches, nil
-}
-
 func ListLogFiles(serverID string) ([]LogFileInfo, error) {
 	dataDir := serverDataDir(serverID)
 	logsDir := filepath.Join(dataDir, "logs")
@@ -234,15 +172,6 @@ func ReadLogFile(serverID, filename string) ([]byte, error) {
 	return os.ReadFile(logPath)
 }
 
-func SendCommand(serverID string, command string) error {
-	ctx := context.Background()
-	id, err := docker.GetContainerID(ctx, containerName(serverID))
-	if err != nil {
-		return err
-	}
-	return docker.SendCommand(ctx, id, command)
-}
-
 func streamInstallLogs(ctx context.Context, serverID, containerID string) {
 	logs, err := docker.Client.ContainerLogs(ctx, containerID, container.LogsOptions{
 		ShowStdout: true,
@@ -262,7 +191,7 @@ func streamInstallLogs(ctx context.Context, serverID, containerID string) {
 		}
 		text := string(line)
 		if strings.TrimSpace(text) != "" {
-			BroadcastLog(serverID, text)
+			installLog(serverID, text)
 		}
 	}
 }
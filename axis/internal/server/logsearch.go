@@ -0,0 +1,250 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+type LogMatch struct {
+	Source     string   `json:"source"`
+	Line       string   `json:"line"`
+	LineNumber int      `json:"line_number"`
+	Timestamp  int64    `json:"timestamp"`
+	Before     []string `json:"before,omitempty"`
+	After      []string `json:"after,omitempty"`
+}
+
+// LogSearchOptions bounds a search. Since and Until are unix milliseconds and
+// are ignored when zero.
+type LogSearchOptions struct {
+	Pattern string
+	Regex   bool
+	Limit   int
+	Since   int64
+	Until   int64
+	Context int
+}
+
+var (
+	gameLogDateRe = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2})`)
+	gameLogTimeRe = regexp.MustCompile(`^\[?(\d{4}-\d{2}-\d{2}[ T])?(\d{2}:\d{2}:\d{2})`)
+)
+
+type logSearch struct {
+	opts    LogSearchOptions
+	re      *regexp.Regexp
+	needle  string
+	matches []LogMatch
+	pending []int
+	before  []string
+	lineNum int
+}
+
+// SearchLogs searches the captured console history followed by the files in
+// the server's logs directory, including gzipped ones.
+func SearchLogs(serverID string, opts LogSearchOptions) ([]LogMatch, error) {
+	if opts.Limit <= 0 {
+		opts.Limit = 100
+	}
+	s := &logSearch{opts: opts, needle: strings.ToLower(opts.Pattern), matches: []LogMatch{}}
+	if opts.Regex {
+		re, err := regexp.Compile(opts.Pattern)
+		if err != nil {
+			return nil, err
+		}
+		s.re = re
+	}
+
+	console := append(consoleArchives(serverID), filepath.Join(consoleDir(serverID), consoleLogName))
+	s.startSource()
+	for _, path := range console {
+		if !s.fileInRange(path) {
+			continue
+		}
+		if !s.scanFile(path, "console", func(_ time.Time, line string) (time.Time, string) {
+			return parseConsoleLine(line)
+		}) {
+			return s.matches, nil
+		}
+	}
+
+	for _, path := range gameLogFiles(serverID) {
+		if !s.fileInRange(path) {
+			continue
+		}
+		s.startSource()
+		base := gameLogDate(path)
+		if !s.scanFile(path, "logs/"+filepath.Base(path), func(last time.Time, line string) (time.Time, string) {
+			return parseGameLogLine(base, last, line), line
+		}) {
+			break
+		}
+	}
+	return s.matches, nil
+}
+
+func (s *logSearch) startSource() {
+	s.pending = nil
+	s.before = nil
+	s.lineNum = 0
+}
+
+func (s *logSearch) full() bool {
+	return len(s.matches) >= s.opts.Limit && len(s.pending) == 0
+}
+
+// fileInRange skips files whose last write is older than Since; every line in
+// them is too.
+func (s *logSearch) fileInRange(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	return s.opts.Since == 0 || info.ModTime().UnixMilli() >= s.opts.Since
+}
+
+func (s *logSearch) scanFile(path, source string, parse func(last time.Time, line string) (time.Time, string)) bool {
+	r, err := openConsoleFile(path)
+	if err != nil {
+		return true
+	}
+	defer r.Close()
+
+	var modTime, last time.Time
+	if info, err := os.Stat(path); err == nil {
+		modTime = info.ModTime()
+	}
+	scanner := newLineScanner(r)
+	for scanner.Scan() {
+		ts, text := parse(last, scanner.Text())
+		if ts.IsZero() {
+			ts = last
+		}
+		if ts.IsZero() {
+			ts = modTime
+		}
+		last = ts
+		s.line(source, ts, stripANSI(text))
+		if s.full() {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *logSearch) line(source string, ts time.Time, text string) {
+	s.lineNum++
+
+	for i := 0; i < len(s.pending); {
+		m := &s.matches[s.pending[i]]
+		m.After = append(m.After, text)
+		if len(m.After) >= s.opts.Context {
+			s.pending = append(s.pending[:i], s.pending[i+1:]...)
+			continue
+		}
+		i++
+	}
+
+	ms := ts.UnixMilli()
+	inRange := (s.opts.Since == 0 || ms >= s.opts.Since) && (s.opts.Until == 0 || ms <= s.opts.Until)
+	if inRange && len(s.matches) < s.opts.Limit && s.match(text) {
+		m := LogMatch{Source: source, Line: text, LineNumber: s.lineNum, Timestamp: ms}
+		if len(s.before) > 0 {
+			m.Before = append([]string(nil), s.before...)
+		}
+		s.matches = append(s.matches, m)
+		if s.opts.Context > 0 {
+			s.pending = append(s.pending, len(s.matches)-1)
+		}
+	}
+
+	if s.opts.Context > 0 {
+		if len(s.before) == s.opts.Context {
+			s.before = s.before[1:]
+		}
+		s.before = append(s.before, text)
+	}
+}
+
+func (s *logSearch) match(text string) bool {
+	if s.re != nil {
+		return s.re.MatchString(text)
+	}
+	return strings.Contains(strings.ToLower(text), s.needle)
+}
+
+// gameLogFiles returns the plain and gzipped files in logs/ oldest first.
+func gameLogFiles(serverID string) []string {
+	dir := filepath.Join(serverDataDir(serverID), "logs")
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	type entry struct {
+		path string
+		mod  time.Time
+	}
+	var files []entry
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !(strings.HasSuffix(name, ".log") || strings.HasSuffix(name, ".log.gz")) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, entry{filepath.Join(dir, name), info.ModTime()})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].mod.Before(files[j].mod) })
+
+	paths := make([]string, len(files))
+	for i, f := range files {
+		paths[i] = f.path
+	}
+	return paths
+}
+
+// gameLogDate returns the day a log file covers, taken from a dated file name
+// such as 2024-05-01-1.log.gz or else from its modification time.
+func gameLogDate(path string) time.Time {
+	if m := gameLogDateRe.FindStringSubmatch(filepath.Base(path)); m != nil {
+		if d, err := time.ParseInLocation("2006-01-02", m[1], time.Local); err == nil {
+			return d
+		}
+	}
+	if info, err := os.Stat(path); err == nil {
+		y, mo, d := info.ModTime().Date()
+		return time.Date(y, mo, d, 0, 0, 0, 0, time.Local)
+	}
+	return time.Time{}
+}
+
+// parseGameLogLine reads a leading [HH:MM:SS] or date-time stamp. Lines
+// without one inherit the previous line's time.
+func parseGameLogLine(day, last time.Time, line string) time.Time {
+	m := gameLogTimeRe.FindStringSubmatch(line)
+	if m == nil {
+		if last.IsZero() {
+			return day
+		}
+		return last
+	}
+	if m[1] != "" {
+		if ts, err := time.ParseInLocation("2006-01-02 15:04:05", strings.Replace(m[1], "T", " ", 1)+m[2], time.Local); err == nil {
+			return ts
+		}
+	}
+	if day.IsZero() {
+		return last
+	}
+	clock, err := time.Parse("15:04:05", m[2])
+	if err != nil {
+		return last
+	}
+	return day.Add(time.Duration(clock.Hour())*time.Hour + time.Duration(clock.Minute())*time.Minute + time.Duration(clock.Second())*time.Second)
+}
@@ -76,28 +76,43 @@ func GetServerUID() string {
 }
 
 type ServerConfig struct {
-	ID            string            `json:"id"`
-	Name          string            `json:"name"`
-	DockerImage   string            `json:"docker_image"`
-	InstallImage  string            `json:"install_image"`
-	InstallScript string            `json:"install_script"`
-	Startup       string            `json:"startup"`
-	Memory        int               `json:"memory"`
-	CPU           int               `json:"cpu"`
-	Disk          int               `json:"disk"`
-	Ports         []PortConfig      `json:"ports"`
-	Variables     map[string]string `json:"variables"`
-	StopSignal    string            `json:"stop_signal"`
-	StopCommand   string            `json:"stop_command"`
-	StopTimeout   int               `json:"stop_timeout"`
+	ID             string            `json:"id"`
+	Name           string            `json:"name"`
+	DockerImage    string            `json:"docker_image"`
+	InstallImage   string            `json:"install_image"`
+	InstallScript  string            `json:"install_script"`
+	Startup        string            `json:"startup"`
+	Memory         int               `json:"memory"`
+	CPU            int               `json:"cpu"`
+	Disk           int               `json:"disk"`
+	Ports          []PortConfig      `json:"ports"`
+	Variables      map[string]string `json:"variables"`
+	StopSignal     string            `json:"stop_signal"`
+	StopCommand    string            `json:"stop_command"`
+	StopTimeout    int               `json:"stop_timeout"`
+	ConfigFiles    []ConfigFile      `json:"config_files"`
+	StartupDone    []string          `json:"startup_done"`
+	StartupProbe   bool              `json:"startup_probe"`
+	StartupTimeout int               `json:"startup_timeout"`
 }
 
 type PortConfig struct {
+	IP        string `json:"ip,omitempty"`
 	Host      int    `json:"host"`
 	Container int    `json:"container"`
 	Protocol  string `json:"protocol"`
 }
 
+const anyIP = "0.0.0.0"
+
+// BindIP is the host address the port is published on.
+func (p PortConfig) BindIP() string {
+	if p.IP == "" {
+		return anyIP
+	}
+	return p.IP
+}
+
 type ServerStats struct {
 	MemoryUsage int64   `json:"memory_usage"`
 	MemoryLimit int64   `json:"memory_limit"`
@@ -120,39 +135,42 @@ func serverDataDir(serverID string) string {
 	return filepath.Join(cfg.Node.DataDir, serverID)
 }
 
-func Create(cfg ServerConfig) error {
+func Create(cfg ServerConfig) (err error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
 	defer cancel()
 
-	BroadcastLog(cfg.ID, "Starting server installation...")
+	run := beginInstall(cfg.ID, "install")
+	defer func() { run.finish(err) }()
+
+	installLog(cfg.ID, "Starting server installation...")
 
 	dataDir := serverDataDir(cfg.ID)
 	if err := os.MkdirAll(dataDir, 0777); err != nil {
-		BroadcastLog(cfg.ID, fmt.Sprintf("Failed to create data directory: %v", err))
+		installLog(cfg.ID, fmt.Sprintf("Failed to create data directory: %v", err))
 		return fmt.Errorf("failed to create data directory: %w", err)
 	}
 	os.Chmod(dataDir, 0777)
 
 	chownRecursive(dataDir)
 
-	BroadcastLog(cfg.ID, "Created server data directory")
+	installLog(cfg.ID, "Created server data directory")
 
 	if cfg.InstallScript != "" {
-		BroadcastLog(cfg.ID, "Running install script...")
+		installLog(cfg.ID, "Running install script...")
 		if err := runInstall(ctx, cfg, dataDir); err != nil {
-			BroadcastLog(cfg.ID, fmt.Sprintf("Installation failed: %v", err))
+			installLog(cfg.ID, fmt.Sprintf("Installation failed: %v", err))
 			return fmt.Errorf("install failed: %w", err)
 		}
-		BroadcastLog(cfg.ID, "Install script completed successfully")
+		installLog(cfg.ID, "Install script completed successfully")
 	}
 
 	if !docker.ImageExists(ctx, cfg.DockerImage) {
-		BroadcastLog(cfg.ID, fmt.Sprintf("Pulling Docker image: %s", cfg.DockerImage))
+		installLog(cfg.ID, fmt.Sprintf("Pulling Docker image: %s", cfg.DockerImage))
 		if err := docker.PullImage(ctx, cfg.DockerImage); err != nil {
-			BroadcastLog(cfg.ID, fmt.Sprintf("Failed to pull image: %v", err))
+			installLog(cfg.ID, fmt.Sprintf("Failed to pull image: %v", err))
 			return fmt.Errorf("failed to pull image: %w", err)
 		}
-		BroadcastLog(cfg.ID, "Docker image pulled successfully")
+		installLog(cfg.ID, "Docker image pulled successfully")
 	}
 
 	startup := cfg.Startup
@@ -170,7 +188,7 @@ func Create(cfg ServerConfig) error {
 		containerPort := nat.Port(fmt.Sprintf("%d/%s", p.Container, proto))
 		exposedPorts[containerPort] = struct{}{}
 		portBindings[containerPort] = []nat.PortBinding{{
-			HostIP:   "0.0.0.0",
+			HostIP:   p.BindIP(),
 			HostPort: strconv.Itoa(p.Host),
 		}}
 	}
@@ -213,11 +231,9 @@ func Create(cfg ServerConfig) error {
 		}
 	}
 
-	_, err := docker.CreateContainer(ctx, name, containerCfg, hostCfg)
+	_, err = docker.CreateContainer(ctx, name, containerCfg, hostCfg)
 	if err == nil {
-		serverConfigsMu.Lock()
-		serverConfigs[cfg.ID] = &cfg
-		serverConfigsMu.Unlock()
+		storeServerConfig(&cfg)
 	}
 	return err
 }
@@ -229,7 +245,7 @@ func runInstall(ctx context.Context, cfg ServerConfig, dataDir string) error {
 	}
 
 	if !docker.ImageExists(ctx, installImage) {
-		BroadcastLog(cfg.ID, fmt.Sprintf("Pulling install image: %s", installImage))
+		installLog(cfg.ID, fmt.Sprintf("Pulling install image: %s", installImage))
 		if err := docker.PullImage(ctx, installImage); err != nil {
 			return fmt.Errorf("failed to pull install image: %w", err)
 		}
@@ -291,7 +307,7 @@ func runInstall(ctx context.Context, cfg ServerConfig, dataDir string) error {
 	case status := <-statusCh:
 		docker.RemoveContainer(ctx, id, true)
 		if status.StatusCode != 0 {
-			return fmt.Errorf("install script exited with code %d", status.StatusCode)
+			return &installExitError{code: int(status.StatusCode)}
 		}
 	}
 
@@ -333,7 +349,7 @@ func CreateContainer(cfg ServerConfig) error {
 		containerPort := nat.Port(fmt.Sprintf("%d/%s", p.Container, proto))
 		exposedPorts[containerPort] = struct{}{}
 		portBindings[containerPort] = []nat.PortBinding{{
-			HostIP:   "0.0.0.0",
+			HostIP:   p.BindIP(),
 			HostPort: strconv.Itoa(p.Host),
 		}}
 	}
@@ -370,9 +386,7 @@ func CreateContainer(cfg ServerConfig) error {
 	name := containerName(cfg.ID)
 	_, err := docker.CreateContainer(ctx, name, containerCfg, hostCfg)
 	if err == nil {
-		serverConfigsMu.Lock()
-		serverConfigs[cfg.ID] = &cfg
-		serverConfigsMu.Unlock()
+		storeServerConfig(&cfg)
 	}
 	return err
 }
@@ -385,7 +399,21 @@ func Start(serverID string) error {
 	if err != nil {
 		return err
 	}
-	return docker.StartContainer(ctx, id)
+
+	serverConfigsMu.RLock()
+	cfg := serverConfigs[serverID]
+	serverConfigsMu.RUnlock()
+	if cfg != nil {
+		applyConfigFiles(cfg)
+	}
+
+	seq := OpenConsoleCursor(serverID, "", 0, 0).Head
+	if err := docker.StartContainer(ctx, id); err != nil {
+		return err
+	}
+	ensureConsoleCapture(serverID, id)
+	watchReadiness(serverID, cfg, seq)
+	return nil
 }
 
 func Stop(serverID string, timeout int) error {
@@ -441,6 +469,7 @@ func StopWithConfig(serverID string, timeout int, stopCommand string, stopSignal
 }
 
 func Kill(serverID string) error {
+	cancelReadiness(serverID)
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
@@ -466,9 +495,12 @@ func RestartWithConfig(serverID string, timeout int, stopCommand string, stopSig
 		if err != nil {
 			return err
 		}
-		return docker.RestartContainer(ctx, id, timeout)
+		if err := docker.StopContainer(ctx, id, timeout); err != nil {
+			return err
+		}
 	}
 	
+	transitionOperation(serverID, StateStopping, StateStarting)
 	if err := Start(serverID); err != nil {
 		return fmt.Errorf("restart failed during start: %w", err)
 	}
@@ -487,41 +519,44 @@ func RemoveContainer(serverID string) {
 	}
 }
 
-func Reinstall(cfg ServerConfig) error {
+func Reinstall(cfg ServerConfig) (err error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
 	defer cancel()
 
+	run := beginInstall(cfg.ID, "reinstall")
+	defer func() { run.finish(err) }()
+
 	name := containerName(cfg.ID)
 	if docker.ContainerExists(ctx, name) {
 		if id, err := docker.GetContainerID(ctx, name); err == nil {
-			BroadcastLog(cfg.ID, "Stopping server for reinstall...")
+			installLog(cfg.ID, "Stopping server for reinstall...")
 			docker.StopContainer(ctx, id, 10)
 			docker.RemoveContainer(ctx, id, true)
 		}
 	}
 
-	BroadcastLog(cfg.ID, "Starting reinstallation...")
+	installLog(cfg.ID, "Starting reinstallation...")
 
 	dataDir := serverDataDir(cfg.ID)
 	os.Chmod(dataDir, 0777)
 	chownRecursive(dataDir)
 
 	if cfg.InstallScript != "" {
-		BroadcastLog(cfg.ID, "Running install script...")
+		installLog(cfg.ID, "Running install script...")
 		if err := runInstall(ctx, cfg, dataDir); err != nil {
-			BroadcastLog(cfg.ID, fmt.Sprintf("Installation failed: %v", err))
+			installLog(cfg.ID, fmt.Sprintf("Installation failed: %v", err))
 			return fmt.Errorf("install failed: %w", err)
 		}
-		BroadcastLog(cfg.ID, "Install script completed successfully")
+		installLog(cfg.ID, "Install script completed successfully")
 	}
 
 	if !docker.ImageExists(ctx, cfg.DockerImage) {
-		BroadcastLog(cfg.ID, fmt.Sprintf("Pulling Docker image: %s", cfg.DockerImage))
+		installLog(cfg.ID, fmt.Sprintf("Pulling Docker image: %s", cfg.DockerImage))
 		if err := docker.PullImage(ctx, cfg.DockerImage); err != nil {
-			BroadcastLog(cfg.ID, fmt.Sprintf("Failed to pull image: %v", err))
+			installLog(cfg.ID, fmt.Sprintf("Failed to pull image: %v", err))
 			return fmt.Errorf("failed to pull image: %w", err)
 		}
-		BroadcastLog(cfg.ID, "Docker image pulled successfully")
+		installLog(cfg.ID, "Docker image pulled successfully")
 	}
 
 	startup := cfg.Startup
@@ -539,7 +574,7 @@ func Reinstall(cfg ServerConfig) error {
 		containerPort := nat.Port(fmt.Sprintf("%d/%s", p.Container, proto))
 		exposedPorts[containerPort] = struct{}{}
 		portBindings[containerPort] = []nat.PortBinding{{
-			HostIP:   "0.0.0.0",
+			HostIP:   p.BindIP(),
 			HostPort: strconv.Itoa(p.Host),
 		}}
 	}
@@ -573,13 +608,14 @@ func Reinstall(cfg ServerConfig) error {
 		RestartPolicy: container.RestartPolicy{Name: "unless-stopped"},
 	}
 
-	_, err := docker.CreateContainer(ctx, name, containerCfg, hostCfg)
+	_, err = docker.CreateContainer(ctx, name, containerCfg, hostCfg)
 	if err != nil {
-		BroadcastLog(cfg.ID, fmt.Sprintf("Failed to create container: %v", err))
+		installLog(cfg.ID, fmt.Sprintf("Failed to create container: %v", err))
 		return err
 	}
+	storeServerConfig(&cfg)
 
-	BroadcastLog(cfg.ID, "Reinstallation complete")
+	installLog(cfg.ID, "Reinstallation complete")
 	return nil
 }
 
@@ -596,9 +632,15 @@ func Delete(serverID string) error {
 		}
 	}
 
+	forgetServerConfig(serverID)
+	forgetManifest(serverID)
+
 	go func() {
 		dataDir := serverDataDir(serverID)
 		os.RemoveAll(dataDir)
+		os.RemoveAll(trashDir(serverID))
+		removeInstallLog(serverID)
+		removeConsoleLogs(serverID)
 	}()
 
 	return nil
@@ -689,7 +731,7 @@ func fetchStats(serverID string) (*ServerStats, error) {
 		netTx += int64(net.TxBytes)
 	}
 
-	diskUsage := getDirSize(serverDataDir(serverID))
+	diskUsage := GetDiskUsage(serverID)
 
 	return &ServerStats{
 		MemoryUsage: int64(stats.MemoryStats.Usage),
@@ -731,7 +773,7 @@ func chownRecursive(path string) {
 }
 
 func GetDiskUsage(serverID string) int64 {
-	return getDirSize(serverDataDir(serverID))
+	return getDirSize(serverDataDir(serverID)) + GetTrashSize(serverID)
 }
 
 
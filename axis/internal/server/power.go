@@ -0,0 +1,343 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"cauthon-axis/internal/config"
+	"cauthon-axis/internal/docker"
+	"cauthon-axis/internal/logger"
+	"cauthon-axis/internal/panel"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+)
+
+type PowerState string
+
+const (
+	StateOffline      PowerState = "offline"
+	StateStarting     PowerState = "starting"
+	StateRunning      PowerState = "running"
+	StateStopping     PowerState = "stopping"
+	StateInstalling   PowerState = "installing"
+	StateRestoring    PowerState = "restoring"
+	StateTransferring PowerState = "transferring"
+)
+
+// OperationConflictError is returned when an operation can't run because
+// another one already holds the server.
+type OperationConflictError struct {
+	Operation string
+	Current   string
+	State     PowerState
+}
+
+func (e *OperationConflictError) Error() string {
+	return fmt.Sprintf("cannot %s: server is %s (%s in progress)", e.Operation, e.State, e.Current)
+}
+
+type powerLock struct {
+	sem   chan struct{}
+	mu    sync.Mutex
+	op    string
+	state PowerState
+	since time.Time
+}
+
+type StateInfo struct {
+	State     PowerState `json:"state"`
+	Operation string     `json:"operation,omitempty"`
+	Since     int64      `json:"since,omitempty"`
+}
+
+type StateChange struct {
+	ServerID  string     `json:"server_id"`
+	From      PowerState `json:"from"`
+	To        PowerState `json:"to"`
+	Operation string     `json:"operation,omitempty"`
+	Error     string     `json:"error,omitempty"`
+	At        int64      `json:"at"`
+}
+
+var (
+	powerLocks   = make(map[string]*powerLock)
+	powerLocksMu sync.Mutex
+
+	lastStates     = make(map[string]PowerState)
+	stateWatchers  = make(map[string][]chan StateChange)
+	lastStatesMu   sync.Mutex
+	stateReportsCh = make(chan StateChange, 256)
+)
+
+func init() {
+	go stateReporter()
+	go stateSupervisor()
+}
+
+func getPowerLock(serverID string) *powerLock {
+	powerLocksMu.Lock()
+	defer powerLocksMu.Unlock()
+	l, ok := powerLocks[serverID]
+	if !ok {
+		l = &powerLock{sem: make(chan struct{}, 1)}
+		powerLocks[serverID] = l
+	}
+	return l
+}
+
+func (l *powerLock) conflict(op string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return &OperationConflictError{Operation: op, Current: l.op, State: l.state}
+}
+
+// Operation holds a server's power lock until End is called.
+type Operation struct {
+	serverID string
+	name     string
+	lock     *powerLock
+	once     sync.Once
+}
+
+// BeginOperation takes the server's power lock for op and moves it into
+// state. If another operation holds the lock it waits up to wait before
+// giving up with an *OperationConflictError.
+func BeginOperation(serverID, op string, state PowerState, wait time.Duration) (*Operation, error) {
+	l := getPowerLock(serverID)
+	select {
+	case l.sem <- struct{}{}:
+	default:
+		if wait <= 0 {
+			return nil, l.conflict(op)
+		}
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case l.sem <- struct{}{}:
+		case <-timer.C:
+			return nil, l.conflict(op)
+		}
+	}
+
+	cancelReadiness(serverID)
+	l.mu.Lock()
+	l.op, l.state, l.since = op, state, time.Now()
+	l.mu.Unlock()
+	setPowerState(serverID, state, op)
+	return &Operation{serverID: serverID, name: op, lock: l}, nil
+}
+
+// Transition moves a running operation into a new transient state, e.g. a
+// reinstall going from installing to starting.
+func (o *Operation) Transition(state PowerState) {
+	o.lock.mu.Lock()
+	o.lock.state = state
+	o.lock.mu.Unlock()
+	setPowerState(o.serverID, state, o.name)
+}
+
+// transitionOperation moves whatever operation currently holds the server
+// into state, for steps inside a compound call like a restart.
+func transitionOperation(serverID string, from, to PowerState) {
+	l := getPowerLock(serverID)
+	l.mu.Lock()
+	if l.state != from {
+		l.mu.Unlock()
+		return
+	}
+	l.state = to
+	op := l.op
+	l.mu.Unlock()
+	setPowerState(serverID, to, op)
+}
+
+// End releases the lock and settles the state from the container.
+func (o *Operation) End() {
+	o.once.Do(func() {
+		o.lock.mu.Lock()
+		o.lock.op, o.lock.state = "", ""
+		o.lock.mu.Unlock()
+		<-o.lock.sem
+		setPowerState(o.serverID, containerState(o.serverID), o.name)
+		if GetServerConfig(o.serverID) == nil {
+			forgetPowerState(o.serverID)
+		}
+	})
+}
+
+// CheckOperation returns a conflict if the server is busy with an operation
+// other than the ones listed, without taking the lock.
+func CheckOperation(serverID, op string, allowed ...PowerState) error {
+	l := getPowerLock(serverID)
+	l.mu.Lock()
+	state := l.state
+	l.mu.Unlock()
+	if state == "" {
+		return nil
+	}
+	for _, s := range allowed {
+		if s == state {
+			return nil
+		}
+	}
+	return l.conflict(op)
+}
+
+func containerState(serverID string) PowerState {
+	if status, _ := GetStatus(serverID); status == "running" {
+		if readinessPending(serverID) {
+			return StateStarting
+		}
+		return StateRunning
+	}
+	return StateOffline
+}
+
+func GetPowerState(serverID string) StateInfo {
+	l := getPowerLock(serverID)
+	l.mu.Lock()
+	op, state, since := l.op, l.state, l.since
+	l.mu.Unlock()
+	if state != "" {
+		return StateInfo{State: state, Operation: op, Since: since.UnixMilli()}
+	}
+	if IsInstalling(serverID) {
+		return StateInfo{State: StateInstalling}
+	}
+	return StateInfo{State: containerState(serverID)}
+}
+
+func setPowerState(serverID string, state PowerState, op string) {
+	reportPowerState(serverID, state, op, "")
+}
+
+// reportPowerState records a transition and passes it on to websocket
+// watchers and the panel. errMsg explains a failed operation.
+func reportPowerState(serverID string, state PowerState, op, errMsg string) {
+	lastStatesMu.Lock()
+	from, known := lastStates[serverID]
+	if known && from == state {
+		lastStatesMu.Unlock()
+		return
+	}
+	if !known {
+		from = StateOffline
+	}
+	lastStates[serverID] = state
+	change := StateChange{ServerID: serverID, From: from, To: state, Operation: op, Error: errMsg, At: time.Now().UnixMilli()}
+	for _, ch := range stateWatchers[serverID] {
+		select {
+		case ch <- change:
+		default:
+		}
+	}
+	lastStatesMu.Unlock()
+
+	// Stops seen without an operation are crashes or the host shutting
+	// down, which should not keep the server down after a reconcile.
+	if state == StateRunning {
+		rememberRunning(serverID, true)
+	} else if state == StateOffline && op != "" {
+		rememberRunning(serverID, false)
+	}
+
+	logger.Info("Server %s state: %s -> %s", serverID, from, state)
+	select {
+	case stateReportsCh <- change:
+	default:
+		logger.Warn("Dropping state report for %s: queue full", serverID)
+	}
+}
+
+func WatchState(serverID string) chan StateChange {
+	ch := make(chan StateChange, 16)
+	lastStatesMu.Lock()
+	stateWatchers[serverID] = append(stateWatchers[serverID], ch)
+	lastStatesMu.Unlock()
+	return ch
+}
+
+func UnwatchState(serverID string, ch chan StateChange) {
+	lastStatesMu.Lock()
+	defer lastStatesMu.Unlock()
+	subs := stateWatchers[serverID]
+	for i, sub := range subs {
+		if sub == ch {
+			stateWatchers[serverID] = append(subs[:i], subs[i+1:]...)
+			break
+		}
+	}
+	if len(stateWatchers[serverID]) == 0 {
+		delete(stateWatchers, serverID)
+	}
+}
+
+func forgetPowerState(serverID string) {
+	lastStatesMu.Lock()
+	delete(lastStates, serverID)
+	lastStatesMu.Unlock()
+}
+
+func stateReporter() {
+	for change := range stateReportsCh {
+		if config.Get() == nil {
+			continue
+		}
+		if err := panel.NewClient().ReportServerState(change.ServerID, string(change.From), string(change.To), change.Operation, change.Error, change.At); err != nil {
+			logger.Warn("Failed to report state for %s: %v", change.ServerID, err)
+		}
+	}
+}
+
+// stateSupervisor picks up transitions nobody asked for, like a crash or
+// the game stopping itself, for servers that aren't mid-operation.
+func stateSupervisor() {
+	ticker := time.NewTicker(5 * time.Second)
+	for range ticker.C {
+		if docker.Client == nil || config.Get() == nil {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		containers, err := docker.Client.ContainerList(ctx, container.ListOptions{
+			Filters: filters.NewArgs(filters.Arg("name", "birdactyl-"), filters.Arg("status", "running")),
+		})
+		cancel()
+		if err != nil {
+			continue
+		}
+		running := make(map[string]bool)
+		for _, c := range containers {
+			for _, n := range c.Names {
+				if id, ok := strings.CutPrefix(strings.TrimPrefix(n, "/"), "birdactyl-"); ok {
+					running[id] = true
+				}
+			}
+		}
+
+		serverConfigsMu.RLock()
+		ids := make([]string, 0, len(serverConfigs))
+		for id := range serverConfigs {
+			ids = append(ids, id)
+		}
+		serverConfigsMu.RUnlock()
+
+		for _, id := range ids {
+			l := getPowerLock(id)
+			l.mu.Lock()
+			busy := l.state != ""
+			l.mu.Unlock()
+			if busy || IsInstalling(id) || readinessPending(id) {
+				continue
+			}
+			state := StateOffline
+			if running[id] {
+				state = StateRunning
+			}
+			setPowerState(id, state, "")
+		}
+	}
+}
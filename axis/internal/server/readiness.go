@@ -0,0 +1,214 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"cauthon-axis/internal/docker"
+	"cauthon-axis/internal/logger"
+)
+
+const defaultStartupTimeout = 300
+
+// readinessWait tracks a started server until it prints one of its package's
+// done patterns and, if asked, its primary port accepts connections.
+type readinessWait struct {
+	cancel chan struct{}
+	once   sync.Once
+}
+
+func (w *readinessWait) stop() { w.once.Do(func() { close(w.cancel) }) }
+
+var (
+	readinessWaits   = make(map[string]*readinessWait)
+	readinessWaitsMu sync.Mutex
+)
+
+type donePattern struct {
+	text string
+	re   *regexp.Regexp
+}
+
+// compileDonePatterns treats patterns as plain substrings unless they start
+// with "regex:".
+func compileDonePatterns(patterns []string) ([]donePattern, error) {
+	var out []donePattern
+	for _, p := range patterns {
+		if p == "" {
+			continue
+		}
+		if expr, ok := strings.CutPrefix(p, "regex:"); ok {
+			re, err := regexp.Compile(expr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid done pattern %q: %w", p, err)
+			}
+			out = append(out, donePattern{re: re})
+			continue
+		}
+		out = append(out, donePattern{text: p})
+	}
+	return out, nil
+}
+
+func matchDone(patterns []donePattern, line string) bool {
+	line = stripANSI(line)
+	for _, p := range patterns {
+		if p.re != nil && p.re.MatchString(line) {
+			return true
+		}
+		if p.re == nil && strings.Contains(line, p.text) {
+			return true
+		}
+	}
+	return false
+}
+
+// probeAddress returns the host and port the readiness probe dials: the
+// first TCP port, on its bound IP or on loopback when it binds everything.
+func probeAddress(cfg *ServerConfig) (string, int) {
+	for _, p := range cfg.Ports {
+		if p.Protocol == "" || strings.EqualFold(p.Protocol, "tcp") {
+			host := p.BindIP()
+			if host == anyIP {
+				host = "127.0.0.1"
+			}
+			return host, p.Host
+		}
+	}
+	return "", 0
+}
+
+func readinessPending(serverID string) bool {
+	readinessWaitsMu.Lock()
+	defer readinessWaitsMu.Unlock()
+	return readinessWaits[serverID] != nil
+}
+
+func cancelReadiness(serverID string) {
+	readinessWaitsMu.Lock()
+	w := readinessWaits[serverID]
+	delete(readinessWaits, serverID)
+	readinessWaitsMu.Unlock()
+	if w != nil {
+		w.stop()
+	}
+}
+
+// watchReadiness keeps the server in starting until it is ready, from console
+// sequence seq onwards. Servers whose package declares no done patterns and
+// no port probe are ready as soon as the container runs.
+func watchReadiness(serverID string, cfg *ServerConfig, seq uint64) {
+	cancelReadiness(serverID)
+	if cfg == nil || (len(cfg.StartupDone) == 0 && !cfg.StartupProbe) {
+		return
+	}
+	patterns, err := compileDonePatterns(cfg.StartupDone)
+	if err != nil {
+		BroadcastLog(serverID, err.Error())
+		return
+	}
+	host, port := "", 0
+	if cfg.StartupProbe {
+		if host, port = probeAddress(cfg); port == 0 {
+			logger.Warn("Server %s has a port probe but no TCP port", serverID)
+		}
+	}
+	if len(patterns) == 0 && port == 0 {
+		return
+	}
+	timeout := cfg.StartupTimeout
+	if timeout <= 0 {
+		timeout = defaultStartupTimeout
+	}
+
+	w := &readinessWait{cancel: make(chan struct{})}
+	readinessWaitsMu.Lock()
+	readinessWaits[serverID] = w
+	readinessWaitsMu.Unlock()
+
+	go w.run(serverID, patterns, host, port, time.Duration(timeout)*time.Second, seq)
+}
+
+func (w *readinessWait) run(serverID string, patterns []donePattern, host string, port int, timeout time.Duration, seq uint64) {
+	watch := WatchConsole(serverID)
+	defer UnwatchConsole(serverID, watch)
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+
+	started := time.Now()
+	logReady, portReady := len(patterns) == 0, port == 0
+	pos := seq
+
+	for {
+		for !logReady {
+			entries, _, next := ReadConsole(serverID, pos, consoleBufferSize)
+			pos = next
+			for _, e := range entries {
+				if matchDone(patterns, e.Line) {
+					logReady = true
+					break
+				}
+			}
+			if len(entries) == 0 {
+				break
+			}
+		}
+		if logReady && portReady {
+			w.finish(serverID, "", started)
+			return
+		}
+
+		select {
+		case <-w.cancel:
+			return
+		case <-watch:
+		case <-ticker.C:
+			if status, _ := GetStatus(serverID); status != "running" {
+				w.finish(serverID, "server exited before it was ready", started)
+				return
+			}
+			if !portReady {
+				conn, err := net.DialTimeout("tcp", net.JoinHostPort(host, strconv.Itoa(port)), time.Second)
+				if err == nil {
+					conn.Close()
+					portReady = true
+				}
+			}
+		case <-deadline.C:
+			BroadcastLog(serverID, fmt.Sprintf("Server did not become ready within %s, stopping it", timeout))
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			if id, err := docker.GetContainerID(ctx, containerName(serverID)); err == nil {
+				docker.KillContainer(ctx, id)
+			}
+			cancel()
+			w.finish(serverID, fmt.Sprintf("startup timed out after %s", timeout), started)
+			return
+		}
+	}
+}
+
+func (w *readinessWait) finish(serverID, errMsg string, started time.Time) {
+	readinessWaitsMu.Lock()
+	if readinessWaits[serverID] != w {
+		readinessWaitsMu.Unlock()
+		return
+	}
+	delete(readinessWaits, serverID)
+	readinessWaitsMu.Unlock()
+
+	if errMsg != "" {
+		logger.Warn("Server %s failed to start: %s", serverID, errMsg)
+		reportPowerState(serverID, StateOffline, "start", errMsg)
+		return
+	}
+	BroadcastLog(serverID, fmt.Sprintf("Server is ready (%.1fs)", time.Since(started).Seconds()))
+	setPowerState(serverID, StateRunning, "start")
+}
@@ -0,0 +1,162 @@
+package server
+
+import (
+	"context"
+	"os"
+	"strings"
+	"time"
+
+	"cauthon-axis/internal/config"
+	"cauthon-axis/internal/docker"
+	"cauthon-axis/internal/logger"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+const reconcileLockWait = 30 * time.Second
+
+type ReconcileFailure struct {
+	ServerID string `json:"server_id"`
+	Error    string `json:"error"`
+}
+
+type ReconcileOrphan struct {
+	ServerID  string `json:"server_id"`
+	Container string `json:"container,omitempty"`
+	HasData   bool   `json:"has_data"`
+}
+
+type ReconcileReport struct {
+	StartedAt           int64              `json:"started_at"`
+	FinishedAt          int64              `json:"finished_at"`
+	Known               int                `json:"known"`
+	Adopted             []string           `json:"adopted"`
+	Recreated           []string           `json:"recreated"`
+	Started             []string           `json:"started"`
+	Failed              []ReconcileFailure `json:"failed"`
+	Orphans             []ReconcileOrphan  `json:"orphans"`
+	InterruptedInstalls []string           `json:"interrupted_installs"`
+}
+
+// Reconcile loads persisted server configs and brings Docker in line with
+// them: existing containers are adopted, missing ones are recreated and
+// containers or data without a config are reported as orphans.
+func Reconcile() *ReconcileReport {
+	report := &ReconcileReport{
+		StartedAt:           time.Now().Unix(),
+		Adopted:             []string{},
+		Recreated:           []string{},
+		Started:             []string{},
+		Failed:              []ReconcileFailure{},
+		Orphans:             []ReconcileOrphan{},
+		InterruptedInstalls: []string{},
+	}
+
+	configs := loadServerConfigs()
+	report.Known = len(configs)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	containers, err := docker.Client.ContainerList(ctx, container.ListOptions{All: true})
+	cancel()
+	if err != nil {
+		logger.Error("Reconcile: failed to list containers: %v", err)
+		report.FinishedAt = time.Now().Unix()
+		return report
+	}
+
+	existing := make(map[string]string)
+	running := make(map[string]bool)
+	for _, c := range containers {
+		for _, n := range c.Names {
+			n = strings.TrimPrefix(n, "/")
+			if id, ok := strings.CutPrefix(n, "birdactyl-install-"); ok {
+				removeInterruptedInstall(c.ID, id)
+				report.InterruptedInstalls = append(report.InterruptedInstalls, id)
+				continue
+			}
+			if id, ok := strings.CutPrefix(n, "birdactyl-"); ok {
+				existing[id] = n
+				running[id] = c.State == "running"
+			}
+		}
+	}
+
+	for id, cfg := range configs {
+		_, exists := existing[id]
+		restore := wasRunning(id) && !running[id]
+		if exists {
+			report.Adopted = append(report.Adopted, id)
+			if !restore {
+				continue
+			}
+		}
+		if err := reconcileServer(cfg, !exists, restore); err != nil {
+			logger.Error("Reconcile: failed to restore %s: %v", id, err)
+			report.Failed = append(report.Failed, ReconcileFailure{ServerID: id, Error: err.Error()})
+			continue
+		}
+		if !exists {
+			report.Recreated = append(report.Recreated, id)
+		}
+		if restore {
+			report.Started = append(report.Started, id)
+		}
+	}
+
+	for id, name := range existing {
+		if configs[id] == nil {
+			report.Orphans = append(report.Orphans, ReconcileOrphan{ServerID: id, Container: name, HasData: !IsDataDirEmpty(id)})
+		}
+	}
+	if entries, err := os.ReadDir(config.Get().Node.DataDir); err == nil {
+		for _, e := range entries {
+			id := e.Name()
+			if !e.IsDir() || ValidateServerID(id) != nil || configs[id] != nil {
+				continue
+			}
+			if _, ok := existing[id]; ok {
+				continue
+			}
+			report.Orphans = append(report.Orphans, ReconcileOrphan{ServerID: id, HasData: true})
+		}
+	}
+
+	report.FinishedAt = time.Now().Unix()
+	logger.Info("Reconcile: %d known, %d adopted, %d recreated, %d started, %d failed, %d orphans, %d interrupted installs",
+		report.Known, len(report.Adopted), len(report.Recreated), len(report.Started), len(report.Failed), len(report.Orphans), len(report.InterruptedInstalls))
+	return report
+}
+
+// reconcileServer recreates a missing container and starts servers that
+// were running before, holding the server's power lock so API calls made
+// while Axis boots cannot interleave with it.
+func reconcileServer(cfg *ServerConfig, create, start bool) error {
+	state := StateOffline
+	if start {
+		state = StateStarting
+	}
+	op, err := BeginOperation(cfg.ID, "reconcile", state, reconcileLockWait)
+	if err != nil {
+		return err
+	}
+	defer op.End()
+
+	if create {
+		if err := CreateContainer(*cfg); err != nil {
+			return err
+		}
+	}
+	if start {
+		return Start(cfg.ID)
+	}
+	return nil
+}
+
+func removeInterruptedInstall(containerID, serverID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	docker.RemoveContainer(ctx, containerID, true)
+	logger.Warn("Reconcile: removed interrupted install container for %s", serverID)
+
+	go reportInstallFinished(serverID, false, -1, 0, "install interrupted by daemon restart")
+}
@@ -0,0 +1,71 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"cauthon-axis/internal/docker"
+
+	"github.com/docker/docker/api/types"
+)
+
+// shellCommand prefers bash and falls back to sh for minimal images.
+var shellCommand = []string{"/bin/sh", "-c", "if command -v bash >/dev/null 2>&1; then exec bash; else exec sh; fi"}
+
+// ShellSession is an interactive exec inside a server's container.
+type ShellSession struct {
+	execID  string
+	conn    types.HijackedResponse
+	writeMu sync.Mutex
+}
+
+func OpenShell(serverID string) (*ShellSession, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	info, err := docker.Client.ContainerInspect(ctx, containerName(serverID))
+	if err != nil {
+		return nil, err
+	}
+	if info.State == nil || !info.State.Running {
+		return nil, ErrNotRunning
+	}
+
+	execID, conn, err := docker.ExecShell(context.Background(), info.ID, shellCommand)
+	if err != nil {
+		return nil, err
+	}
+	return &ShellSession{execID: execID, conn: conn}, nil
+}
+
+func (s *ShellSession) Read(p []byte) (int, error) {
+	return s.conn.Reader.Read(p)
+}
+
+func (s *ShellSession) Write(p []byte) (int, error) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	return s.conn.Conn.Write(p)
+}
+
+func (s *ShellSession) Resize(cols, rows uint) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return docker.ResizeExec(ctx, s.execID, rows, cols)
+}
+
+// ExitCode returns the shell's exit code, or -1 while it is still running.
+func (s *ShellSession) ExitCode() int {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	code, err := docker.ExecExitCode(ctx, s.execID)
+	if err != nil {
+		return -1
+	}
+	return code
+}
+
+func (s *ShellSession) Close() {
+	s.conn.Close()
+}
@@ -0,0 +1,115 @@
+package server
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"cauthon-axis/internal/config"
+	"cauthon-axis/internal/logger"
+)
+
+func serverConfigDir() string {
+	return filepath.Join(config.Get().Node.StateDir, "servers")
+}
+
+func serverConfigPath(serverID string) string {
+	return filepath.Join(serverConfigDir(), serverID+".json")
+}
+
+func storeServerConfig(cfg *ServerConfig) {
+	serverConfigsMu.Lock()
+	serverConfigs[cfg.ID] = cfg
+	serverConfigsMu.Unlock()
+
+	if err := writeServerConfig(cfg); err != nil {
+		logger.Warn("Failed to persist config for %s: %v", cfg.ID, err)
+	}
+}
+
+func writeServerConfig(cfg *ServerConfig) error {
+	if err := os.MkdirAll(serverConfigDir(), 0700); err != nil {
+		return err
+	}
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	path := serverConfigPath(cfg.ID)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func forgetServerConfig(serverID string) {
+	serverConfigsMu.Lock()
+	delete(serverConfigs, serverID)
+	serverConfigsMu.Unlock()
+	os.Remove(serverConfigPath(serverID))
+	os.Remove(runningMarkerPath(serverID))
+}
+
+func runningMarkerPath(serverID string) string {
+	return filepath.Join(serverConfigDir(), serverID+".running")
+}
+
+// rememberRunning records whether a server is meant to be running, so
+// Reconcile can bring back servers that were up before Axis or the host
+// restarted.
+func rememberRunning(serverID string, running bool) {
+	if running {
+		os.WriteFile(runningMarkerPath(serverID), nil, 0600)
+	} else {
+		os.Remove(runningMarkerPath(serverID))
+	}
+}
+
+func wasRunning(serverID string) bool {
+	_, err := os.Stat(runningMarkerPath(serverID))
+	return err == nil
+}
+
+func GetServerConfig(serverID string) *ServerConfig {
+	serverConfigsMu.RLock()
+	defer serverConfigsMu.RUnlock()
+	return serverConfigs[serverID]
+}
+
+func loadServerConfigs() map[string]*ServerConfig {
+	configs := make(map[string]*ServerConfig)
+	entries, err := os.ReadDir(serverConfigDir())
+	if err != nil {
+		return configs
+	}
+
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		id := strings.TrimSuffix(name, ".json")
+		if ValidateServerID(id) != nil {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(serverConfigDir(), name))
+		if err != nil {
+			continue
+		}
+		var cfg ServerConfig
+		if err := json.Unmarshal(data, &cfg); err != nil || cfg.ID != id {
+			logger.Warn("Ignoring unreadable server config %s", name)
+			continue
+		}
+		configs[id] = &cfg
+	}
+
+	serverConfigsMu.Lock()
+	for id, cfg := range configs {
+		serverConfigs[id] = cfg
+	}
+	serverConfigsMu.Unlock()
+	return configs
+}
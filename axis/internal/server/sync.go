@@ -0,0 +1,219 @@
+package server
+
+import (
+	"context"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"cauthon-axis/internal/config"
+	"cauthon-axis/internal/docker"
+	"cauthon-axis/internal/logger"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+type DriftReport struct {
+	CheckedAt         int64    `json:"checked_at"`
+	Servers           int      `json:"servers"`
+	MissingContainers []string `json:"missing_containers"`
+	MissingData       []string `json:"missing_data"`
+	OrphanContainers  []string `json:"orphan_containers"`
+	OrphanData        []string `json:"orphan_data"`
+	OrphanBackups     []string `json:"orphan_backups"`
+}
+
+type SyncResult struct {
+	Removed   []string           `json:"removed"`
+	Recreated []string           `json:"recreated"`
+	Skipped   []string           `json:"skipped"`
+	Failed    []ReconcileFailure `json:"failed"`
+}
+
+func listServerContainers() (map[string]bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	containers, err := docker.Client.ContainerList(ctx, container.ListOptions{All: true})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]bool)
+	for _, c := range containers {
+		for _, n := range c.Names {
+			n = strings.TrimPrefix(n, "/")
+			if strings.HasPrefix(n, "birdactyl-install-") {
+				continue
+			}
+			if id, ok := strings.CutPrefix(n, "birdactyl-"); ok {
+				result[id] = true
+			}
+		}
+	}
+	return result, nil
+}
+
+func listServerDirs(root string) []string {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil
+	}
+	var ids []string
+	for _, e := range entries {
+		if e.IsDir() && e.Name() != "transfers" && ValidateServerID(e.Name()) == nil {
+			ids = append(ids, e.Name())
+		}
+	}
+	return ids
+}
+
+// receiving reports whether this node is taking in the server through a
+// transfer or clone, before the panel lists it here.
+func receiving(serverID string) bool {
+	if IsInstalling(serverID) || CheckOperation(serverID, "purge") != nil {
+		return true
+	}
+	for _, t := range []*jobTable{imports, syncs} {
+		if status, ok := t.get(serverID); ok && status.Stage != JobFailed {
+			return true
+		}
+	}
+	return false
+}
+
+// ComputeDrift compares the panel's authoritative server list with what is on
+// this node without changing anything.
+func ComputeDrift(desired []ServerConfig) (*DriftReport, error) {
+	containers, err := listServerContainers()
+	if err != nil {
+		return nil, err
+	}
+
+	wanted := make(map[string]bool, len(desired))
+	for _, cfg := range desired {
+		if ValidateServerID(cfg.ID) == nil {
+			wanted[cfg.ID] = true
+		}
+	}
+
+	report := &DriftReport{
+		CheckedAt:         time.Now().Unix(),
+		Servers:           len(wanted),
+		MissingContainers: []string{},
+		MissingData:       []string{},
+		OrphanContainers:  []string{},
+		OrphanData:        []string{},
+		OrphanBackups:     []string{},
+	}
+
+	for id := range wanted {
+		if IsInstalling(id) {
+			continue
+		}
+		if !containers[id] {
+			report.MissingContainers = append(report.MissingContainers, id)
+		}
+		if _, err := os.Stat(serverDataDir(id)); err != nil {
+			report.MissingData = append(report.MissingData, id)
+		}
+	}
+	for id := range containers {
+		if !wanted[id] && !receiving(id) {
+			report.OrphanContainers = append(report.OrphanContainers, id)
+		}
+	}
+
+	cfg := config.Get()
+	for _, id := range listServerDirs(cfg.Node.DataDir) {
+		if !wanted[id] && !receiving(id) {
+			report.OrphanData = append(report.OrphanData, id)
+		}
+	}
+	for _, id := range listServerDirs(cfg.Node.BackupDir) {
+		if !wanted[id] {
+			report.OrphanBackups = append(report.OrphanBackups, id)
+		}
+	}
+
+	sort.Strings(report.MissingContainers)
+	sort.Strings(report.MissingData)
+	sort.Strings(report.OrphanContainers)
+	sort.Strings(report.OrphanData)
+	sort.Strings(report.OrphanBackups)
+	return report, nil
+}
+
+// ApplyDrift removes confirmed orphans and recreates confirmed missing
+// containers. Drift is recomputed first so stale confirmations are skipped.
+func ApplyDrift(desired []ServerConfig, removeOrphans, recreate []string) (*SyncResult, error) {
+	report, err := ComputeDrift(desired)
+	if err != nil {
+		return nil, err
+	}
+
+	orphans := make(map[string]bool)
+	for _, list := range [][]string{report.OrphanContainers, report.OrphanData, report.OrphanBackups} {
+		for _, id := range list {
+			orphans[id] = true
+		}
+	}
+	missing := make(map[string]bool)
+	for _, id := range report.MissingContainers {
+		missing[id] = true
+	}
+
+	result := &SyncResult{Removed: []string{}, Recreated: []string{}, Skipped: []string{}, Failed: []ReconcileFailure{}}
+
+	for _, id := range removeOrphans {
+		if !orphans[id] {
+			result.Skipped = append(result.Skipped, id)
+			continue
+		}
+		if err := CheckOperation(id, "purge"); err != nil {
+			result.Failed = append(result.Failed, ReconcileFailure{ServerID: id, Error: err.Error()})
+			continue
+		}
+		purgeServer(id)
+		logger.Info("Sync: removed orphaned server %s", id)
+		result.Removed = append(result.Removed, id)
+	}
+
+	configs := make(map[string]ServerConfig, len(desired))
+	for _, cfg := range desired {
+		if cfg.DockerImage != "" {
+			configs[cfg.ID] = cfg
+		}
+	}
+
+	for _, id := range recreate {
+		cfg, ok := configs[id]
+		if !missing[id] || !ok {
+			result.Skipped = append(result.Skipped, id)
+			continue
+		}
+		if err := CheckOperation(id, "recreate"); err != nil {
+			result.Failed = append(result.Failed, ReconcileFailure{ServerID: id, Error: err.Error()})
+			continue
+		}
+		if err := CreateContainer(cfg); err != nil {
+			result.Failed = append(result.Failed, ReconcileFailure{ServerID: id, Error: err.Error()})
+			continue
+		}
+		logger.Info("Sync: recreated container for %s", id)
+		result.Recreated = append(result.Recreated, id)
+	}
+
+	return result, nil
+}
+
+func purgeServer(serverID string) {
+	RemoveContainer(serverID)
+	forgetServerConfig(serverID)
+	os.RemoveAll(serverDataDir(serverID))
+	os.RemoveAll(trashDir(serverID))
+	os.RemoveAll(backupDir(serverID))
+	removeInstallLog(serverID)
+	removeConsoleLogs(serverID)
+}
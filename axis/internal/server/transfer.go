@@ -0,0 +1,216 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"cauthon-axis/internal/config"
+	"cauthon-axis/internal/logger"
+)
+
+const (
+	JobScanning       = "scanning"
+	JobCopying        = "copying"
+	ImportDownloading = "downloading"
+	ImportExtracting  = "extracting"
+	JobDone           = "done"
+	JobFailed         = "failed"
+)
+
+// ImportSource describes where a transfer archive comes from and what it
+// should look like once downloaded.
+type ImportSource struct {
+	URL      string
+	Token    string
+	Checksum string
+	Size     int64
+}
+
+// JobStatus is the progress of a transfer import or sync as reported to
+// the panel.
+type JobStatus struct {
+	Stage      string `json:"stage"`
+	BytesDone  int64  `json:"bytes_done"`
+	BytesTotal int64  `json:"bytes_total"`
+	FilesDone  int64  `json:"files_done,omitempty"`
+	FilesTotal int64  `json:"files_total,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+type transferJob struct {
+	mu         sync.Mutex
+	stage      string
+	total      int64
+	filesTotal int64
+	err        string
+	done       atomic.Int64
+	filesDone  atomic.Int64
+}
+
+// jobTable keeps the latest job of one kind per server.
+type jobTable struct {
+	mu   sync.Mutex
+	jobs map[string]*transferJob
+}
+
+var (
+	imports = &jobTable{jobs: make(map[string]*transferJob)}
+	syncs   = &jobTable{jobs: make(map[string]*transferJob)}
+)
+
+var transferClient = &http.Client{}
+
+// FileChecksum returns the hex SHA-256 of a file.
+func FileChecksum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// start runs fn in the background unless the server already has a job of
+// this kind running. finished runs when fn returns.
+func (t *jobTable) start(serverID, kind, stage string, fn func(*transferJob) error, finished func()) error {
+	t.mu.Lock()
+	if job := t.jobs[serverID]; job != nil {
+		job.mu.Lock()
+		running := job.stage != JobDone && job.stage != JobFailed
+		job.mu.Unlock()
+		if running {
+			t.mu.Unlock()
+			return fmt.Errorf("%s already running for this server", kind)
+		}
+	}
+	job := &transferJob{stage: stage}
+	t.jobs[serverID] = job
+	t.mu.Unlock()
+
+	go func() {
+		defer finished()
+		if err := fn(job); err != nil {
+			logger.Error("Transfer %s failed for server %s: %v", kind, serverID, err)
+			job.set(JobFailed, err.Error())
+			return
+		}
+		logger.Success("Transfer %s complete for server %s", kind, serverID)
+		job.set(JobDone, "")
+	}()
+	return nil
+}
+
+func (t *jobTable) get(serverID string) (JobStatus, bool) {
+	t.mu.Lock()
+	job := t.jobs[serverID]
+	t.mu.Unlock()
+	if job == nil {
+		return JobStatus{}, false
+	}
+	job.mu.Lock()
+	defer job.mu.Unlock()
+	return JobStatus{
+		Stage:      job.stage,
+		BytesDone:  job.done.Load(),
+		BytesTotal: job.total,
+		FilesDone:  job.filesDone.Load(),
+		FilesTotal: job.filesTotal,
+		Error:      job.err,
+	}, true
+}
+
+// StartImport downloads a transfer archive from the source node, verifies it
+// and extracts it in the background. finished runs when the import ends,
+// whether it worked or not.
+func StartImport(serverID string, src ImportSource, finished func()) error {
+	return imports.start(serverID, "import", ImportDownloading, func(j *transferJob) error {
+		return j.runImport(serverID, src)
+	}, finished)
+}
+
+// GetImport returns the progress of the server's latest import.
+func GetImport(serverID string) (JobStatus, bool) {
+	return imports.get(serverID)
+}
+
+func (j *transferJob) set(stage, errMsg string) {
+	j.mu.Lock()
+	j.stage, j.err = stage, errMsg
+	j.mu.Unlock()
+}
+
+func (j *transferJob) setTotals(bytes, files int64) {
+	j.mu.Lock()
+	j.total, j.filesTotal = bytes, files
+	j.mu.Unlock()
+}
+
+func (j *transferJob) Write(p []byte) (int, error) {
+	j.done.Add(int64(len(p)))
+	return len(p), nil
+}
+
+func (j *transferJob) runImport(serverID string, src ImportSource) error {
+	req, err := http.NewRequest("GET", src.URL, nil)
+	if err != nil {
+		return err
+	}
+	if src.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+src.Token)
+	}
+	resp, err := transferClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch archive: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("source node returned status %s", resp.Status)
+	}
+	if src.Size > 0 {
+		j.setTotals(src.Size, 0)
+	} else if resp.ContentLength > 0 {
+		j.setTotals(resp.ContentLength, 0)
+	}
+
+	dir := filepath.Join(config.Get().Node.BackupDir, "transfers")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	tmpPath := filepath.Join(dir, serverID+"-import.tar.gz")
+	dst, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpPath)
+
+	h := sha256.New()
+	written, err := io.Copy(io.MultiWriter(dst, h, j), resp.Body)
+	dst.Close()
+	if err != nil {
+		return fmt.Errorf("failed to download archive: %v", err)
+	}
+	logger.Transfer("Downloaded %d bytes for %s", written, serverID)
+
+	if src.Size > 0 && written != src.Size {
+		return fmt.Errorf("archive size mismatch: expected %d bytes, got %d", src.Size, written)
+	}
+	if sum := hex.EncodeToString(h.Sum(nil)); src.Checksum != "" && !strings.EqualFold(sum, src.Checksum) {
+		return fmt.Errorf("archive checksum mismatch: expected %s, got %s", src.Checksum, sum)
+	}
+
+	j.set(ImportExtracting, "")
+	logger.Transfer("Extracting archive for %s", serverID)
+	return ImportServer(serverID, tmpPath)
+}
@@ -0,0 +1,273 @@
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"cauthon-axis/internal/config"
+	"cauthon-axis/internal/logger"
+)
+
+type TrashEntry struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Path      string `json:"path"`
+	Size      int64  `json:"size"`
+	IsDir     bool   `json:"is_dir"`
+	DeletedAt int64  `json:"deleted_at"`
+}
+
+var trashIDRegex = regexp.MustCompile(`^[a-f0-9]{24}$`)
+var trashMu sync.Mutex
+
+func init() {
+	go trashJanitor()
+}
+
+func trashDir(serverID string) string {
+	cfg := config.Get()
+	return filepath.Join(cfg.Node.TrashDir, serverID)
+}
+
+func newTrashID() string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func moveToTrash(serverID, target string) error {
+	base := serverDataDir(serverID)
+	info, err := os.Lstat(target)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	id := newTrashID()
+	entryDir := filepath.Join(trashDir(serverID), id)
+	if err := os.MkdirAll(entryDir, 0755); err != nil {
+		return err
+	}
+
+	dest := filepath.Join(entryDir, info.Name())
+	if err := movePath(target, dest, info.IsDir()); err != nil {
+		os.RemoveAll(entryDir)
+		return err
+	}
+
+	size := info.Size()
+	if info.IsDir() {
+		size = getDirSize(dest)
+	}
+
+	rel := strings.TrimPrefix(target, base)
+	entry := TrashEntry{
+		ID:        id,
+		Name:      info.Name(),
+		Path:      filepath.ToSlash(rel),
+		Size:      size,
+		IsDir:     info.IsDir(),
+		DeletedAt: time.Now().Unix(),
+	}
+	data, _ := json.Marshal(entry)
+	return os.WriteFile(entryDir+".json", data, 0644)
+}
+
+func movePath(src, dest string, isDir bool) error {
+	if err := os.Rename(src, dest); err == nil {
+		return nil
+	}
+
+	var err error
+	if isDir {
+		err = copyDir(src, dest)
+	} else {
+		err = copyFile(src, dest)
+	}
+	if err != nil {
+		os.RemoveAll(dest)
+		return err
+	}
+	return os.RemoveAll(src)
+}
+
+func readTrashEntry(serverID, id string) (*TrashEntry, error) {
+	if !trashIDRegex.MatchString(id) {
+		return nil, fmt.Errorf("invalid trash id")
+	}
+	data, err := os.ReadFile(filepath.Join(trashDir(serverID), id+".json"))
+	if err != nil {
+		return nil, fmt.Errorf("trash entry not found")
+	}
+	var entry TrashEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func ListTrash(serverID string) ([]TrashEntry, error) {
+	trashMu.Lock()
+	defer trashMu.Unlock()
+	return listTrash(serverID)
+}
+
+func listTrash(serverID string) ([]TrashEntry, error) {
+	entries, err := os.ReadDir(trashDir(serverID))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []TrashEntry{}, nil
+		}
+		return nil, err
+	}
+
+	items := make([]TrashEntry, 0, len(entries))
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		entry, err := readTrashEntry(serverID, strings.TrimSuffix(e.Name(), ".json"))
+		if err != nil {
+			continue
+		}
+		items = append(items, *entry)
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].DeletedAt > items[j].DeletedAt
+	})
+	return items, nil
+}
+
+func RestoreTrash(serverID string, ids []string) (int, error) {
+	trashMu.Lock()
+	defer trashMu.Unlock()
+
+	base := serverDataDir(serverID)
+	restored := 0
+	for _, id := range ids {
+		entry, err := readTrashEntry(serverID, id)
+		if err != nil {
+			continue
+		}
+
+		dest := filepath.Join(base, filepath.Clean("/"+entry.Path))
+		if !strings.HasPrefix(dest, base) || dest == base {
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			return restored, err
+		}
+		dest = uniquePath(dest)
+
+		entryDir := filepath.Join(trashDir(serverID), id)
+		if err := movePath(filepath.Join(entryDir, entry.Name), dest, entry.IsDir); err != nil {
+			return restored, err
+		}
+		os.RemoveAll(entryDir)
+		os.Remove(entryDir + ".json")
+		restored++
+	}
+	return restored, nil
+}
+
+func PurgeTrash(serverID string, ids []string) (int, error) {
+	trashMu.Lock()
+	defer trashMu.Unlock()
+
+	if len(ids) == 0 {
+		items, err := listTrash(serverID)
+		if err != nil {
+			return 0, err
+		}
+		if err := os.RemoveAll(trashDir(serverID)); err != nil {
+			return 0, err
+		}
+		return len(items), nil
+	}
+
+	purged := 0
+	for _, id := range ids {
+		if !trashIDRegex.MatchString(id) {
+			continue
+		}
+		if removeTrashEntry(serverID, id) == nil {
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+func removeTrashEntry(serverID, id string) error {
+	entryDir := filepath.Join(trashDir(serverID), id)
+	if err := os.RemoveAll(entryDir); err != nil {
+		return err
+	}
+	return os.Remove(entryDir + ".json")
+}
+
+func GetTrashSize(serverID string) int64 {
+	return getDirSize(trashDir(serverID))
+}
+
+func trashJanitor() {
+	ticker := time.NewTicker(10 * time.Minute)
+	for range ticker.C {
+		cfg := config.Get()
+		if cfg == nil {
+			continue
+		}
+		entries, err := os.ReadDir(cfg.Node.TrashDir)
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			if e.IsDir() && ValidateServerID(e.Name()) == nil {
+				purgeExpiredTrash(e.Name(), cfg.Trash)
+			}
+		}
+	}
+}
+
+func purgeExpiredTrash(serverID string, cfg config.TrashConfig) {
+	trashMu.Lock()
+	defer trashMu.Unlock()
+
+	items, err := listTrash(serverID)
+	if err != nil {
+		return
+	}
+
+	var cutoff int64
+	if *cfg.RetentionHours > 0 {
+		cutoff = time.Now().Add(-time.Duration(*cfg.RetentionHours) * time.Hour).Unix()
+	}
+	maxSize := int64(*cfg.MaxSizeMB) * 1024 * 1024
+
+	var total int64
+	purged := 0
+	for _, item := range items {
+		if (cutoff > 0 && item.DeletedAt < cutoff) || (maxSize > 0 && total+item.Size > maxSize) {
+			if removeTrashEntry(serverID, item.ID) == nil {
+				purged++
+			}
+			continue
+		}
+		total += item.Size
+	}
+
+	if purged > 0 {
+		logger.Info("Purged %d trash entries for server %s", purged, serverID)
+	}
+}
@@ -0,0 +1,194 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"cauthon-axis/internal/docker"
+	"cauthon-axis/internal/logger"
+
+	"github.com/docker/docker/api/types"
+)
+
+var ErrNotRunning = errors.New("server is not running")
+
+// ttySession is the single long-lived attach to a running container. Input
+// from every console client is written through it and its raw output is
+// fanned out to clients in tty mode.
+type ttySession struct {
+	containerID string
+	conn        types.HijackedResponse
+	writeMu     sync.Mutex
+}
+
+var (
+	ttySessions   sync.Map
+	ttySessionsMu sync.Mutex
+	ttySubs       = make(map[string]map[chan []byte]struct{})
+	ttySubsMu     sync.RWMutex
+)
+
+// attachTTY returns the server's console session, attaching to the
+// container when there is none. A session is dropped as soon as its attach
+// ends, so a stored one is always live. The Docker calls run outside
+// ttySessionsMu so one slow daemon call cannot hold up other servers.
+func attachTTY(serverID string) (*ttySession, error) {
+	if s, ok := ttySessions.Load(serverID); ok {
+		return s.(*ttySession), nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	info, err := docker.Client.ContainerInspect(ctx, containerName(serverID))
+	if err != nil {
+		return nil, err
+	}
+	if info.State == nil || !info.State.Running {
+		return nil, ErrNotRunning
+	}
+
+	conn, err := docker.AttachConsole(context.Background(), info.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	ttySessionsMu.Lock()
+	defer ttySessionsMu.Unlock()
+	if v, ok := ttySessions.Load(serverID); ok {
+		existing := v.(*ttySession)
+		if existing.containerID == info.ID {
+			conn.Close()
+			return existing, nil
+		}
+		existing.conn.Close()
+	}
+	s := &ttySession{containerID: info.ID, conn: conn}
+	ttySessions.Store(serverID, s)
+	go s.pump(serverID)
+	return s, nil
+}
+
+func (s *ttySession) pump(serverID string) {
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := s.conn.Reader.Read(buf)
+		if n > 0 {
+			chunk := append([]byte(nil), buf[:n]...)
+			ttySubsMu.RLock()
+			for ch := range ttySubs[serverID] {
+				select {
+				case ch <- chunk:
+				default:
+				}
+			}
+			ttySubsMu.RUnlock()
+		}
+		if err != nil {
+			break
+		}
+	}
+
+	ttySessions.CompareAndDelete(serverID, s)
+	s.conn.Close()
+}
+
+func (s *ttySession) write(data []byte) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	s.conn.Conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	_, err := s.conn.Conn.Write(data)
+	return err
+}
+
+func closeTTY(serverID string) {
+	if s, ok := ttySessions.LoadAndDelete(serverID); ok {
+		s.(*ttySession).conn.Close()
+	}
+}
+
+// WriteConsoleInput sends raw bytes to the server's stdin.
+func WriteConsoleInput(serverID string, data []byte) error {
+	s, err := attachTTY(serverID)
+	if err != nil {
+		return err
+	}
+	if err := s.write(data); err != nil {
+		closeTTY(serverID)
+		return err
+	}
+	return nil
+}
+
+func SendCommand(serverID string, command string) error {
+	return WriteConsoleInput(serverID, []byte(command+"\n"))
+}
+
+// CaptureCommand sends a command and returns the console lines printed in
+// the following wait, for callers that need to read its answer.
+func CaptureCommand(serverID, command string, wait time.Duration) ([]string, error) {
+	cur := OpenConsoleCursor(serverID, "", 0, 0)
+	if err := SendCommand(serverID, command); err != nil {
+		return nil, err
+	}
+	time.Sleep(wait)
+	entries, _, _ := ReadConsole(serverID, cur.Head, consoleBufferSize)
+	lines := make([]string, 0, len(entries))
+	for _, e := range entries {
+		lines = append(lines, stripANSI(e.Line))
+	}
+	return lines, nil
+}
+
+func ResizeConsole(serverID string, cols, rows uint) error {
+	if cols == 0 || rows == 0 {
+		return errors.New("invalid terminal size")
+	}
+	s, err := attachTTY(serverID)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return docker.ResizeTTY(ctx, s.containerID, rows, cols)
+}
+
+// SubscribeTTY returns raw terminal output. Chunks are dropped when the
+// subscriber falls behind. The subscription stays open when the attach
+// fails, and output resumes once the server is started again.
+func SubscribeTTY(serverID string) (chan []byte, error) {
+	ch := make(chan []byte, 256)
+	ttySubsMu.Lock()
+	if ttySubs[serverID] == nil {
+		ttySubs[serverID] = make(map[chan []byte]struct{})
+	}
+	ttySubs[serverID][ch] = struct{}{}
+	ttySubsMu.Unlock()
+	_, err := attachTTY(serverID)
+	return ch, err
+}
+
+// reattachTTY attaches to a freshly started container when console clients
+// are still waiting for tty output.
+func reattachTTY(serverID string) {
+	ttySubsMu.RLock()
+	n := len(ttySubs[serverID])
+	ttySubsMu.RUnlock()
+	if n == 0 {
+		return
+	}
+	if _, err := attachTTY(serverID); err != nil {
+		logger.Warn("Failed to attach tty for %s: %v", serverID, err)
+	}
+}
+
+func UnsubscribeTTY(serverID string, ch chan []byte) {
+	ttySubsMu.Lock()
+	delete(ttySubs[serverID], ch)
+	if len(ttySubs[serverID]) == 0 {
+		delete(ttySubs, serverID)
+	}
+	ttySubsMu.Unlock()
+}
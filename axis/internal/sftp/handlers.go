@@ -0,0 +1,144 @@
+package sftp
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	srv "cauthon-axis/internal/server"
+
+	"github.com/pkg/sftp"
+)
+
+type fsHandler struct {
+	serverID string
+	root     string
+}
+
+func newTrashHandlers(serverID, root string) sftp.Handlers {
+	h := &fsHandler{serverID: serverID, root: root}
+	return sftp.Handlers{FileGet: h, FilePut: h, FileCmd: h, FileList: h}
+}
+
+func (h *fsHandler) resolve(p string) (string, error) {
+	target := filepath.Join(h.root, filepath.Clean("/"+p))
+	if !strings.HasPrefix(target, h.root) {
+		return "", fmt.Errorf("invalid path")
+	}
+	return target, nil
+}
+
+func (h *fsHandler) Fileread(r *sftp.Request) (io.ReaderAt, error) {
+	target, err := h.resolve(r.Filepath)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(target)
+}
+
+func (h *fsHandler) Filewrite(r *sftp.Request) (io.WriterAt, error) {
+	target, err := h.resolve(r.Filepath)
+	if err != nil {
+		return nil, err
+	}
+
+	flags := os.O_WRONLY
+	pflags := r.Pflags()
+	if pflags.Creat {
+		flags |= os.O_CREATE
+	}
+	if pflags.Trunc {
+		flags |= os.O_TRUNC
+	}
+	if pflags.Excl {
+		flags |= os.O_EXCL
+	}
+	return os.OpenFile(target, flags, 0644)
+}
+
+func (h *fsHandler) Filecmd(r *sftp.Request) error {
+	target, err := h.resolve(r.Filepath)
+	if err != nil {
+		return err
+	}
+
+	switch r.Method {
+	case "Remove", "Rmdir":
+		return srv.DeletePath(h.serverID, r.Filepath)
+	case "Mkdir":
+		return os.Mkdir(target, 0755)
+	case "Rename", "PosixRename":
+		dest, err := h.resolve(r.Target)
+		if err != nil {
+			return err
+		}
+		return os.Rename(target, dest)
+	case "Setstat":
+		attrs := r.Attributes()
+		flags := r.AttrFlags()
+		if flags.Permissions {
+			if err := os.Chmod(target, attrs.FileMode()); err != nil {
+				return err
+			}
+		}
+		if flags.Size {
+			if err := os.Truncate(target, int64(attrs.Size)); err != nil {
+				return err
+			}
+		}
+		if flags.Acmodtime {
+			if err := os.Chtimes(target, time.Unix(int64(attrs.Atime), 0), time.Unix(int64(attrs.Mtime), 0)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "Symlink", "Link":
+		return fmt.Errorf("links are not supported")
+	}
+	return fmt.Errorf("unsupported command: %s", r.Method)
+}
+
+func (h *fsHandler) Filelist(r *sftp.Request) (sftp.ListerAt, error) {
+	target, err := h.resolve(r.Filepath)
+	if err != nil {
+		return nil, err
+	}
+
+	switch r.Method {
+	case "List":
+		entries, err := os.ReadDir(target)
+		if err != nil {
+			return nil, err
+		}
+		infos := make([]os.FileInfo, 0, len(entries))
+		for _, e := range entries {
+			if info, err := e.Info(); err == nil {
+				infos = append(infos, info)
+			}
+		}
+		return listerAt(infos), nil
+	case "Stat", "Lstat":
+		info, err := os.Lstat(target)
+		if err != nil {
+			return nil, err
+		}
+		return listerAt{info}, nil
+	}
+	return nil, fmt.Errorf("unsupported list method: %s", r.Method)
+}
+
+type listerAt []os.FileInfo
+
+func (l listerAt) ListAt(dst []os.FileInfo, offset int64) (int, error) {
+	if offset >= int64(len(l)) {
+		return 0, io.EOF
+	}
+	n := copy(dst, l[offset:])
+	if n < len(dst) {
+		return n, io.EOF
+	}
+	return n, nil
+}
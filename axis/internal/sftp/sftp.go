@@ -139,6 +139,18 @@ func handleSFTP(channel ssh.Channel, username string) {
 		return
 	}
 
+	if cfg.Trash.SFTP {
+		requestServer := sftp.NewRequestServer(
+			channel,
+			newTrashHandlers(serverID, rootPath),
+			sftp.WithStartDirectory("/"),
+		)
+		if err := requestServer.Serve(); err != nil && err != io.EOF {
+			logger.Warn("SFTP session error for %s: %v", serverID, err)
+		}
+		return
+	}
+
 	sftpServer, err := sftp.NewServer(
 		channel,
 		sftp.WithServerWorkingDirectory(rootPath),
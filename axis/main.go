@@ -16,6 +16,7 @@ import (
 	"cauthon-axis/internal/logger"
 	"cauthon-axis/internal/pairing"
 	"cauthon-axis/internal/panel"
+	"cauthon-axis/internal/server"
 	"cauthon-axis/internal/sftp"
 )
 
@@ -83,6 +84,13 @@ func main() {
 
 	go heartbeatLoop(client)
 
+	go func() {
+		report := server.Reconcile()
+		if err := client.SendReconcileReport(report); err != nil {
+			logger.Warn("Failed to send reconcile report: %v", err)
+		}
+	}()
+
 	if err := sftp.Start(cfg.Node.SFTPPort); err != nil {
 		logger.Warn("SFTP server failed to start: %v", err)
 	}
@@ -134,7 +142,7 @@ func ensureBirdactylUser() {
 }
 
 func ensureDataDirectories(cfg *config.Config) error {
-	dirs := []string{cfg.Node.DataDir, cfg.Node.BackupDir}
+	dirs := []string{cfg.Node.DataDir, cfg.Node.BackupDir, cfg.Node.TrashDir}
 
 	isRoot := os.Geteuid() == 0
 
@@ -3,10 +3,12 @@ import { Modal, Button, Icons } from '../';
 
 const stageLabels: Record<TransferStatus['stage'], string> = {
   pending: 'Starting transfer...',
+  syncing: 'Copying files while running...',
   stopping: 'Stopping server...',
   archiving: 'Creating archive...',
   downloading: 'Transferring...',
   uploading: 'Transferring to target...',
+  finalizing: 'Copying final changes...',
   importing: 'Finalizing...',
   cleanup: 'Cleaning up...',
   complete: 'Transfer complete!',
@@ -59,7 +59,7 @@ export interface TransferStatus {
   from_node_name: string;
   to_node_id: string;
   to_node_name: string;
-  stage: 'pending' | 'stopping' | 'archiving' | 'downloading' | 'uploading' | 'importing' | 'cleanup' | 'complete' | 'failed';
+  stage: 'pending' | 'syncing' | 'stopping' | 'archiving' | 'downloading' | 'uploading' | 'finalizing' | 'importing' | 'cleanup' | 'complete' | 'failed';
   progress: number;
   error?: string;
   started_at: string;
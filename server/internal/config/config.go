@@ -18,6 +18,8 @@ type Config struct {
 	Resources  ResourcesConfig       `yaml:"resources"`
 	Logging    LoggingConfig         `yaml:"logging"`
 	Plugins    PluginsConfig         `yaml:"plugins"`
+	Scheduler  SchedulerConfig       `yaml:"scheduler"`
+	State      StateConfig           `yaml:"state"`
 	RootAdmins []string              `yaml:"root_admins"`
 	APIKeys    map[string]APIKeyConfig `yaml:"api_keys"`
 }
@@ -28,8 +30,10 @@ type APIKeyConfig struct {
 }
 
 type ServerConfig struct {
-	Host string `yaml:"host"`
-	Port int    `yaml:"port"`
+	Host             string `yaml:"host"`
+	Port             int    `yaml:"port"`
+	ShellIdleTimeout int    `yaml:"shell_idle_timeout"`
+	ShellRecordInput bool   `yaml:"shell_record_input"`
 }
 
 type LoggingConfig struct {
@@ -51,6 +55,30 @@ type PluginsConfig struct {
 	Container    ContainerConfig  `yaml:"container"`
 }
 
+type CatchUpPolicy string
+
+const (
+	CatchUpSkip CatchUpPolicy = "skip"
+	CatchUpOnce CatchUpPolicy = "once"
+)
+
+type SchedulerConfig struct {
+	CatchUp          CatchUpPolicy `yaml:"catch_up"`
+	CatchUpWindow    int           `yaml:"catch_up_window"`
+	NodeSyncInterval int           `yaml:"node_sync_interval"`
+}
+
+type StateBackend string
+
+const (
+	StateBackendMemory   StateBackend = "memory"
+	StateBackendDatabase StateBackend = "database"
+)
+
+type StateConfig struct {
+	Backend StateBackend `yaml:"backend"`
+}
+
 type ContainerConfig struct {
 	Enabled     bool   `yaml:"enabled"`
 	Image       string `yaml:"image"`
@@ -158,6 +186,8 @@ func generateDefaultConfig(path string) error {
 	defaultConfig := `server:
   host: "0.0.0.0"
   port: 3000
+  shell_idle_timeout: 10
+  shell_record_input: false
 
 logging:
   file: "logs/panel.log"
@@ -201,6 +231,14 @@ plugins:
     network_mode: "host"
     memory_limit: "512m"
     cpu_limit: "1.0"
+
+scheduler:
+  catch_up: "skip"
+  catch_up_window: 0
+  node_sync_interval: 15
+
+state:
+  backend: "memory"
 `
 
 	return os.WriteFile(path, []byte(defaultConfig), 0644)
@@ -213,6 +251,9 @@ func (c *Config) setDefaults() {
 	if c.Server.Port == 0 {
 		c.Server.Port = 3000
 	}
+	if c.Server.ShellIdleTimeout == 0 {
+		c.Server.ShellIdleTimeout = 10
+	}
 	if c.Database.Port == 0 {
 		c.Database.Port = 5432
 	}
@@ -275,6 +316,15 @@ func (c *Config) setDefaults() {
 	if c.Plugins.LoadMode == "" {
 		c.Plugins.LoadMode = PluginLoadManual
 	}
+	if c.Scheduler.CatchUp != CatchUpOnce {
+		c.Scheduler.CatchUp = CatchUpSkip
+	}
+	if c.Scheduler.NodeSyncInterval <= 0 {
+		c.Scheduler.NodeSyncInterval = 15
+	}
+	if c.State.Backend == "" {
+		c.State.Backend = StateBackendMemory
+	}
 }
 
 func (c *Config) loadEnvOverrides() {
@@ -30,6 +30,11 @@ func ILikeValue(value string) string {
 	return "%" + strings.ReplaceAll(strings.ReplaceAll(value, "%", "\\%"), "_", "\\_") + "%"
 }
 
+func PostgresDSN(cfg *config.DatabaseConfig) string {
+	return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+		cfg.Host, cfg.Port, cfg.User, cfg.Password, cfg.Name, cfg.SSLMode)
+}
+
 func Connect(cfg *config.DatabaseConfig) error {
 	var err error
 	var dialector gorm.Dialector
@@ -37,9 +42,7 @@ func Connect(cfg *config.DatabaseConfig) error {
 
 	switch cfg.Driver {
 	case "postgres":
-		dsn := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
-			cfg.Host, cfg.Port, cfg.User, cfg.Password, cfg.Name, cfg.SSLMode)
-		dialector = postgres.Open(dsn)
+		dialector = postgres.Open(PostgresDSN(cfg))
 	case "mysql":
 		dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local",
 			cfg.User, cfg.Password, cfg.Host, cfg.Port, cfg.Name)
@@ -73,15 +76,21 @@ func Connect(cfg *config.DatabaseConfig) error {
 		&models.IPRegistration{},
 		&models.Node{},
 		&models.Package{},
+		&models.PackageRevision{},
 		&models.Server{},
 		&models.ActivityLog{},
+		&models.ActivityAttachment{},
 		&models.IPBan{},
 		&models.Setting{},
 		&models.Subuser{},
 		&models.DatabaseHost{},
 		&models.ServerDatabase{},
 		&models.Schedule{},
+		&models.ScheduleRun{},
 		&models.APIKey{},
+		&models.StateEntry{},
+		&models.Transfer{},
+		&models.Allocation{},
 	); err != nil {
 		return err
 	}
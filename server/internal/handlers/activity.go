@@ -21,14 +21,17 @@ const (
 	ActionProfileSessionRevoke  = "profile.session_revoke"
 	ActionProfileSessionsRevoke = "profile.sessions_revoke_all"
 
-	ActionServerCreate    = "server.create"
-	ActionServerDelete    = "server.delete"
-	ActionServerStart     = "server.start"
-	ActionServerStop      = "server.stop"
-	ActionServerKill      = "server.kill"
-	ActionServerRestart   = "server.restart"
-	ActionServerReinstall = "server.reinstall"
-	ActionServerCommand   = "server.command"
+	ActionServerCreate       = "server.create"
+	ActionServerClone        = "server.clone"
+	ActionServerDelete       = "server.delete"
+	ActionServerStart        = "server.start"
+	ActionServerStop         = "server.stop"
+	ActionServerKill         = "server.kill"
+	ActionServerRestart      = "server.restart"
+	ActionServerReinstall    = "server.reinstall"
+	ActionServerInstallRetry = "server.install.retry"
+	ActionServerCommand      = "server.command"
+	ActionServerShell        = "server.shell"
 
 	ActionServerNameUpdate      = "server.name.update"
 	ActionServerResourcesUpdate = "server.resources.update"
@@ -48,6 +51,8 @@ const (
 	ActionFileBulkDelete    = "server.file.bulk_delete"
 	ActionFileBulkCopy      = "server.file.bulk_copy"
 	ActionFileBulkCompress  = "server.file.bulk_compress"
+	ActionFileTrashRestore  = "server.file.trash_restore"
+	ActionFileTrashPurge    = "server.file.trash_purge"
 
 	ActionBackupCreate  = "server.backup.create"
 	ActionBackupDelete  = "server.backup.delete"
@@ -74,14 +79,21 @@ const (
 	ActionAdminServerResources = "admin.server.resources"
 	ActionAdminServerTransfer  = "admin.server.transfer"
 
-	ActionAdminNodeCreate     = "admin.node.create"
-	ActionAdminNodeUpdate     = "admin.node.update"
-	ActionAdminNodeDelete     = "admin.node.delete"
-	ActionAdminNodeResetToken = "admin.node.reset_token"
+	ActionAdminNodeCreate       = "admin.node.create"
+	ActionAdminNodeUpdate       = "admin.node.update"
+	ActionAdminNodeDelete       = "admin.node.delete"
+	ActionAdminNodeResetToken   = "admin.node.reset_token"
+	ActionAdminNodeDriftResolve = "admin.node.drift_resolve"
 
-	ActionAdminPackageCreate = "admin.package.create"
-	ActionAdminPackageUpdate = "admin.package.update"
-	ActionAdminPackageDelete = "admin.package.delete"
+	ActionAdminAllocationCreate = "admin.allocation.create"
+	ActionAdminAllocationUpdate = "admin.allocation.update"
+	ActionAdminAllocationDelete = "admin.allocation.delete"
+
+	ActionAdminPackageCreate  = "admin.package.create"
+	ActionAdminPackageUpdate  = "admin.package.update"
+	ActionAdminPackageDelete  = "admin.package.delete"
+	ActionAdminPackageImport  = "admin.package.import"
+	ActionAdminPackageRollout = "admin.package.rollout"
 
 	ActionAdminIPBanCreate = "admin.ipban.create"
 	ActionAdminIPBanDelete = "admin.ipban.delete"
@@ -110,7 +122,7 @@ const (
 	ActionSFTPPasswordReset = "server.sftp.password_reset"
 )
 
-func LogActivity(userID uuid.UUID, username, action, description, ip, userAgent string, isAdmin bool, metadata map[string]interface{}) {
+func LogActivity(userID uuid.UUID, username, action, description, ip, userAgent string, isAdmin bool, metadata map[string]interface{}) uuid.UUID {
 	var metaStr string
 	if metadata != nil {
 		if b, err := json.Marshal(metadata); err == nil {
@@ -129,6 +141,25 @@ func LogActivity(userID uuid.UUID, username, action, description, ip, userAgent
 		Metadata:    metaStr,
 	}
 	database.DB.Create(&log)
+	return log.ID
+}
+
+func AttachToActivity(logID uuid.UUID, name, contentType string, data []byte) error {
+	return database.DB.Create(&models.ActivityAttachment{
+		ActivityLogID: logID,
+		Name:          name,
+		ContentType:   contentType,
+		Size:          int64(len(data)),
+		Data:          data,
+	}).Error
+}
+
+func UpdateActivityMetadata(logID uuid.UUID, metadata map[string]interface{}) error {
+	b, err := json.Marshal(metadata)
+	if err != nil {
+		return err
+	}
+	return database.DB.Model(&models.ActivityLog{}).Where("id = ?", logID).Update("metadata", string(b)).Error
 }
 
 func Log(c *fiber.Ctx, user *models.User, action, description string, metadata map[string]interface{}) {
@@ -1,6 +1,7 @@
 package admin
 
 import (
+	"fmt"
 	"math"
 	"strconv"
 	"time"
@@ -10,6 +11,7 @@ import (
 	"birdactyl-panel-backend/internal/plugins"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
 )
 
 type PaginatedLogs struct {
@@ -82,4 +84,31 @@ func AdminGetLogs(c *fiber.Ctx) error {
 			TotalPages: totalPages,
 		},
 	})
-}
\ No newline at end of file
+}
+
+func AdminGetLogAttachments(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid log ID"})
+	}
+
+	var attachments []models.ActivityAttachment
+	database.DB.Omit("data").Where("activity_log_id = ?", id).Order("created_at ASC").Find(&attachments)
+	return c.JSON(fiber.Map{"success": true, "data": attachments})
+}
+
+func AdminDownloadLogAttachment(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("attachmentId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid attachment ID"})
+	}
+
+	var attachment models.ActivityAttachment
+	if err := database.DB.First(&attachment, "id = ?", id).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"success": false, "error": "Attachment not found"})
+	}
+
+	c.Set("Content-Type", attachment.ContentType)
+	c.Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", attachment.Name))
+	return c.Send(attachment.Data)
+}
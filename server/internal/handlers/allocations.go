@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"strconv"
+
+	"birdactyl-panel-backend/internal/models"
+	"birdactyl-panel-backend/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type AllocationRangeRequest struct {
+	IP    string `json:"ip"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+	Alias string `json:"alias"`
+}
+
+func AdminGetNodeAllocations(c *fiber.Ctx) error {
+	nodeID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid node ID"})
+	}
+
+	allocations, err := services.GetNodeAllocations(nodeID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"success": true, "data": allocations})
+}
+
+func AdminCreateNodeAllocations(c *fiber.Ctx) error {
+	nodeID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid node ID"})
+	}
+
+	var req AllocationRangeRequest
+	if err := c.BodyParser(&req); err != nil || req.Start == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "start is required"})
+	}
+
+	created, err := services.CreateAllocations(nodeID, req.IP, req.Start, req.End, req.Alias)
+	if err != nil {
+		status := fiber.StatusBadRequest
+		if err == services.ErrNodeNotFound {
+			status = fiber.StatusNotFound
+		}
+		return c.Status(status).JSON(fiber.Map{"success": false, "error": err.Error()})
+	}
+
+	admin := c.Locals("user").(*models.User)
+	LogActivity(admin.ID, admin.Username, ActionAdminAllocationCreate, "Added "+strconv.Itoa(created)+" allocations", c.IP(), c.Get("User-Agent"), true, map[string]interface{}{"node_id": nodeID.String(), "ip": req.IP, "start": req.Start, "end": req.End})
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"success": true, "data": fiber.Map{"created": created}})
+}
+
+func AdminUpdateNodeAllocation(c *fiber.Ctx) error {
+	nodeID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid node ID"})
+	}
+	allocationID, err := uuid.Parse(c.Params("allocationId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid allocation ID"})
+	}
+
+	var req struct {
+		Alias string `json:"alias"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid request body"})
+	}
+
+	allocation, err := services.UpdateAllocationAlias(nodeID, allocationID, req.Alias)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"success": false, "error": err.Error()})
+	}
+
+	admin := c.Locals("user").(*models.User)
+	LogActivity(admin.ID, admin.Username, ActionAdminAllocationUpdate, "Updated allocation "+allocation.IP+":"+strconv.Itoa(allocation.Port), c.IP(), c.Get("User-Agent"), true, map[string]interface{}{"node_id": nodeID.String(), "allocation_id": allocationID.String()})
+
+	return c.JSON(fiber.Map{"success": true, "data": allocation})
+}
+
+func AdminDeleteNodeAllocation(c *fiber.Ctx) error {
+	nodeID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid node ID"})
+	}
+	allocationID, err := uuid.Parse(c.Params("allocationId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid allocation ID"})
+	}
+
+	if err := services.DeleteNodeAllocation(nodeID, allocationID); err != nil {
+		status := fiber.StatusNotFound
+		if err == services.ErrAllocationAssigned {
+			status = fiber.StatusConflict
+		}
+		return c.Status(status).JSON(fiber.Map{"success": false, "error": err.Error()})
+	}
+
+	admin := c.Locals("user").(*models.User)
+	LogActivity(admin.ID, admin.Username, ActionAdminAllocationDelete, "Deleted allocation", c.IP(), c.Get("User-Agent"), true, map[string]interface{}{"node_id": nodeID.String(), "allocation_id": allocationID.String()})
+
+	return c.JSON(fiber.Map{"success": true})
+}
+
+func AdminDeleteNodeAllocationRange(c *fiber.Ctx) error {
+	nodeID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid node ID"})
+	}
+
+	var req AllocationRangeRequest
+	if err := c.BodyParser(&req); err != nil || req.Start == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "start is required"})
+	}
+
+	deleted, err := services.DeleteAllocationRange(nodeID, req.IP, req.Start, req.End)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": err.Error()})
+	}
+
+	admin := c.Locals("user").(*models.User)
+	LogActivity(admin.ID, admin.Username, ActionAdminAllocationDelete, "Deleted "+strconv.Itoa(deleted)+" allocations", c.IP(), c.Get("User-Agent"), true, map[string]interface{}{"node_id": nodeID.String(), "ip": req.IP, "start": req.Start, "end": req.End})
+
+	return c.JSON(fiber.Map{"success": true, "data": fiber.Map{"deleted": deleted}})
+}
@@ -1,6 +1,10 @@
 package handlers
 
 import (
+	"encoding/json"
+	"strconv"
+	"time"
+
 	"birdactyl-panel-backend/internal/models"
 	"birdactyl-panel-backend/internal/plugins"
 	"birdactyl-panel-backend/internal/services"
@@ -285,6 +289,29 @@ func NodeHeartbeat(c *fiber.Ctx) error {
 	})
 }
 
+func NodeReconcileReport(c *fiber.Ctx) error {
+	node := c.Locals("node").(*models.Node)
+
+	body := c.Body()
+	if !json.Valid(body) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Invalid request body",
+		})
+	}
+
+	if err := services.RecordNodeReconcile(node.ID, body); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+	})
+}
+
 func GetAvailableNodes(c *fiber.Ctx) error {
 	nodes, err := services.GetOnlineNodes()
 	if err != nil {
@@ -365,3 +392,217 @@ func AdminPairNode(c *fiber.Ctx) error {
 		},
 	})
 }
+
+func NodeInstallFinished(c *fiber.Ctx) error {
+	node := c.Locals("node").(*models.Node)
+
+	serverID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Invalid server ID",
+		})
+	}
+
+	var req struct {
+		Success    bool   `json:"success"`
+		ExitCode   int    `json:"exit_code"`
+		DurationMs int64  `json:"duration_ms"`
+		Error      string `json:"error"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Invalid request body",
+		})
+	}
+
+	if err := services.FinishServerInstall(node.ID, serverID, req.Success); err != nil {
+		status := fiber.StatusInternalServerError
+		if err == services.ErrServerNotFound {
+			status = fiber.StatusNotFound
+		}
+		return c.Status(status).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	plugins.Emit(plugins.EventServerInstalled, map[string]string{
+		"server_id": serverID.String(),
+		"success":   strconv.FormatBool(req.Success),
+		"exit_code": strconv.Itoa(req.ExitCode),
+	})
+
+	return c.JSON(fiber.Map{
+		"success": true,
+	})
+}
+
+func AdminGetNodeDrift(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Invalid node ID",
+		})
+	}
+
+	node, err := services.GetNodeByID(id)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"drift":          node.DriftReport,
+			"sync_result":    node.SyncResult,
+			"last_sync":      node.LastSync,
+			"reconcile":      node.ReconcileReport,
+			"last_reconcile": node.LastReconcile,
+		},
+	})
+}
+
+func AdminSyncNode(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Invalid node ID",
+		})
+	}
+
+	node, err := services.GetNodeByID(id)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	report, err := services.SyncNode(node)
+	if err != nil {
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    report,
+	})
+}
+
+func AdminResolveNodeDrift(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Invalid node ID",
+		})
+	}
+
+	var req struct {
+		RemoveOrphans []string `json:"remove_orphans"`
+		Recreate      []string `json:"recreate"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Invalid request body",
+		})
+	}
+	if len(req.RemoveOrphans) == 0 && len(req.Recreate) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Nothing to resolve",
+		})
+	}
+
+	node, err := services.GetNodeByID(id)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	result, err := services.ResolveNodeDrift(node, req.RemoveOrphans, req.Recreate)
+	if err != nil {
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	admin := c.Locals("user").(*models.User)
+	LogActivity(admin.ID, admin.Username, ActionAdminNodeDriftResolve, "Resolved node drift", c.IP(), c.Get("User-Agent"), true, map[string]interface{}{"node_id": id.String(), "remove_orphans": req.RemoveOrphans, "recreate": req.Recreate})
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    result,
+	})
+}
+
+func NodeServerState(c *fiber.Ctx) error {
+	node := c.Locals("node").(*models.Node)
+
+	serverID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Invalid server ID",
+		})
+	}
+
+	var req struct {
+		From      string `json:"from"`
+		State     string `json:"state"`
+		Operation string `json:"operation"`
+		Error     string `json:"error"`
+		At        int64  `json:"at"`
+	}
+	if err := c.BodyParser(&req); err != nil || req.State == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Invalid request body",
+		})
+	}
+
+	at := time.UnixMilli(req.At)
+	if req.At == 0 {
+		at = time.Now()
+	}
+
+	applied, err := services.RecordServerState(node.ID, serverID, req.State, at)
+	if err != nil {
+		status := fiber.StatusInternalServerError
+		if err == services.ErrServerNotFound {
+			status = fiber.StatusNotFound
+		}
+		return c.Status(status).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	if applied {
+		plugins.Emit(plugins.EventServerStateChanged, map[string]string{
+			"server_id": serverID.String(),
+			"from":      req.From,
+			"state":     req.State,
+			"operation": req.Operation,
+			"error":     req.Error,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+	})
+}
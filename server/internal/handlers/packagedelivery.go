@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"encoding/json"
+
 	"birdactyl-panel-backend/internal/models"
 	"birdactyl-panel-backend/internal/plugins"
 	"birdactyl-panel-backend/internal/services"
@@ -11,24 +13,29 @@ import (
 )
 
 type CreatePackageRequest struct {
-	Name                string                     `json:"name"`
-	Version             string                     `json:"version"`
-	Author              string                     `json:"author"`
-	Description         string                     `json:"description"`
-	Icon                string                     `json:"icon"`
-	DockerImage         string                     `json:"docker_image"`
-	InstallImage        string                     `json:"install_image"`
-	Startup             string                     `json:"startup"`
-	InstallScript       string                     `json:"install_script"`
-	StopSignal          string                     `json:"stop_signal"`
-	StopCommand         string                     `json:"stop_command"`
-	StopTimeout         int                        `json:"stop_timeout"`
-	StartupEditable     bool                       `json:"startup_editable"`
-	DockerImageEditable bool                       `json:"docker_image_editable"`
-	Ports               []models.PackagePort       `json:"ports"`
-	Variables           []models.PackageVariable   `json:"variables"`
-	ConfigFiles         []models.PackageConfigFile `json:"config_files"`
-	AddonSources        []models.AddonSource       `json:"addon_sources"`
+	Name                string                      `json:"name"`
+	Version             string                      `json:"version"`
+	Author              string                      `json:"author"`
+	Description         string                      `json:"description"`
+	Icon                string                      `json:"icon"`
+	DockerImage         string                      `json:"docker_image"`
+	InstallImage        string                      `json:"install_image"`
+	Startup             string                      `json:"startup"`
+	InstallScript       string                      `json:"install_script"`
+	StopSignal          string                      `json:"stop_signal"`
+	StopCommand         string                      `json:"stop_command"`
+	StopTimeout         int                         `json:"stop_timeout"`
+	StartupEditable     bool                        `json:"startup_editable"`
+	DockerImageEditable bool                        `json:"docker_image_editable"`
+	AllowShell          bool                        `json:"allow_shell"`
+	StartupDone         []string                    `json:"startup_done"`
+	StartupProbe        bool                        `json:"startup_probe"`
+	StartupTimeout      int                         `json:"startup_timeout"`
+	DockerImages        []models.PackageDockerImage `json:"docker_images"`
+	Ports               []models.PackagePort        `json:"ports"`
+	Variables           []models.PackageVariable    `json:"variables"`
+	ConfigFiles         []models.PackageConfigFile  `json:"config_files"`
+	AddonSources        []models.AddonSource        `json:"addon_sources"`
 }
 
 func AdminGetPackages(c *fiber.Ctx) error {
@@ -69,12 +76,45 @@ func AdminCreatePackage(c *fiber.Ctx) error {
 		})
 	}
 
+	if err := services.ValidatePackageVariables(req.Variables); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	if err := services.ValidateConfigFiles(req.ConfigFiles); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	if err := services.ValidateStartupDone(req.StartupDone); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	if err := services.ValidateDockerImages(req.DockerImages); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
 	if req.StopSignal == "" {
 		req.StopSignal = "SIGTERM"
 	}
 	if req.StopTimeout == 0 {
 		req.StopTimeout = 30
 	}
+	if req.StartupTimeout == 0 {
+		req.StartupTimeout = 300
+	}
+
+	admin := c.Locals("user").(*models.User)
 
 	mixinInput := map[string]interface{}{
 		"name":         req.Name,
@@ -83,8 +123,10 @@ func AdminCreatePackage(c *fiber.Ctx) error {
 
 	portsJSON, _ := datatypes.NewJSONType(req.Ports).MarshalJSON()
 	varsJSON, _ := datatypes.NewJSONType(req.Variables).MarshalJSON()
+	imagesJSON, _ := datatypes.NewJSONType(req.DockerImages).MarshalJSON()
 	configJSON, _ := datatypes.NewJSONType(req.ConfigFiles).MarshalJSON()
 	addonJSON, _ := datatypes.NewJSONType(req.AddonSources).MarshalJSON()
+	doneJSON, _ := datatypes.NewJSONType(req.StartupDone).MarshalJSON()
 
 	pkg := &models.Package{
 		Name:                req.Name,
@@ -101,6 +143,11 @@ func AdminCreatePackage(c *fiber.Ctx) error {
 		StopTimeout:         req.StopTimeout,
 		StartupEditable:     req.StartupEditable,
 		DockerImageEditable: req.DockerImageEditable,
+		AllowShell:          req.AllowShell,
+		StartupDone:         doneJSON,
+		StartupProbe:        req.StartupProbe,
+		StartupTimeout:      req.StartupTimeout,
+		DockerImages:        imagesJSON,
 		Ports:               portsJSON,
 		Variables:           varsJSON,
 		ConfigFiles:         configJSON,
@@ -108,7 +155,7 @@ func AdminCreatePackage(c *fiber.Ctx) error {
 	}
 
 	_, err := plugins.ExecuteMixin(string(plugins.MixinPackageCreate), mixinInput, func(input map[string]interface{}) (interface{}, error) {
-		return pkg, services.CreatePackage(pkg)
+		return pkg, services.CreatePackage(pkg, &admin.ID)
 	})
 
 	if err != nil {
@@ -125,7 +172,6 @@ func AdminCreatePackage(c *fiber.Ctx) error {
 		})
 	}
 
-	admin := c.Locals("user").(*models.User)
 	LogActivity(admin.ID, admin.Username, ActionAdminPackageCreate, "Created package: "+req.Name, c.IP(), c.Get("User-Agent"), true, map[string]interface{}{"package_name": req.Name})
 
 	plugins.Emit(plugins.EventPackageCreated, map[string]string{"package_id": pkg.ID.String(), "name": pkg.Name})
@@ -183,10 +229,40 @@ func AdminUpdatePackage(c *fiber.Ctx) error {
 		})
 	}
 
+	if err := services.ValidatePackageVariables(req.Variables); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	if err := services.ValidateConfigFiles(req.ConfigFiles); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	if err := services.ValidateStartupDone(req.StartupDone); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	if err := services.ValidateDockerImages(req.DockerImages); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
 	portsJSON, _ := datatypes.NewJSONType(req.Ports).MarshalJSON()
 	varsJSON, _ := datatypes.NewJSONType(req.Variables).MarshalJSON()
+	imagesJSON, _ := datatypes.NewJSONType(req.DockerImages).MarshalJSON()
 	configJSON, _ := datatypes.NewJSONType(req.ConfigFiles).MarshalJSON()
 	addonJSON, _ := datatypes.NewJSONType(req.AddonSources).MarshalJSON()
+	doneJSON, _ := datatypes.NewJSONType(req.StartupDone).MarshalJSON()
 
 	updates := map[string]interface{}{
 		"name":                  req.Name,
@@ -203,12 +279,19 @@ func AdminUpdatePackage(c *fiber.Ctx) error {
 		"stop_timeout":          req.StopTimeout,
 		"startup_editable":      req.StartupEditable,
 		"docker_image_editable": req.DockerImageEditable,
+		"allow_shell":           req.AllowShell,
+		"startup_done":          doneJSON,
+		"startup_probe":         req.StartupProbe,
+		"startup_timeout":       req.StartupTimeout,
+		"docker_images":         imagesJSON,
 		"ports":                 portsJSON,
 		"variables":             varsJSON,
 		"config_files":          configJSON,
 		"addon_sources":         addonJSON,
 	}
 
+	admin := c.Locals("user").(*models.User)
+
 	mixinInput := map[string]interface{}{
 		"package_id": id.String(),
 		"name":       req.Name,
@@ -217,7 +300,7 @@ func AdminUpdatePackage(c *fiber.Ctx) error {
 	var pkg *models.Package
 	_, err = plugins.ExecuteMixin(string(plugins.MixinPackageUpdate), mixinInput, func(input map[string]interface{}) (interface{}, error) {
 		var updateErr error
-		pkg, updateErr = services.UpdatePackage(id, updates)
+		pkg, updateErr = services.UpdatePackage(id, updates, &admin.ID)
 		return pkg, updateErr
 	})
 
@@ -231,7 +314,6 @@ func AdminUpdatePackage(c *fiber.Ctx) error {
 		})
 	}
 
-	admin := c.Locals("user").(*models.User)
 	LogActivity(admin.ID, admin.Username, ActionAdminPackageUpdate, "Updated package: "+req.Name, c.IP(), c.Get("User-Agent"), true, map[string]interface{}{"package_id": id})
 
 	plugins.Emit(plugins.EventPackageUpdated, map[string]string{"package_id": id.String(), "name": req.Name})
@@ -288,8 +370,282 @@ func GetAvailablePackages(c *fiber.Ctx) error {
 			"error":   err.Error(),
 		})
 	}
+	type availablePackage struct {
+		models.Package
+		Variables []services.VariableSchema `json:"variables"`
+	}
+
+	result := make([]availablePackage, len(packages))
+	for i := range packages {
+		result[i] = availablePackage{Package: packages[i], Variables: services.GetVariableSchemas(&packages[i])}
+	}
+
 	return c.JSON(fiber.Map{
 		"success": true,
-		"data":    packages,
+		"data":    result,
+	})
+}
+
+func AdminImportEgg(c *fiber.Ctx) error {
+	pkg, warnings, err := services.ImportEgg(c.Body())
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	admin := c.Locals("user").(*models.User)
+
+	mixinInput := map[string]interface{}{
+		"name":         pkg.Name,
+		"docker_image": pkg.DockerImage,
+	}
+
+	_, err = plugins.ExecuteMixin(string(plugins.MixinPackageCreate), mixinInput, func(input map[string]interface{}) (interface{}, error) {
+		return pkg, services.CreatePackage(pkg, &admin.ID)
+	})
+
+	if err != nil {
+		if mixinErr, ok := err.(*plugins.MixinError); ok {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"success": false, "error": mixinErr.Message})
+		}
+		status := fiber.StatusInternalServerError
+		if err == services.ErrPackageNameTaken {
+			status = fiber.StatusConflict
+		}
+		return c.Status(status).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	LogActivity(admin.ID, admin.Username, ActionAdminPackageImport, "Imported package from egg: "+pkg.Name, c.IP(), c.Get("User-Agent"), true, map[string]interface{}{"package_id": pkg.ID, "warnings": len(warnings)})
+
+	plugins.Emit(plugins.EventPackageCreated, map[string]string{"package_id": pkg.ID.String(), "name": pkg.Name})
+
+	if warnings == nil {
+		warnings = []string{}
+	}
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success":  true,
+		"data":     pkg,
+		"warnings": warnings,
+	})
+}
+
+func AdminExportPackage(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Invalid package ID",
+		})
+	}
+
+	pkg, err := services.GetPackageByID(id)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	switch c.Query("format", "native") {
+	case "native":
+		return c.JSON(fiber.Map{
+			"success":  true,
+			"data":     packageToRequest(pkg),
+			"warnings": []string{},
+		})
+	case "egg":
+		egg, warnings := services.ExportEgg(pkg)
+		if warnings == nil {
+			warnings = []string{}
+		}
+		return c.JSON(fiber.Map{
+			"success":  true,
+			"data":     egg,
+			"warnings": warnings,
+		})
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "format must be native or egg",
+		})
+	}
+}
+
+func packageToRequest(pkg *models.Package) CreatePackageRequest {
+	req := CreatePackageRequest{
+		Name:                pkg.Name,
+		Version:             pkg.Version,
+		Author:              pkg.Author,
+		Description:         pkg.Description,
+		Icon:                pkg.Icon,
+		DockerImage:         pkg.DockerImage,
+		InstallImage:        pkg.InstallImage,
+		Startup:             pkg.Startup,
+		InstallScript:       pkg.InstallScript,
+		StopSignal:          pkg.StopSignal,
+		StopCommand:         pkg.StopCommand,
+		StopTimeout:         pkg.StopTimeout,
+		StartupEditable:     pkg.StartupEditable,
+		DockerImageEditable: pkg.DockerImageEditable,
+		AllowShell:          pkg.AllowShell,
+		StartupProbe:        pkg.StartupProbe,
+		StartupTimeout:      pkg.StartupTimeout,
+	}
+	json.Unmarshal(pkg.DockerImages, &req.DockerImages)
+	json.Unmarshal(pkg.Ports, &req.Ports)
+	json.Unmarshal(pkg.Variables, &req.Variables)
+	json.Unmarshal(pkg.ConfigFiles, &req.ConfigFiles)
+	json.Unmarshal(pkg.AddonSources, &req.AddonSources)
+	json.Unmarshal(pkg.StartupDone, &req.StartupDone)
+	return req
+}
+
+func AdminListPackageRevisions(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Invalid package ID",
+		})
+	}
+
+	revisions, err := services.ListPackageRevisions(id)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    revisions,
+	})
+}
+
+func AdminDiffPackageRevisions(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Invalid package ID",
+		})
+	}
+
+	from, to := c.QueryInt("from"), c.QueryInt("to")
+	if from <= 0 || to <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "from and to revisions are required",
+		})
+	}
+
+	changes, err := services.DiffPackageRevisions(id, from, to)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    changes,
+	})
+}
+
+func AdminGetPackageRollout(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Invalid package ID",
+		})
+	}
+
+	latest, behind, err := services.GetRolloutStatus(id)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"revision": latest,
+			"behind":   behind,
+		},
+	})
+}
+
+func AdminRolloutPackage(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Invalid package ID",
+		})
+	}
+
+	var req struct {
+		Revision  int         `json:"revision"`
+		ServerIDs []uuid.UUID `json:"server_ids"`
+		Reinstall bool        `json:"reinstall"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Invalid request body",
+		})
+	}
+
+	servers, err := services.RolloutPackageRevision(id, req.Revision, req.ServerIDs)
+	if err != nil {
+		status := fiber.StatusInternalServerError
+		if err == services.ErrPackageNotFound || err == services.ErrRevisionNotFound {
+			status = fiber.StatusNotFound
+		}
+		return c.Status(status).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	updated := make([]fiber.Map, len(servers))
+	for i := range servers {
+		server := servers[i]
+		updated[i] = fiber.Map{"id": server.ID, "name": server.Name, "revision": server.PackageRevision}
+		if req.Reinstall {
+			go func() {
+				services.UpdateServerStatus(server.ID, models.ServerStatusInstalling, "")
+				if err := services.SendReinstallServer(&server); err != nil {
+					status := models.ServerStatusFailed
+					if services.IsNodeConflict(err) {
+						status = server.Status
+					}
+					services.UpdateServerStatus(server.ID, status, "")
+				}
+			}()
+		}
+	}
+
+	admin := c.Locals("user").(*models.User)
+	LogActivity(admin.ID, admin.Username, ActionAdminPackageRollout, "Rolled out package revision", c.IP(), c.Get("User-Agent"), true, map[string]interface{}{"package_id": id, "revision": req.Revision, "servers": len(servers), "reinstall": req.Reinstall})
+
+	_, behind, _ := services.GetRolloutStatus(id)
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"updated": updated,
+			"behind":  behind,
+		},
 	})
 }
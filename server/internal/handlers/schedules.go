@@ -3,6 +3,8 @@ package handlers
 import (
 	"encoding/json"
 	"errors"
+	"math"
+	"strconv"
 
 	"birdactyl-panel-backend/internal/database"
 	"birdactyl-panel-backend/internal/models"
@@ -14,6 +16,14 @@ import (
 
 var errScheduleHandled = errors.New("handled")
 
+type PaginatedScheduleRuns struct {
+	Runs       []models.ScheduleRun `json:"runs"`
+	Page       int                  `json:"page"`
+	PerPage    int                  `json:"per_page"`
+	Total      int64                `json:"total"`
+	TotalPages int                  `json:"total_pages"`
+}
+
 func checkSchedulePerm(c *fiber.Ctx, perm string) (uuid.UUID, error) {
 	user := c.Locals("user").(*models.User)
 	serverID, err := uuid.Parse(c.Params("id"))
@@ -40,6 +50,17 @@ func checkSchedulePerm(c *fiber.Ctx, perm string) (uuid.UUID, error) {
 	return serverID, nil
 }
 
+// scheduleTaskPerms reports whether the requesting user holds a server
+// permission, so a schedule cannot run tasks its author couldn't run.
+func scheduleTaskPerms(c *fiber.Ctx, serverID uuid.UUID) func(string) bool {
+	user := c.Locals("user").(*models.User)
+	var server models.Server
+	database.DB.Select("user_id").Where("id = ?", serverID).First(&server)
+	return func(perm string) bool {
+		return user.IsAdmin || server.UserID == user.ID || services.HasServerPermission(user.ID, serverID, false, perm)
+	}
+}
+
 func GetServerSchedules(c *fiber.Ctx) error {
 	serverID, err := checkSchedulePerm(c, models.PermScheduleList)
 	if err != nil {
@@ -100,6 +121,13 @@ func CreateSchedule(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Name and cron_expression are required"})
 	}
 
+	if err := services.ValidateCronExpression(req.CronExpression); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": err.Error()})
+	}
+	if err := services.ValidateScheduleTasks(req.Tasks, scheduleTaskPerms(c, serverID)); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": err.Error()})
+	}
+
 	tasksJSON, _ := json.Marshal(req.Tasks)
 
 	schedule := &models.Schedule{
@@ -143,6 +171,13 @@ func UpdateSchedule(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid request body"})
 	}
 
+	if err := services.ValidateCronExpression(req.CronExpression); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": err.Error()})
+	}
+	if err := services.ValidateScheduleTasks(req.Tasks, scheduleTaskPerms(c, serverID)); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": err.Error()})
+	}
+
 	tasksJSON, _ := json.Marshal(req.Tasks)
 
 	updates := map[string]interface{}{
@@ -208,9 +243,53 @@ func RunScheduleNow(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"success": false, "error": "Access denied"})
 	}
 
-	if err := services.RunScheduleNow(scheduleID); err != nil {
+	run, err := services.RunScheduleNow(scheduleID, models.ScheduleTriggerManual)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"success": true, "message": "Schedule execution started", "data": run})
+}
+
+func GetScheduleRuns(c *fiber.Ctx) error {
+	serverID, err := checkSchedulePerm(c, models.PermScheduleList)
+	if err != nil {
+		return nil
+	}
+
+	scheduleID, err := uuid.Parse(c.Params("scheduleId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid schedule ID"})
+	}
+
+	existing, err := services.GetScheduleByID(scheduleID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"success": false, "error": "Schedule not found"})
+	}
+
+	if existing.ServerID != serverID {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"success": false, "error": "Access denied"})
+	}
+
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	perPage, _ := strconv.Atoi(c.Query("per_page", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 || perPage > 100 {
+		perPage = 20
+	}
+
+	runs, total, err := services.GetScheduleRuns(scheduleID, perPage, (page-1)*perPage)
+	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": err.Error()})
 	}
 
-	return c.JSON(fiber.Map{"success": true, "message": "Schedule execution started"})
+	return c.JSON(fiber.Map{"success": true, "data": PaginatedScheduleRuns{
+		Runs:       runs,
+		Page:       page,
+		PerPage:    perPage,
+		Total:      total,
+		TotalPages: int(math.Ceil(float64(total) / float64(perPage))),
+	}})
 }
@@ -91,10 +91,14 @@ func AdminCreateServer(c *fiber.Ctx) error {
 		Memory:    req.Memory,
 		CPU:       req.CPU,
 		Disk:      req.Disk,
+		IsAdmin:   true,
 	}
 
 	server, err := services.CreateServer(ownerID, createReq)
 	if err != nil {
+		if varErr, ok := err.(*services.VariableValidationError); ok {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": varErr.Error(), "errors": varErr.Fields})
+		}
 		status := fiber.StatusInternalServerError
 		switch err {
 		case services.ErrNodeNotFound, services.ErrPackageNotFound:
@@ -392,6 +396,7 @@ func AdminTransferServer(c *fiber.Ctx) error {
 
 	var req struct {
 		TargetNodeID string `json:"target_node_id"`
+		Mode         string `json:"mode"`
 	}
 	if err := c.BodyParser(&req); err != nil || req.TargetNodeID == "" {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "target_node_id required"})
@@ -409,10 +414,11 @@ func AdminTransferServer(c *fiber.Ctx) error {
 		"server_id":      serverID.String(),
 		"name":           server.Name,
 		"target_node_id": targetNodeID.String(),
+		"mode":           req.Mode,
 	}
 
 	result, err := plugins.ExecuteMixin(string(plugins.MixinServerTransfer), mixinInput, func(input map[string]interface{}) (interface{}, error) {
-		return services.StartTransfer(serverID, targetNodeID)
+		return services.StartTransfer(serverID, targetNodeID, req.Mode)
 	})
 
 	if err != nil {
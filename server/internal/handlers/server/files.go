@@ -148,10 +148,14 @@ func WriteFile(c *fiber.Ctx) error {
 	var body struct {
 		Path    string `json:"path"`
 		Content string `json:"content"`
+		IfMatch string `json:"if_match,omitempty"`
 	}
 	if err := c.BodyParser(&body); err != nil || body.Path == "" {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "path and content required"})
 	}
+	if ifMatch := c.Get(fiber.HeaderIfMatch); ifMatch != "" {
+		body.IfMatch = ifMatch
+	}
 	user := c.Locals("user").(*models.User)
 	if allow, msg := plugins.Emit(plugins.EventFileWriting, map[string]string{"server_id": server.ID.String(), "path": body.Path, "user_id": user.ID.String()}); !allow {
 		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"success": false, "error": msg})
@@ -472,3 +476,52 @@ func BulkCompressFiles(c *fiber.Ctx) error {
 	handlers.Log(c, user, handlers.ActionFileBulkCompress, "Bulk compressed files", map[string]interface{}{"server_id": server.ID, "count": len(body.Paths), "dest": body.Dest})
 	return proxyPost(c, server, "/files/bulk-compress", body)
 }
+
+func ListTrash(c *fiber.Ctx) error {
+	server, err := getServerWithFilePerm(c, models.PermFileDelete)
+	if err != nil {
+		return nil
+	}
+	resp, err := services.ProxyToNode(server, "GET", "/api/servers/"+server.ID.String()+"/trash", nil)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": err.Error()})
+	}
+	return c.Status(resp.StatusCode).Send(resp.Body)
+}
+
+func RestoreTrash(c *fiber.Ctx) error {
+	server, err := getServerWithFilePerm(c, models.PermFileDelete)
+	if err != nil {
+		return nil
+	}
+	var body struct{ IDs []string `json:"ids"` }
+	if err := c.BodyParser(&body); err != nil || len(body.IDs) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "ids required"})
+	}
+	user := c.Locals("user").(*models.User)
+	handlers.Log(c, user, handlers.ActionFileTrashRestore, "Restored files from trash", map[string]interface{}{"server_id": server.ID, "count": len(body.IDs)})
+	return proxyPost(c, server, "/trash/restore", body)
+}
+
+func PurgeTrash(c *fiber.Ctx) error {
+	server, err := getServerWithFilePerm(c, models.PermFileDelete)
+	if err != nil {
+		return nil
+	}
+	var body struct {
+		IDs []string `json:"ids"`
+		All bool     `json:"all"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid request body"})
+	}
+	if len(body.IDs) == 0 && !body.All {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "ids or all required"})
+	}
+	if body.All {
+		body.IDs = nil
+	}
+	user := c.Locals("user").(*models.User)
+	handlers.Log(c, user, handlers.ActionFileTrashPurge, "Purged trash", map[string]interface{}{"server_id": server.ID, "count": len(body.IDs), "all": body.All})
+	return proxyPost(c, server, "/trash/purge", body)
+}
@@ -0,0 +1,68 @@
+package server
+
+import (
+	"birdactyl-panel-backend/internal/handlers"
+	"birdactyl-panel-backend/internal/models"
+	"birdactyl-panel-backend/internal/plugins"
+	"birdactyl-panel-backend/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+func GetInstallLog(c *fiber.Ctx) error {
+	serverID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid server ID"})
+	}
+
+	server, err := checkServerPerm(c, serverID, models.PermReinstall)
+	if err != nil {
+		return nil
+	}
+
+	resp, err := services.ProxyToNode(server, "GET", "/api/servers/"+server.ID.String()+"/install", nil)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": err.Error()})
+	}
+	return c.Status(resp.StatusCode).Send(resp.Body)
+}
+
+func RetryInstall(c *fiber.Ctx) error {
+	user := c.Locals("user").(*models.User)
+	serverID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid server ID"})
+	}
+
+	server, err := checkServerPerm(c, serverID, models.PermReinstall)
+	if err != nil {
+		return nil
+	}
+
+	if server.IsSuspended {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"success": false, "error": "Server is suspended"})
+	}
+	if server.Status != models.ServerStatusFailed {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"success": false, "error": "Only failed installations can be retried"})
+	}
+
+	if allow, msg := plugins.Emit(plugins.EventServerReinstall, map[string]string{"server_id": serverID.String(), "name": server.Name, "user_id": user.ID.String()}); !allow {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"success": false, "error": msg})
+	}
+
+	services.UpdateServerStatus(serverID, models.ServerStatusInstalling, "")
+
+	go func() {
+		if err := services.SendReinstallServer(server); err != nil {
+			status := models.ServerStatusFailed
+			if services.IsNodeConflict(err) {
+				status = server.Status
+			}
+			services.UpdateServerStatus(server.ID, status, "")
+		}
+	}()
+
+	handlers.Log(c, user, handlers.ActionServerInstallRetry, "Retried installation: "+server.Name, map[string]interface{}{"server_id": serverID})
+	return c.JSON(fiber.Map{"success": true, "message": "Installation restarted"})
+}
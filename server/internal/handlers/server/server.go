@@ -84,6 +84,47 @@ func GetServer(c *fiber.Ctx) error {
 	return c.JSON(fiber.Map{"success": true, "data": server})
 }
 
+// resourceLimitError checks a new server of the given size against the
+// user's resource limits and returns why it does not fit, or "" when it
+// does. Admins are not limited.
+func resourceLimitError(user *models.User, memory, cpu, disk int) string {
+	cfg := config.Get()
+	if user.IsAdmin || !cfg.Resources.Enabled {
+		return ""
+	}
+	used := services.GetUserResourceUsage(user.ID)
+
+	ramLimit := cfg.Resources.DefaultRAM
+	cpuLimit := cfg.Resources.DefaultCPU
+	diskLimit := cfg.Resources.DefaultDisk
+	serverLimit := cfg.Resources.MaxServers
+
+	if user.RAMLimit != nil {
+		ramLimit = *user.RAMLimit
+	}
+	if user.CPULimit != nil {
+		cpuLimit = *user.CPULimit
+	}
+	if user.DiskLimit != nil {
+		diskLimit = *user.DiskLimit
+	}
+	if user.ServerLimit != nil {
+		serverLimit = *user.ServerLimit
+	}
+
+	switch {
+	case used.Servers >= serverLimit:
+		return "Maximum server limit reached"
+	case used.RAM+memory > ramLimit:
+		return "Not enough RAM available"
+	case used.CPU+cpu > cpuLimit:
+		return "Not enough CPU available"
+	case used.Disk+disk > diskLimit:
+		return "Not enough disk space available"
+	}
+	return ""
+}
+
 func CreateServer(c *fiber.Ctx) error {
 	user := c.Locals("user").(*models.User)
 
@@ -115,49 +156,12 @@ func CreateServer(c *fiber.Ctx) error {
 	if req.Disk < 256 {
 		req.Disk = 256
 	}
+	req.IsAdmin = user.IsAdmin
 
-	cfg := config.Get()
-	if !user.IsAdmin && cfg.Resources.Enabled {
-		used := services.GetUserResourceUsage(user.ID)
-
-		ramLimit := cfg.Resources.DefaultRAM
-		cpuLimit := cfg.Resources.DefaultCPU
-		diskLimit := cfg.Resources.DefaultDisk
-		serverLimit := cfg.Resources.MaxServers
-
-		if user.RAMLimit != nil {
-			ramLimit = *user.RAMLimit
-		}
-		if user.CPULimit != nil {
-			cpuLimit = *user.CPULimit
-		}
-		if user.DiskLimit != nil {
-			diskLimit = *user.DiskLimit
-		}
-		if user.ServerLimit != nil {
-			serverLimit = *user.ServerLimit
-		}
-
-		if used.Servers >= serverLimit {
-			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-				"success": false, "error": "Maximum server limit reached",
-			})
-		}
-		if used.RAM+req.Memory > ramLimit {
-			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-				"success": false, "error": "Not enough RAM available",
-			})
-		}
-		if used.CPU+req.CPU > cpuLimit {
-			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-				"success": false, "error": "Not enough CPU available",
-			})
-		}
-		if used.Disk+req.Disk > diskLimit {
-			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-				"success": false, "error": "Not enough disk space available",
-			})
-		}
+	if msg := resourceLimitError(user, req.Memory, req.CPU, req.Disk); msg != "" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"success": false, "error": msg,
+		})
 	}
 
 	if allow, msg := plugins.Emit(plugins.EventServerCreating, map[string]string{"user_id": user.ID.String(), "name": req.Name}); !allow {
@@ -198,12 +202,17 @@ func CreateServer(c *fiber.Ctx) error {
 		if mixinErr, ok := err.(*plugins.MixinError); ok {
 			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"success": false, "error": mixinErr.Message})
 		}
+		if varErr, ok := err.(*services.VariableValidationError); ok {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": varErr.Error(), "errors": varErr.Fields})
+		}
 		status := fiber.StatusInternalServerError
 		switch err {
 		case services.ErrNodeNotFound, services.ErrPackageNotFound:
 			status = fiber.StatusBadRequest
 		case services.ErrNodeOffline:
 			status = fiber.StatusServiceUnavailable
+		case services.ErrNoFreeAllocation:
+			status = fiber.StatusConflict
 		}
 		return c.Status(status).JSON(fiber.Map{"success": false, "error": err.Error()})
 	}
@@ -231,6 +240,91 @@ func CreateServer(c *fiber.Ctx) error {
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"success": true, "data": server})
 }
 
+// CloneServer copies a server with its configuration and files. Only the
+// owner and admins can clone, and the copy counts against the owner's
+// resource limits.
+func CloneServer(c *fiber.Ctx) error {
+	user := c.Locals("user").(*models.User)
+
+	serverID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false, "error": "Invalid server ID",
+		})
+	}
+
+	var source models.Server
+	if err := database.DB.Where("id = ?", serverID).First(&source).Error; err != nil || (!user.IsAdmin && source.UserID != user.ID) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false, "error": "Server not found",
+		})
+	}
+	if !user.IsAdmin && (source.IsSuspended || !services.IsServerCreationEnabled()) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"success": false, "error": "Server cannot be cloned",
+		})
+	}
+
+	var req struct {
+		Name   string    `json:"name"`
+		NodeID uuid.UUID `json:"node_id"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false, "error": "Invalid request body",
+		})
+	}
+	if req.NodeID == uuid.Nil {
+		req.NodeID = source.NodeID
+	}
+
+	// The clone belongs to the source's owner, so it counts against their
+	// limits even when an admin makes it.
+	owner := user
+	if source.UserID != user.ID {
+		owner = &models.User{}
+		if err := database.DB.Where("id = ?", source.UserID).First(owner).Error; err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"success": false, "error": "Server owner not found",
+			})
+		}
+	}
+	if msg := resourceLimitError(owner, source.Memory, source.CPU, source.Disk); msg != "" {
+		if owner != user {
+			msg = "Server owner: " + msg
+		}
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"success": false, "error": msg,
+		})
+	}
+
+	if allow, msg := plugins.Emit(plugins.EventServerCreating, map[string]string{"user_id": source.UserID.String(), "name": req.Name}); !allow {
+		if msg == "" {
+			msg = "Server creation blocked by plugin"
+		}
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"success": false, "error": msg})
+	}
+
+	server, err := services.CloneServer(&source, req.NodeID, req.Name)
+	if err != nil {
+		status := fiber.StatusInternalServerError
+		switch err {
+		case services.ErrNodeNotFound:
+			status = fiber.StatusBadRequest
+		case services.ErrNodeOffline:
+			status = fiber.StatusServiceUnavailable
+		case services.ErrCloneSourceBusy, services.ErrNoFreeAllocation:
+			status = fiber.StatusConflict
+		}
+		return c.Status(status).JSON(fiber.Map{"success": false, "error": err.Error()})
+	}
+
+	handlers.Log(c, user, handlers.ActionServerClone, "Cloned server "+source.Name+" to "+server.Name, map[string]interface{}{"server_id": server.ID, "server_name": server.Name, "source_id": source.ID})
+	plugins.Emit(plugins.EventServerCreated, map[string]string{"server_id": server.ID.String(), "name": server.Name, "user_id": server.UserID.String()})
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"success": true, "data": server})
+}
+
 func DeleteServer(c *fiber.Ctx) error {
 	user := c.Locals("user").(*models.User)
 
@@ -313,7 +407,7 @@ func StartServer(c *fiber.Ctx) error {
 		if mixinErr, ok := err.(*plugins.MixinError); ok {
 			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"success": false, "error": mixinErr.Message})
 		}
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": err.Error()})
+		return c.Status(powerErrorStatus(err)).JSON(fiber.Map{"success": false, "error": err.Error()})
 	}
 
 	handlers.Log(c, user, handlers.ActionServerStart, "Started server: "+server.Name, map[string]interface{}{"server_id": serverID})
@@ -352,7 +446,7 @@ func StopServer(c *fiber.Ctx) error {
 		if mixinErr, ok := err.(*plugins.MixinError); ok {
 			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"success": false, "error": mixinErr.Message})
 		}
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": err.Error()})
+		return c.Status(powerErrorStatus(err)).JSON(fiber.Map{"success": false, "error": err.Error()})
 	}
 
 	handlers.Log(c, user, handlers.ActionServerStop, "Stopped server: "+server.Name, map[string]interface{}{"server_id": serverID})
@@ -391,7 +485,7 @@ func KillServer(c *fiber.Ctx) error {
 		if mixinErr, ok := err.(*plugins.MixinError); ok {
 			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"success": false, "error": mixinErr.Message})
 		}
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": err.Error()})
+		return c.Status(powerErrorStatus(err)).JSON(fiber.Map{"success": false, "error": err.Error()})
 	}
 
 	handlers.Log(c, user, handlers.ActionServerKill, "Killed server: "+server.Name, map[string]interface{}{"server_id": serverID})
@@ -429,7 +523,7 @@ func RestartServer(c *fiber.Ctx) error {
 		if mixinErr, ok := err.(*plugins.MixinError); ok {
 			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"success": false, "error": mixinErr.Message})
 		}
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": err.Error()})
+		return c.Status(powerErrorStatus(err)).JSON(fiber.Map{"success": false, "error": err.Error()})
 	}
 
 	handlers.Log(c, user, handlers.ActionServerRestart, "Restarted server: "+server.Name, map[string]interface{}{"server_id": serverID})
@@ -459,18 +553,26 @@ func ReinstallServer(c *fiber.Ctx) error {
 	}
 
 	_, err = plugins.ExecuteMixin(string(plugins.MixinServerReinstall), mixinInput, func(input map[string]interface{}) (interface{}, error) {
-		return nil, services.SendReinstallServer(server)
+		services.UpdateServerStatus(serverID, models.ServerStatusInstalling, "")
+		if err := services.SendReinstallServer(server); err != nil {
+			status := models.ServerStatusFailed
+			if services.IsNodeConflict(err) {
+				status = server.Status
+			}
+			services.UpdateServerStatus(serverID, status, "")
+			return nil, err
+		}
+		return nil, nil
 	})
 
 	if err != nil {
 		if mixinErr, ok := err.(*plugins.MixinError); ok {
 			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"success": false, "error": mixinErr.Message})
 		}
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": err.Error()})
+		return c.Status(powerErrorStatus(err)).JSON(fiber.Map{"success": false, "error": err.Error()})
 	}
 
 	handlers.Log(c, user, handlers.ActionServerReinstall, "Reinstalled server: "+server.Name, map[string]interface{}{"server_id": serverID})
-	services.UpdateServerStatus(serverID, models.ServerStatusInstalling, "")
 	return c.JSON(fiber.Map{"success": true, "message": "Server reinstalling"})
 }
 
@@ -625,6 +727,9 @@ func UpdateServerVariables(c *fiber.Ctx) error {
 		if mixinErr, ok := err.(*plugins.MixinError); ok {
 			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"success": false, "error": mixinErr.Message})
 		}
+		if varErr, ok := err.(*services.VariableValidationError); ok {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": varErr.Error(), "errors": varErr.Fields})
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": err.Error()})
 	}
 
@@ -732,3 +837,12 @@ func GetConsoleLogs(c *fiber.Ctx) error {
 		},
 	})
 }
+
+// powerErrorStatus passes through a 409 from Axis when another power
+// operation is already running on the server.
+func powerErrorStatus(err error) int {
+	if services.IsNodeConflict(err) {
+		return fiber.StatusConflict
+	}
+	return fiber.StatusInternalServerError
+}
@@ -0,0 +1,254 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"birdactyl-panel-backend/internal/config"
+	"birdactyl-panel-backend/internal/database"
+	"birdactyl-panel-backend/internal/models"
+	"birdactyl-panel-backend/internal/services"
+
+	"github.com/gofiber/contrib/websocket"
+	"github.com/google/uuid"
+	gorilla "github.com/gorilla/websocket"
+)
+
+const shellRecordingLimit = 10 * 1024 * 1024
+
+// shellRecording collects a session in asciicast v2 format.
+type shellRecording struct {
+	mu        sync.Mutex
+	start     time.Time
+	buf       bytes.Buffer
+	truncated bool
+	exitCode  int
+}
+
+func newShellRecording(cols, rows int) *shellRecording {
+	r := &shellRecording{start: time.Now(), exitCode: -1}
+	header, _ := json.Marshal(map[string]interface{}{
+		"version":   2,
+		"width":     cols,
+		"height":    rows,
+		"timestamp": r.start.Unix(),
+		"env":       map[string]string{"TERM": "xterm-256color"},
+	})
+	r.buf.Write(header)
+	r.buf.WriteByte('\n')
+	return r
+}
+
+func (r *shellRecording) event(kind, data string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.truncated {
+		return
+	}
+	line, _ := json.Marshal([]interface{}{time.Since(r.start).Seconds(), kind, data})
+	if r.buf.Len()+len(line) > shellRecordingLimit {
+		r.truncated = true
+		return
+	}
+	r.buf.Write(line)
+	r.buf.WriteByte('\n')
+}
+
+func (r *shellRecording) setExitCode(code int) {
+	r.mu.Lock()
+	r.exitCode = code
+	r.mu.Unlock()
+}
+
+func (r *shellRecording) bytes() []byte {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]byte(nil), r.buf.Bytes()...)
+}
+
+func canOpenShell(user *models.User, server *models.Server) bool {
+	if user.IsAdmin {
+		return true
+	}
+	if server.IsSuspended || server.Package == nil || !server.Package.AllowShell {
+		return false
+	}
+	return server.UserID == user.ID || services.HasServerPermission(user.ID, server.ID, false, models.PermShell)
+}
+
+func queryInt(c *websocket.Conn, key string, def int) int {
+	if v, err := strconv.Atoi(c.Query(key)); err == nil && v > 0 {
+		return v
+	}
+	return def
+}
+
+// ServerShellWS proxies an interactive shell in the server's container. The
+// activity entry is written when the shell opens and completed when it
+// closes, with the session's output attached as a recording. Keystrokes are
+// only recorded when server.shell_record_input is set, since they can hold
+// passwords typed at prompts.
+func ServerShellWS(c *websocket.Conn) {
+	userID, _ := c.Locals("userID").(uuid.UUID)
+	serverID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		c.WriteJSON(map[string]string{"type": "error", "error": "Invalid server ID"})
+		return
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, "id = ?", userID).Error; err != nil {
+		c.WriteJSON(map[string]string{"type": "error", "error": "Unauthorized"})
+		return
+	}
+
+	var server models.Server
+	if err := database.DB.Preload("Node").Preload("Package").First(&server, "id = ?", serverID).Error; err != nil {
+		c.WriteJSON(map[string]string{"type": "error", "error": "Server not found"})
+		return
+	}
+	if !canOpenShell(&user, &server) {
+		c.WriteJSON(map[string]string{"type": "error", "error": "Permission denied"})
+		return
+	}
+	if server.Node == nil {
+		c.WriteJSON(map[string]string{"type": "error", "error": "Node not found"})
+		return
+	}
+
+	cols, rows := queryInt(c, "cols", 80), queryInt(c, "rows", 24)
+	idle := time.Duration(config.Get().Server.ShellIdleTimeout) * time.Minute
+
+	query := url.Values{}
+	query.Set("token", server.Node.DaemonToken)
+	query.Set("cols", strconv.Itoa(cols))
+	query.Set("rows", strconv.Itoa(rows))
+	query.Set("idle_timeout", strconv.Itoa(int(idle.Seconds())))
+	nodeURL := fmt.Sprintf("ws://%s:%d/api/servers/%s/shell?%s",
+		server.Node.FQDN, server.Node.Port, server.ID.String(), query.Encode())
+
+	dialer := gorilla.Dialer{HandshakeTimeout: 10 * time.Second}
+	nodeConn, _, err := dialer.Dial(nodeURL, nil)
+	if err != nil {
+		c.WriteJSON(map[string]string{"type": "error", "error": "Failed to connect to node: " + err.Error()})
+		return
+	}
+	defer nodeConn.Close()
+
+	recording := newShellRecording(cols, rows)
+	recordInput := config.Get().Server.ShellRecordInput
+	logID := LogActivity(user.ID, user.Username, ActionServerShell, "Opened a shell on server: "+server.Name, c.IP(), c.Headers("User-Agent"), user.IsAdmin, map[string]interface{}{
+		"server_id":    server.ID,
+		"server_name":  server.Name,
+		"status":       "open",
+		"input_logged": recordInput,
+	})
+	defer func() {
+		data := recording.bytes()
+		recording.mu.Lock()
+		exitCode, truncated := recording.exitCode, recording.truncated
+		recording.mu.Unlock()
+		UpdateActivityMetadata(logID, map[string]interface{}{
+			"server_id":    server.ID,
+			"server_name":  server.Name,
+			"status":       "closed",
+			"input_logged": recordInput,
+			"duration":     int(time.Since(recording.start).Seconds()),
+			"exit_code":    exitCode,
+			"truncated":    truncated,
+		})
+		AttachToActivity(logID, fmt.Sprintf("shell-%s-%d.cast", server.ID, recording.start.Unix()), "application/x-asciicast", data)
+	}()
+
+	done := make(chan struct{})
+	var closeOnce sync.Once
+	closeDone := func() { closeOnce.Do(func() { close(done) }) }
+	var writeMu sync.Mutex
+
+	go func() {
+		defer closeDone()
+		for {
+			_, msg, err := nodeConn.ReadMessage()
+			if err != nil {
+				return
+			}
+			var frame struct {
+				Type string `json:"type"`
+				Data string `json:"data"`
+				Code int    `json:"code"`
+			}
+			if json.Unmarshal(msg, &frame) == nil {
+				switch frame.Type {
+				case "output":
+					recording.event("o", frame.Data)
+				case "exit":
+					recording.setExitCode(frame.Code)
+				}
+			}
+			writeMu.Lock()
+			c.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+			err = c.WriteMessage(websocket.TextMessage, msg)
+			writeMu.Unlock()
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	go func() {
+		ticker := time.NewTicker(wsPingInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				c.Close()
+				return
+			case <-ticker.C:
+				writeMu.Lock()
+				c.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+				err := c.WriteMessage(websocket.PingMessage, nil)
+				writeMu.Unlock()
+				if err != nil {
+					closeDone()
+				}
+			}
+		}
+	}()
+
+	for {
+		_, msg, err := c.ReadMessage()
+		if err != nil {
+			closeDone()
+			return
+		}
+		var frame struct {
+			Type string `json:"type"`
+			Data string `json:"data"`
+			Cols int    `json:"cols"`
+			Rows int    `json:"rows"`
+		}
+		if json.Unmarshal(msg, &frame) != nil {
+			continue
+		}
+		switch frame.Type {
+		case "input":
+			if recordInput {
+				recording.event("i", frame.Data)
+			}
+		case "resize":
+			recording.event("r", fmt.Sprintf("%dx%d", frame.Cols, frame.Rows))
+		default:
+			continue
+		}
+		nodeConn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+		if err := nodeConn.WriteMessage(gorilla.TextMessage, msg); err != nil {
+			closeDone()
+			return
+		}
+	}
+}
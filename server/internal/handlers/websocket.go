@@ -22,6 +22,13 @@ const (
 	wsWriteTimeout  = 10 * time.Second
 )
 
+// consoleQueryParams are passed through to the node so clients can negotiate
+// the v2 console protocol, resume and filter.
+var consoleQueryParams = []string{"protocol", "epoch", "seq", "levels", "pattern", "batch_ms", "tty"}
+
+// consoleWriteTypes are client messages that need console write permission.
+var consoleWriteTypes = map[string]bool{"command": true, "input": true, "resize": true}
+
 func ServerLogsWS(c *websocket.Conn) {
 	serverID := c.Params("id")
 	userID := c.Locals("userID")
@@ -59,9 +66,15 @@ func ServerLogsWS(c *websocket.Conn) {
 		return
 	}
 
-	nodeURL := fmt.Sprintf("ws://%s:%d/api/servers/%s/ws?token=%s",
-		server.Node.FQDN, server.Node.Port, server.ID.String(),
-		url.QueryEscape(server.Node.DaemonToken))
+	query := url.Values{}
+	query.Set("token", server.Node.DaemonToken)
+	for _, key := range consoleQueryParams {
+		if v := c.Query(key); v != "" {
+			query.Set(key, v)
+		}
+	}
+	nodeURL := fmt.Sprintf("ws://%s:%d/api/servers/%s/ws?%s",
+		server.Node.FQDN, server.Node.Port, server.ID.String(), query.Encode())
 
 	dialer := gorilla.Dialer{
 		HandshakeTimeout: 10 * time.Second,
@@ -150,7 +163,7 @@ func ServerLogsWS(c *websocket.Conn) {
 		var wsMsg struct {
 			Type string `json:"type"`
 		}
-		if json.Unmarshal(msg, &wsMsg) == nil && wsMsg.Type == "command" {
+		if json.Unmarshal(msg, &wsMsg) == nil && consoleWriteTypes[wsMsg.Type] {
 			if !canWrite {
 				continue
 			}
@@ -6,9 +6,12 @@ import (
 	"encoding/hex"
 	"fmt"
 	"hash/fnv"
+	"log"
 	"sync"
 	"time"
 
+	"birdactyl-panel-backend/internal/state"
+
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -111,7 +114,7 @@ func ThousandTHR(config ThousandTHRConfig) fiber.Handler {
 		err := c.Next()
 
 		if config.SkipFailedRequest && c.Response().StatusCode() >= 400 {
-			refundToken(key)
+			refundToken(key, config)
 		}
 
 		return err
@@ -156,6 +159,14 @@ func getShard(key string) *shard {
 }
 
 func checkRateLimit(key string, config ThousandTHRConfig, refillRate float64) (allowed bool, remaining int, resetIn int) {
+	if state.Shared() {
+		allowed, remaining, resetIn, err := checkSharedRateLimit(key, config, refillRate)
+		if err == nil {
+			return allowed, remaining, resetIn
+		}
+		log.Printf("[ratelimit] shared bucket unavailable, limiting locally: %v", err)
+	}
+
 	s := getShard(key)
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -219,7 +230,11 @@ func (s *shard) evictOldest() {
 	}
 }
 
-func refundToken(key string) {
+func refundToken(key string, config ThousandTHRConfig) {
+	if state.Shared() && refundSharedToken(key, config) == nil {
+		return
+	}
+
 	s := getShard(key)
 	s.mu.Lock()
 	defer s.mu.Unlock()
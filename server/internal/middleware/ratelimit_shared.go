@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"birdactyl-panel-backend/internal/state"
+)
+
+// sharedBucket is a token bucket kept in shared state so every panel
+// instance draws from the same allowance.
+type sharedBucket struct {
+	Tokens     float64 `json:"t"`
+	LastRefill int64   `json:"r"`
+}
+
+func sharedBucketKey(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return "ratelimit:" + hex.EncodeToString(hash[:16])
+}
+
+func checkSharedRateLimit(key string, config ThousandTHRConfig, refillRate float64) (allowed bool, remaining int, resetIn int, err error) {
+	maxTokens := float64(config.BurstLimit)
+	err = state.Update(sharedBucketKey(key), bucketExpiry, func(cur []byte) ([]byte, error) {
+		now := time.Now()
+		bucket := sharedBucket{Tokens: maxTokens, LastRefill: now.UnixMilli()}
+		if cur != nil {
+			json.Unmarshal(cur, &bucket)
+		}
+
+		elapsed := now.Sub(time.UnixMilli(bucket.LastRefill)).Seconds()
+		if elapsed > 0 && refillRate > 0 {
+			bucket.Tokens = minFloat(bucket.Tokens+elapsed*refillRate, maxTokens)
+			bucket.LastRefill = now.UnixMilli()
+		}
+
+		resetIn = 0
+		if refillRate > 0 && bucket.Tokens < maxTokens {
+			resetIn = int((maxTokens - bucket.Tokens) / refillRate)
+		}
+
+		if bucket.Tokens >= 1.0 {
+			bucket.Tokens -= 1.0
+			allowed, remaining = true, int(bucket.Tokens)
+		} else {
+			allowed, remaining = false, 0
+			if refillRate > 0 {
+				resetIn = int(1.0 / refillRate)
+			} else {
+				resetIn = 60
+			}
+		}
+		return json.Marshal(bucket)
+	})
+	return allowed, remaining, resetIn, err
+}
+
+func refundSharedToken(key string, config ThousandTHRConfig) error {
+	return state.Update(sharedBucketKey(key), bucketExpiry, func(cur []byte) ([]byte, error) {
+		if cur == nil {
+			return nil, state.ErrUnchanged
+		}
+		var bucket sharedBucket
+		json.Unmarshal(cur, &bucket)
+		bucket.Tokens = minFloat(bucket.Tokens+1.0, float64(config.BurstLimit))
+		return json.Marshal(bucket)
+	})
+}
@@ -26,3 +26,20 @@ func (a *ActivityLog) BeforeCreate(tx *gorm.DB) error {
 	}
 	return nil
 }
+
+type ActivityAttachment struct {
+	ID            uuid.UUID `gorm:"primaryKey" json:"id"`
+	ActivityLogID uuid.UUID `gorm:"index;not null" json:"activity_log_id"`
+	Name          string    `gorm:"type:varchar(255);not null" json:"name"`
+	ContentType   string    `gorm:"type:varchar(100)" json:"content_type"`
+	Size          int64     `json:"size"`
+	Data          []byte    `json:"-"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+func (a *ActivityAttachment) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return nil
+}
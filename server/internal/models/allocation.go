@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AllocationAnyIP is the address of allocations that bind every interface
+// of the node.
+const AllocationAnyIP = "0.0.0.0"
+
+// Allocation is one IP and port a node offers to servers. ServerID is set
+// while a server holds it.
+type Allocation struct {
+	ID        uuid.UUID  `gorm:"primaryKey" json:"id"`
+	NodeID    uuid.UUID  `gorm:"not null;uniqueIndex:idx_allocations_node_ip_port" json:"node_id"`
+	IP        string     `gorm:"type:varchar(45);not null;uniqueIndex:idx_allocations_node_ip_port" json:"ip"`
+	Port      int        `gorm:"not null;uniqueIndex:idx_allocations_node_ip_port" json:"port"`
+	Alias     string     `gorm:"type:varchar(255)" json:"alias"`
+	ServerID  *uuid.UUID `gorm:"index" json:"server_id"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+func (a *Allocation) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return nil
+}
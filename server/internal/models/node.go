@@ -6,26 +6,32 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/datatypes"
 	"gorm.io/gorm"
 )
 
 type Node struct {
-	ID            uuid.UUID      `gorm:"primaryKey" json:"id"`
-	Name          string         `gorm:"type:varchar(255);not null" json:"name"`
-	Icon          string         `gorm:"type:varchar(500)" json:"icon"`
-	FQDN          string         `gorm:"type:varchar(255);not null" json:"fqdn"`
-	Port          int            `gorm:"not null;default:8443" json:"port"`
-	TokenID       string         `gorm:"type:varchar(255);uniqueIndex;not null" json:"-"`
-	TokenHash     string         `gorm:"type:varchar(255);not null" json:"-"`
-	DaemonToken   string         `gorm:"type:varchar(255);not null" json:"-"`
-	IsOnline      bool           `gorm:"default:false" json:"is_online"`
-	AuthError     bool           `gorm:"default:false" json:"auth_error"`
-	LastHeartbeat *time.Time     `json:"last_heartbeat"`
-	SystemInfo    SystemInfo     `gorm:"type:json" json:"system_info"`
-	DisplayIP     string         `gorm:"type:varchar(255)" json:"display_ip"`
-	CreatedAt     time.Time      `json:"created_at"`
-	UpdatedAt     time.Time      `json:"updated_at"`
-	DeletedAt     gorm.DeletedAt `gorm:"index" json:"-"`
+	ID              uuid.UUID      `gorm:"primaryKey" json:"id"`
+	Name            string         `gorm:"type:varchar(255);not null" json:"name"`
+	Icon            string         `gorm:"type:varchar(500)" json:"icon"`
+	FQDN            string         `gorm:"type:varchar(255);not null" json:"fqdn"`
+	Port            int            `gorm:"not null;default:8443" json:"port"`
+	TokenID         string         `gorm:"type:varchar(255);uniqueIndex;not null" json:"-"`
+	TokenHash       string         `gorm:"type:varchar(255);not null" json:"-"`
+	DaemonToken     string         `gorm:"type:varchar(255);not null" json:"-"`
+	IsOnline        bool           `gorm:"default:false" json:"is_online"`
+	AuthError       bool           `gorm:"default:false" json:"auth_error"`
+	LastHeartbeat   *time.Time     `json:"last_heartbeat"`
+	SystemInfo      SystemInfo     `gorm:"type:json" json:"system_info"`
+	DisplayIP       string         `gorm:"type:varchar(255)" json:"display_ip"`
+	ReconcileReport datatypes.JSON `gorm:"type:json" json:"reconcile_report,omitempty"`
+	LastReconcile   *time.Time     `json:"last_reconcile"`
+	DriftReport     datatypes.JSON `gorm:"type:json" json:"drift_report,omitempty"`
+	SyncResult      datatypes.JSON `gorm:"type:json" json:"sync_result,omitempty"`
+	LastSync        *time.Time     `json:"last_sync"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt `gorm:"index" json:"-"`
 }
 
 func (n *Node) BeforeCreate(tx *gorm.DB) error {
@@ -23,9 +23,16 @@ type PackageVariable struct {
 	Rules        string `json:"rules,omitempty"`
 }
 
+type PackageDockerImage struct {
+	Name  string `json:"name"`
+	Image string `json:"image"`
+}
+
 type PackageConfigFile struct {
-	Path     string `json:"path"`
-	Template string `json:"template"`
+	Path     string            `json:"path"`
+	Parser   string            `json:"parser,omitempty"`
+	Template string            `json:"template,omitempty"`
+	Replace  map[string]string `json:"replace,omitempty"`
 }
 
 type AddonSourceMapping struct {
@@ -73,10 +80,16 @@ type Package struct {
 	StopTimeout         int            `json:"stop_timeout" gorm:"default:30"`
 	StartupEditable     bool           `json:"startup_editable" gorm:"default:false"`
 	DockerImageEditable bool           `json:"docker_image_editable" gorm:"default:false"`
+	AllowShell          bool           `json:"allow_shell" gorm:"default:false"`
+	StartupDone         datatypes.JSON `json:"startup_done" gorm:"type:json"`
+	StartupProbe        bool           `json:"startup_probe" gorm:"default:false"`
+	StartupTimeout      int            `json:"startup_timeout" gorm:"default:300"`
+	DockerImages        datatypes.JSON `json:"docker_images" gorm:"type:json"`
 	Ports               datatypes.JSON `json:"ports" gorm:"type:json"`
 	Variables           datatypes.JSON `json:"variables" gorm:"type:json"`
 	ConfigFiles         datatypes.JSON `json:"config_files" gorm:"type:json"`
 	AddonSources        datatypes.JSON `json:"addon_sources" gorm:"type:json"`
+	Revision            int            `json:"revision" gorm:"default:1"`
 	CreatedAt           time.Time      `json:"created_at"`
 	UpdatedAt           time.Time      `json:"updated_at"`
 }
@@ -91,11 +104,17 @@ func (p *Package) BeforeCreate(tx *gorm.DB) error {
 	if p.Variables == nil {
 		p.Variables = []byte("[]")
 	}
+	if p.DockerImages == nil {
+		p.DockerImages = []byte("[]")
+	}
 	if p.ConfigFiles == nil {
 		p.ConfigFiles = []byte("[]")
 	}
 	if p.AddonSources == nil {
 		p.AddonSources = []byte("[]")
 	}
+	if p.StartupDone == nil {
+		p.StartupDone = []byte("[]")
+	}
 	return nil
 }
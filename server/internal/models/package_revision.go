@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+type PackageRevision struct {
+	ID        uuid.UUID      `json:"id" gorm:"primaryKey"`
+	PackageID uuid.UUID      `json:"package_id" gorm:"not null;uniqueIndex:idx_package_revision"`
+	Revision  int            `json:"revision" gorm:"not null;uniqueIndex:idx_package_revision"`
+	Snapshot  datatypes.JSON `json:"snapshot" gorm:"type:json"`
+	CreatedBy *uuid.UUID     `json:"created_by,omitempty"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+func (r *PackageRevision) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
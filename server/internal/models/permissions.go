@@ -51,6 +51,7 @@ const (
 	PermStartupUpdate = "startup.update"
 
 	PermReinstall = "server.reinstall"
+	PermShell     = "server.shell"
 
 	PermActivityView = "activity.view"
 
@@ -72,7 +73,7 @@ var AllPermissions = []string{
 	PermAllocationView, PermAllocationAdd, PermAllocationDelete, PermAllocationSetPrimary,
 	PermSettingsView, PermSettingsRename, PermSettingsResources,
 	PermStartupView, PermStartupUpdate,
-	PermReinstall,
+	PermReinstall, PermShell,
 	PermActivityView,
 	PermSFTPView, PermSFTPResetPassword,
 }
@@ -87,7 +88,7 @@ var PermissionGroups = map[string][]string{
 	"allocation": {PermAllocationView, PermAllocationAdd, PermAllocationDelete, PermAllocationSetPrimary},
 	"settings":   {PermSettingsView, PermSettingsRename, PermSettingsResources},
 	"startup":    {PermStartupView, PermStartupUpdate},
-	"server":     {PermReinstall},
+	"server":     {PermReinstall, PermShell},
 	"activity":   {PermActivityView},
 	"sftp":       {PermSFTPView, PermSFTPResetPassword},
 }
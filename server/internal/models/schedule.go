@@ -16,16 +16,54 @@ type Schedule struct {
 	IsActive       bool           `json:"is_active" gorm:"default:true"`
 	OnlyWhenOnline bool           `json:"only_when_online" gorm:"default:false"`
 	LastRunAt      *time.Time     `json:"last_run_at"`
-	NextRunAt      *time.Time     `json:"next_run_at"`
+	NextRunAt      *time.Time     `json:"next_run_at" gorm:"index"`
 	Tasks          datatypes.JSON `json:"tasks" gorm:"type:json"`
 	CreatedAt      time.Time      `json:"created_at"`
 	UpdatedAt      time.Time      `json:"updated_at"`
 }
 
 type ScheduleTask struct {
-	Sequence int    `json:"sequence"`
-	Action   string `json:"action"`
-	Payload  string `json:"payload"`
+	Sequence          int    `json:"sequence"`
+	Action            string `json:"action"`
+	Payload           string `json:"payload"`
+	ContinueOnFailure bool   `json:"continue_on_failure"`
+}
+
+const (
+	ScheduleTriggerCron    = "cron"
+	ScheduleTriggerManual  = "manual"
+	ScheduleTriggerPlugin  = "plugin"
+	ScheduleTriggerCatchUp = "catch_up"
+)
+
+const (
+	ScheduleRunRunning   = "running"
+	ScheduleRunSucceeded = "succeeded"
+	ScheduleRunPartial   = "partial"
+	ScheduleRunFailed    = "failed"
+	ScheduleRunSkipped   = "skipped"
+)
+
+type ScheduleRun struct {
+	ID          uuid.UUID      `json:"id" gorm:"primaryKey"`
+	ScheduleID  uuid.UUID      `json:"schedule_id" gorm:"index;not null"`
+	ServerID    uuid.UUID      `json:"server_id" gorm:"index;not null"`
+	Trigger     string         `json:"trigger" gorm:"type:varchar(20);not null"`
+	Status      string         `json:"status" gorm:"type:varchar(20);not null"`
+	Error       string         `json:"error,omitempty" gorm:"type:text"`
+	Tasks       datatypes.JSON `json:"tasks" gorm:"type:json"`
+	Instance    string         `json:"instance" gorm:"type:varchar(255)"`
+	StartedAt   time.Time      `json:"started_at"`
+	FinishedAt  *time.Time     `json:"finished_at"`
+	HeartbeatAt *time.Time     `json:"-" gorm:"index"`
+}
+
+type ScheduleTaskResult struct {
+	Sequence   int    `json:"sequence"`
+	Action     string `json:"action"`
+	Status     string `json:"status"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
 }
 
 func (s *Schedule) BeforeCreate(tx *gorm.DB) error {
@@ -37,3 +75,13 @@ func (s *Schedule) BeforeCreate(tx *gorm.DB) error {
 	}
 	return nil
 }
+
+func (r *ScheduleRun) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	if r.Tasks == nil {
+		r.Tasks = []byte("[]")
+	}
+	return nil
+}
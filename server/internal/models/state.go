@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+// StateEntry is a shared key/value entry for the database state backend.
+type StateEntry struct {
+	ID        string `gorm:"primaryKey;type:varchar(255)"`
+	Value     []byte
+	ExpiresAt *time.Time `gorm:"index"`
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	TransferModeArchive = "archive"
+	TransferModeSync    = "sync"
+)
+
+const (
+	TransferStagePending     = "pending"
+	TransferStageSyncing     = "syncing"
+	TransferStageStopping    = "stopping"
+	TransferStageArchiving   = "archiving"
+	TransferStageDownloading = "downloading"
+	TransferStageFinalizing  = "finalizing"
+	TransferStageImporting   = "importing"
+	TransferStageCleanup     = "cleanup"
+	TransferStageComplete    = "complete"
+	TransferStageFailed      = "failed"
+)
+
+// Transfer moves a server's files from one node to another. Its stage is
+// stored after every step so an interrupted transfer can pick up where it
+// stopped.
+type Transfer struct {
+	ID           uuid.UUID  `json:"id" gorm:"primaryKey"`
+	ServerID     uuid.UUID  `json:"server_id" gorm:"index;not null"`
+	ServerName   string     `json:"server_name" gorm:"type:varchar(255)"`
+	FromNodeID   uuid.UUID  `json:"from_node_id" gorm:"not null"`
+	FromNodeName string     `json:"from_node_name" gorm:"type:varchar(255)"`
+	ToNodeID     uuid.UUID  `json:"to_node_id" gorm:"not null"`
+	ToNodeName   string     `json:"to_node_name" gorm:"type:varchar(255)"`
+	Mode         string     `json:"mode" gorm:"type:varchar(10);default:'archive'"`
+	Stage        string     `json:"stage" gorm:"type:varchar(20);index;not null"`
+	Progress     int        `json:"progress"`
+	BytesDone    int64      `json:"bytes_done"`
+	BytesTotal   int64      `json:"bytes_total"`
+	Checksum     string     `json:"checksum,omitempty" gorm:"type:varchar(64)"`
+	WasRunning   bool       `json:"was_running"`
+	Error        string     `json:"error,omitempty" gorm:"type:text"`
+	Instance     string     `json:"instance" gorm:"type:varchar(255)"`
+	HeartbeatAt  *time.Time `json:"-" gorm:"index"`
+	StartedAt    time.Time  `json:"started_at"`
+	CompletedAt  *time.Time `json:"completed_at,omitempty"`
+}
+
+func (t *Transfer) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return nil
+}
+
+// Finished reports whether the transfer reached a final stage.
+func (t *Transfer) Finished() bool {
+	return t.Stage == TransferStageComplete || t.Stage == TransferStageFailed
+}
@@ -16,6 +16,10 @@ func Emit(event EventType, data map[string]string) (bool, string) {
 		Sync:      SyncEvents[event],
 	}
 
+	if !ev.Sync {
+		relayEvent(ev)
+	}
+
 	legacyPlugins := GetRegistry().GetByEvent(event)
 	var streamPlugins []*PluginStream
 	for _, ps := range GetStreamRegistry().All() {
@@ -4,9 +4,11 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 	"sync"
@@ -73,6 +75,7 @@ func (s *PanelServer) CreateServer(ctx context.Context, req *pb.CreateServerRequ
 	server, err := services.CreateServer(userID, services.CreateServerRequest{
 		Name: req.Name, NodeID: nodeID, PackageID: packageID,
 		Memory: int(req.Memory), CPU: int(req.Cpu), Disk: int(req.Disk),
+		IsAdmin: true,
 	})
 	if err != nil {
 		return nil, status.Error(codes.Internal, err.Error())
@@ -161,7 +164,7 @@ func (s *PanelServer) ReinstallServer(ctx context.Context, req *pb.IDRequest) (*
 func (s *PanelServer) TransferServer(ctx context.Context, req *pb.TransferServerRequest) (*pb.Empty, error) {
 	serverID, _ := uuid.Parse(req.ServerId)
 	nodeID, _ := uuid.Parse(req.TargetNodeId)
-	services.StartTransfer(serverID, nodeID)
+	services.StartTransfer(serverID, nodeID, "")
 	return &pb.Empty{}, nil
 }
 
@@ -216,7 +219,7 @@ func (s *PanelServer) GetFullLog(ctx context.Context, req *pb.IDRequest) (*pb.Fu
 
 func (s *PanelServer) SearchLogs(ctx context.Context, req *pb.SearchLogsRequest) (*pb.SearchLogsResponse, error) {
 	serverID, _ := uuid.Parse(req.ServerId)
-	matches := services.SearchLogs(serverID, req.Pattern, req.Regex, int(req.Limit), req.Since)
+	matches := services.SearchLogs(serverID, services.LogSearchOptions{Pattern: req.Pattern, Regex: req.Regex, Limit: int(req.Limit), Since: req.Since})
 	var pbMatches []*pb.LogMatch
 	for _, m := range matches {
 		pbMatches = append(pbMatches, &pb.LogMatch{Line: m.Line, LineNumber: int32(m.LineNumber), Timestamp: m.Timestamp})
@@ -566,18 +569,17 @@ func (s *PanelServer) ReadFile(ctx context.Context, req *pb.FilePathRequest) (*p
 	if err := database.DB.Preload("Node").First(&server, "id = ?", req.ServerId).Error; err != nil {
 		return nil, status.Error(codes.NotFound, "server not found")
 	}
-	data, err := services.ProxyGetToNode(&server, "/files/read?path="+req.Path)
+	data, err := services.ProxyGetToNode(&server, "/files/read?path="+url.QueryEscape(req.Path))
 	if err != nil {
 		return nil, status.Error(codes.Internal, err.Error())
 	}
 	var resp struct {
-		Data struct {
-			Content string `json:"content"`
-		} `json:"data"`
+		Data string `json:"data"`
+		ETag string `json:"etag"`
 	}
 	b, _ := json.Marshal(data)
 	json.Unmarshal(b, &resp)
-	return &pb.FileContent{Content: []byte(resp.Data.Content)}, nil
+	return &pb.FileContent{Content: []byte(resp.Data), Etag: resp.ETag}, nil
 }
 
 func (s *PanelServer) WriteFile(ctx context.Context, req *pb.WriteFileRequest) (*pb.Empty, error) {
@@ -585,8 +587,24 @@ func (s *PanelServer) WriteFile(ctx context.Context, req *pb.WriteFileRequest) (
 	if err := database.DB.Preload("Node").First(&server, "id = ?", req.ServerId).Error; err != nil {
 		return nil, status.Error(codes.NotFound, "server not found")
 	}
-	body, _ := json.Marshal(map[string]string{"path": req.Path, "content": string(req.Content)})
-	services.ProxyPostToNode(&server, "/files/write", body)
+	body := map[string]string{"path": req.Path, "content": string(req.Content), "if_match": req.IfMatch}
+	resp, err := services.ProxyToNode(&server, "POST", "/api/servers/"+server.ID.String()+"/files/write", body)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	if resp.StatusCode == http.StatusConflict {
+		return nil, status.Error(codes.Aborted, "file has been modified since it was read")
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var result struct {
+			Error string `json:"error"`
+		}
+		json.Unmarshal(resp.Body, &result)
+		if result.Error == "" {
+			result.Error = fmt.Sprintf("node returned status %d", resp.StatusCode)
+		}
+		return nil, status.Error(codes.Internal, result.Error)
+	}
 	return &pb.Empty{}, nil
 }
 
@@ -765,32 +783,38 @@ func (s *PanelServer) CreatePackage(ctx context.Context, req *pb.CreatePackageRe
 		Name: req.Name, Description: req.Description, DockerImage: req.DockerImage,
 		Startup: req.StartupCommand, StopSignal: req.StopCommand,
 	}
-	database.DB.Create(pkg)
+	if err := services.CreatePackage(pkg, nil); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
 	return packageToProto(pkg), nil
 }
 
 func (s *PanelServer) UpdatePackage(ctx context.Context, req *pb.UpdatePackageRequest) (*pb.Package, error) {
-	var pkg models.Package
-	if err := database.DB.First(&pkg, "id = ?", req.Id).Error; err != nil {
-		return nil, status.Error(codes.NotFound, "package not found")
+	id, err := uuid.Parse(req.Id)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid package id")
 	}
+	updates := map[string]interface{}{}
 	if req.Name != "" {
-		pkg.Name = req.Name
+		updates["name"] = req.Name
 	}
 	if req.Description != "" {
-		pkg.Description = req.Description
+		updates["description"] = req.Description
 	}
 	if req.DockerImage != "" {
-		pkg.DockerImage = req.DockerImage
+		updates["docker_image"] = req.DockerImage
 	}
 	if req.StartupCommand != "" {
-		pkg.Startup = req.StartupCommand
+		updates["startup"] = req.StartupCommand
 	}
 	if req.StopCommand != "" {
-		pkg.StopSignal = req.StopCommand
+		updates["stop_signal"] = req.StopCommand
 	}
-	database.DB.Save(&pkg)
-	return packageToProto(&pkg), nil
+	pkg, err := services.UpdatePackage(id, updates, nil)
+	if err != nil {
+		return nil, status.Error(codes.NotFound, "package not found")
+	}
+	return packageToProto(pkg), nil
 }
 
 func (s *PanelServer) DeletePackage(ctx context.Context, req *pb.IDRequest) (*pb.Empty, error) {
@@ -836,6 +860,21 @@ func (s *PanelServer) SetServerCreationEnabled(ctx context.Context, req *pb.Bool
 	return &pb.Empty{}, nil
 }
 
+func (s *PanelServer) RunSchedule(ctx context.Context, req *pb.IDRequest) (*pb.ScheduleRunResponse, error) {
+	scheduleID, err := uuid.Parse(req.Id)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid schedule id")
+	}
+	if _, err := services.GetScheduleByID(scheduleID); err != nil {
+		return nil, status.Error(codes.NotFound, "schedule not found")
+	}
+	run, err := services.RunScheduleNow(scheduleID, models.ScheduleTriggerPlugin)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &pb.ScheduleRunResponse{RunId: run.ID.String(), Status: run.Status}, nil
+}
+
 func (s *PanelServer) GetActivityLogs(ctx context.Context, req *pb.GetLogsRequest) (*pb.GetLogsResponse, error) {
 	var logs []models.ActivityLog
 	var total int64
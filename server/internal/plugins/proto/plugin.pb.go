@@ -128,7 +128,7 @@ func (x AddonInstallAction_ActionType) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use AddonInstallAction_ActionType.Descriptor instead.
 func (AddonInstallAction_ActionType) EnumDescriptor() ([]byte, []int) {
-	return file_plugin_proto_rawDescGZIP(), []int{106, 0}
+	return file_plugin_proto_rawDescGZIP(), []int{107, 0}
 }
 
 type PluginMessage struct {
@@ -4698,6 +4698,7 @@ type FileContent struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Content       []byte                 `protobuf:"bytes,1,opt,name=content,proto3" json:"content,omitempty"`
 	Mime          string                 `protobuf:"bytes,2,opt,name=mime,proto3" json:"mime,omitempty"`
+	Etag          string                 `protobuf:"bytes,3,opt,name=etag,proto3" json:"etag,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -4746,11 +4747,19 @@ func (x *FileContent) GetMime() string {
 	return ""
 }
 
+func (x *FileContent) GetEtag() string {
+	if x != nil {
+		return x.Etag
+	}
+	return ""
+}
+
 type WriteFileRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	ServerId      string                 `protobuf:"bytes,1,opt,name=server_id,json=serverId,proto3" json:"server_id,omitempty"`
 	Path          string                 `protobuf:"bytes,2,opt,name=path,proto3" json:"path,omitempty"`
 	Content       []byte                 `protobuf:"bytes,3,opt,name=content,proto3" json:"content,omitempty"`
+	IfMatch       string                 `protobuf:"bytes,4,opt,name=if_match,json=ifMatch,proto3" json:"if_match,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -4806,6 +4815,13 @@ func (x *WriteFileRequest) GetContent() []byte {
 	return nil
 }
 
+func (x *WriteFileRequest) GetIfMatch() string {
+	if x != nil {
+		return x.IfMatch
+	}
+	return ""
+}
+
 type MoveFileRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	ServerId      string                 `protobuf:"bytes,1,opt,name=server_id,json=serverId,proto3" json:"server_id,omitempty"`
@@ -6003,6 +6019,59 @@ func (x *Settings) GetServerCreationEnabled() bool {
 	return false
 }
 
+// Schedules
+type ScheduleRunResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RunId         string                 `protobuf:"bytes,1,opt,name=run_id,json=runId,proto3" json:"run_id,omitempty"`
+	Status        string                 `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ScheduleRunResponse) Reset() {
+	*x = ScheduleRunResponse{}
+	mi := &file_plugin_proto_msgTypes[88]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ScheduleRunResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScheduleRunResponse) ProtoMessage() {}
+
+func (x *ScheduleRunResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_plugin_proto_msgTypes[88]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScheduleRunResponse.ProtoReflect.Descriptor instead.
+func (*ScheduleRunResponse) Descriptor() ([]byte, []int) {
+	return file_plugin_proto_rawDescGZIP(), []int{88}
+}
+
+func (x *ScheduleRunResponse) GetRunId() string {
+	if x != nil {
+		return x.RunId
+	}
+	return ""
+}
+
+func (x *ScheduleRunResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
 // Activity Logs
 type ActivityLog struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -6020,7 +6089,7 @@ type ActivityLog struct {
 
 func (x *ActivityLog) Reset() {
 	*x = ActivityLog{}
-	mi := &file_plugin_proto_msgTypes[88]
+	mi := &file_plugin_proto_msgTypes[89]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -6032,7 +6101,7 @@ func (x *ActivityLog) String() string {
 func (*ActivityLog) ProtoMessage() {}
 
 func (x *ActivityLog) ProtoReflect() protoreflect.Message {
-	mi := &file_plugin_proto_msgTypes[88]
+	mi := &file_plugin_proto_msgTypes[89]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -6045,7 +6114,7 @@ func (x *ActivityLog) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ActivityLog.ProtoReflect.Descriptor instead.
 func (*ActivityLog) Descriptor() ([]byte, []int) {
-	return file_plugin_proto_rawDescGZIP(), []int{88}
+	return file_plugin_proto_rawDescGZIP(), []int{89}
 }
 
 func (x *ActivityLog) GetId() string {
@@ -6116,7 +6185,7 @@ type GetLogsRequest struct {
 
 func (x *GetLogsRequest) Reset() {
 	*x = GetLogsRequest{}
-	mi := &file_plugin_proto_msgTypes[89]
+	mi := &file_plugin_proto_msgTypes[90]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -6128,7 +6197,7 @@ func (x *GetLogsRequest) String() string {
 func (*GetLogsRequest) ProtoMessage() {}
 
 func (x *GetLogsRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_plugin_proto_msgTypes[89]
+	mi := &file_plugin_proto_msgTypes[90]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -6141,7 +6210,7 @@ func (x *GetLogsRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetLogsRequest.ProtoReflect.Descriptor instead.
 func (*GetLogsRequest) Descriptor() ([]byte, []int) {
-	return file_plugin_proto_rawDescGZIP(), []int{89}
+	return file_plugin_proto_rawDescGZIP(), []int{90}
 }
 
 func (x *GetLogsRequest) GetLimit() int32 {
@@ -6182,7 +6251,7 @@ type GetLogsResponse struct {
 
 func (x *GetLogsResponse) Reset() {
 	*x = GetLogsResponse{}
-	mi := &file_plugin_proto_msgTypes[90]
+	mi := &file_plugin_proto_msgTypes[91]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -6194,7 +6263,7 @@ func (x *GetLogsResponse) String() string {
 func (*GetLogsResponse) ProtoMessage() {}
 
 func (x *GetLogsResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_plugin_proto_msgTypes[90]
+	mi := &file_plugin_proto_msgTypes[91]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -6207,7 +6276,7 @@ func (x *GetLogsResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetLogsResponse.ProtoReflect.Descriptor instead.
 func (*GetLogsResponse) Descriptor() ([]byte, []int) {
-	return file_plugin_proto_rawDescGZIP(), []int{90}
+	return file_plugin_proto_rawDescGZIP(), []int{91}
 }
 
 func (x *GetLogsResponse) GetLogs() []*ActivityLog {
@@ -6235,7 +6304,7 @@ type LogRequest struct {
 
 func (x *LogRequest) Reset() {
 	*x = LogRequest{}
-	mi := &file_plugin_proto_msgTypes[91]
+	mi := &file_plugin_proto_msgTypes[92]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -6247,7 +6316,7 @@ func (x *LogRequest) String() string {
 func (*LogRequest) ProtoMessage() {}
 
 func (x *LogRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_plugin_proto_msgTypes[91]
+	mi := &file_plugin_proto_msgTypes[92]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -6260,7 +6329,7 @@ func (x *LogRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use LogRequest.ProtoReflect.Descriptor instead.
 func (*LogRequest) Descriptor() ([]byte, []int) {
-	return file_plugin_proto_rawDescGZIP(), []int{91}
+	return file_plugin_proto_rawDescGZIP(), []int{92}
 }
 
 func (x *LogRequest) GetLevel() string {
@@ -6286,7 +6355,7 @@ type KVRequest struct {
 
 func (x *KVRequest) Reset() {
 	*x = KVRequest{}
-	mi := &file_plugin_proto_msgTypes[92]
+	mi := &file_plugin_proto_msgTypes[93]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -6298,7 +6367,7 @@ func (x *KVRequest) String() string {
 func (*KVRequest) ProtoMessage() {}
 
 func (x *KVRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_plugin_proto_msgTypes[92]
+	mi := &file_plugin_proto_msgTypes[93]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -6311,7 +6380,7 @@ func (x *KVRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use KVRequest.ProtoReflect.Descriptor instead.
 func (*KVRequest) Descriptor() ([]byte, []int) {
-	return file_plugin_proto_rawDescGZIP(), []int{92}
+	return file_plugin_proto_rawDescGZIP(), []int{93}
 }
 
 func (x *KVRequest) GetKey() string {
@@ -6331,7 +6400,7 @@ type KVResponse struct {
 
 func (x *KVResponse) Reset() {
 	*x = KVResponse{}
-	mi := &file_plugin_proto_msgTypes[93]
+	mi := &file_plugin_proto_msgTypes[94]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -6343,7 +6412,7 @@ func (x *KVResponse) String() string {
 func (*KVResponse) ProtoMessage() {}
 
 func (x *KVResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_plugin_proto_msgTypes[93]
+	mi := &file_plugin_proto_msgTypes[94]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -6356,7 +6425,7 @@ func (x *KVResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use KVResponse.ProtoReflect.Descriptor instead.
 func (*KVResponse) Descriptor() ([]byte, []int) {
-	return file_plugin_proto_rawDescGZIP(), []int{93}
+	return file_plugin_proto_rawDescGZIP(), []int{94}
 }
 
 func (x *KVResponse) GetValue() string {
@@ -6383,7 +6452,7 @@ type KVSetRequest struct {
 
 func (x *KVSetRequest) Reset() {
 	*x = KVSetRequest{}
-	mi := &file_plugin_proto_msgTypes[94]
+	mi := &file_plugin_proto_msgTypes[95]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -6395,7 +6464,7 @@ func (x *KVSetRequest) String() string {
 func (*KVSetRequest) ProtoMessage() {}
 
 func (x *KVSetRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_plugin_proto_msgTypes[94]
+	mi := &file_plugin_proto_msgTypes[95]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -6408,7 +6477,7 @@ func (x *KVSetRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use KVSetRequest.ProtoReflect.Descriptor instead.
 func (*KVSetRequest) Descriptor() ([]byte, []int) {
-	return file_plugin_proto_rawDescGZIP(), []int{94}
+	return file_plugin_proto_rawDescGZIP(), []int{95}
 }
 
 func (x *KVSetRequest) GetKey() string {
@@ -6435,7 +6504,7 @@ type QueryDBRequest struct {
 
 func (x *QueryDBRequest) Reset() {
 	*x = QueryDBRequest{}
-	mi := &file_plugin_proto_msgTypes[95]
+	mi := &file_plugin_proto_msgTypes[96]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -6447,7 +6516,7 @@ func (x *QueryDBRequest) String() string {
 func (*QueryDBRequest) ProtoMessage() {}
 
 func (x *QueryDBRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_plugin_proto_msgTypes[95]
+	mi := &file_plugin_proto_msgTypes[96]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -6460,7 +6529,7 @@ func (x *QueryDBRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use QueryDBRequest.ProtoReflect.Descriptor instead.
 func (*QueryDBRequest) Descriptor() ([]byte, []int) {
-	return file_plugin_proto_rawDescGZIP(), []int{95}
+	return file_plugin_proto_rawDescGZIP(), []int{96}
 }
 
 func (x *QueryDBRequest) GetQuery() string {
@@ -6486,7 +6555,7 @@ type QueryDBResponse struct {
 
 func (x *QueryDBResponse) Reset() {
 	*x = QueryDBResponse{}
-	mi := &file_plugin_proto_msgTypes[96]
+	mi := &file_plugin_proto_msgTypes[97]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -6498,7 +6567,7 @@ func (x *QueryDBResponse) String() string {
 func (*QueryDBResponse) ProtoMessage() {}
 
 func (x *QueryDBResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_plugin_proto_msgTypes[96]
+	mi := &file_plugin_proto_msgTypes[97]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -6511,7 +6580,7 @@ func (x *QueryDBResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use QueryDBResponse.ProtoReflect.Descriptor instead.
 func (*QueryDBResponse) Descriptor() ([]byte, []int) {
-	return file_plugin_proto_rawDescGZIP(), []int{96}
+	return file_plugin_proto_rawDescGZIP(), []int{97}
 }
 
 func (x *QueryDBResponse) GetRows() [][]byte {
@@ -6531,7 +6600,7 @@ type BroadcastEventRequest struct {
 
 func (x *BroadcastEventRequest) Reset() {
 	*x = BroadcastEventRequest{}
-	mi := &file_plugin_proto_msgTypes[97]
+	mi := &file_plugin_proto_msgTypes[98]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -6543,7 +6612,7 @@ func (x *BroadcastEventRequest) String() string {
 func (*BroadcastEventRequest) ProtoMessage() {}
 
 func (x *BroadcastEventRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_plugin_proto_msgTypes[97]
+	mi := &file_plugin_proto_msgTypes[98]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -6556,7 +6625,7 @@ func (x *BroadcastEventRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use BroadcastEventRequest.ProtoReflect.Descriptor instead.
 func (*BroadcastEventRequest) Descriptor() ([]byte, []int) {
-	return file_plugin_proto_rawDescGZIP(), []int{97}
+	return file_plugin_proto_rawDescGZIP(), []int{98}
 }
 
 func (x *BroadcastEventRequest) GetEventType() string {
@@ -6585,7 +6654,7 @@ type NotificationRequest struct {
 
 func (x *NotificationRequest) Reset() {
 	*x = NotificationRequest{}
-	mi := &file_plugin_proto_msgTypes[98]
+	mi := &file_plugin_proto_msgTypes[99]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -6597,7 +6666,7 @@ func (x *NotificationRequest) String() string {
 func (*NotificationRequest) ProtoMessage() {}
 
 func (x *NotificationRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_plugin_proto_msgTypes[98]
+	mi := &file_plugin_proto_msgTypes[99]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -6610,7 +6679,7 @@ func (x *NotificationRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use NotificationRequest.ProtoReflect.Descriptor instead.
 func (*NotificationRequest) Descriptor() ([]byte, []int) {
-	return file_plugin_proto_rawDescGZIP(), []int{98}
+	return file_plugin_proto_rawDescGZIP(), []int{99}
 }
 
 func (x *NotificationRequest) GetUserId() string {
@@ -6655,7 +6724,7 @@ type PluginHTTPRequest struct {
 
 func (x *PluginHTTPRequest) Reset() {
 	*x = PluginHTTPRequest{}
-	mi := &file_plugin_proto_msgTypes[99]
+	mi := &file_plugin_proto_msgTypes[100]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -6667,7 +6736,7 @@ func (x *PluginHTTPRequest) String() string {
 func (*PluginHTTPRequest) ProtoMessage() {}
 
 func (x *PluginHTTPRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_plugin_proto_msgTypes[99]
+	mi := &file_plugin_proto_msgTypes[100]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -6680,7 +6749,7 @@ func (x *PluginHTTPRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PluginHTTPRequest.ProtoReflect.Descriptor instead.
 func (*PluginHTTPRequest) Descriptor() ([]byte, []int) {
-	return file_plugin_proto_rawDescGZIP(), []int{99}
+	return file_plugin_proto_rawDescGZIP(), []int{100}
 }
 
 func (x *PluginHTTPRequest) GetMethod() string {
@@ -6730,7 +6799,7 @@ type PluginHTTPResponse struct {
 
 func (x *PluginHTTPResponse) Reset() {
 	*x = PluginHTTPResponse{}
-	mi := &file_plugin_proto_msgTypes[100]
+	mi := &file_plugin_proto_msgTypes[101]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -6742,7 +6811,7 @@ func (x *PluginHTTPResponse) String() string {
 func (*PluginHTTPResponse) ProtoMessage() {}
 
 func (x *PluginHTTPResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_plugin_proto_msgTypes[100]
+	mi := &file_plugin_proto_msgTypes[101]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -6755,7 +6824,7 @@ func (x *PluginHTTPResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PluginHTTPResponse.ProtoReflect.Descriptor instead.
 func (*PluginHTTPResponse) Descriptor() ([]byte, []int) {
-	return file_plugin_proto_rawDescGZIP(), []int{100}
+	return file_plugin_proto_rawDescGZIP(), []int{101}
 }
 
 func (x *PluginHTTPResponse) GetStatus() int32 {
@@ -6798,7 +6867,7 @@ type CallPluginRequest struct {
 
 func (x *CallPluginRequest) Reset() {
 	*x = CallPluginRequest{}
-	mi := &file_plugin_proto_msgTypes[101]
+	mi := &file_plugin_proto_msgTypes[102]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -6810,7 +6879,7 @@ func (x *CallPluginRequest) String() string {
 func (*CallPluginRequest) ProtoMessage() {}
 
 func (x *CallPluginRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_plugin_proto_msgTypes[101]
+	mi := &file_plugin_proto_msgTypes[102]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -6823,7 +6892,7 @@ func (x *CallPluginRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CallPluginRequest.ProtoReflect.Descriptor instead.
 func (*CallPluginRequest) Descriptor() ([]byte, []int) {
-	return file_plugin_proto_rawDescGZIP(), []int{101}
+	return file_plugin_proto_rawDescGZIP(), []int{102}
 }
 
 func (x *CallPluginRequest) GetPluginId() string {
@@ -6857,7 +6926,7 @@ type CallPluginResponse struct {
 
 func (x *CallPluginResponse) Reset() {
 	*x = CallPluginResponse{}
-	mi := &file_plugin_proto_msgTypes[102]
+	mi := &file_plugin_proto_msgTypes[103]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -6869,7 +6938,7 @@ func (x *CallPluginResponse) String() string {
 func (*CallPluginResponse) ProtoMessage() {}
 
 func (x *CallPluginResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_plugin_proto_msgTypes[102]
+	mi := &file_plugin_proto_msgTypes[103]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -6882,7 +6951,7 @@ func (x *CallPluginResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CallPluginResponse.ProtoReflect.Descriptor instead.
 func (*CallPluginResponse) Descriptor() ([]byte, []int) {
-	return file_plugin_proto_rawDescGZIP(), []int{102}
+	return file_plugin_proto_rawDescGZIP(), []int{103}
 }
 
 func (x *CallPluginResponse) GetData() []byte {
@@ -6910,7 +6979,7 @@ type AddonTypeInfo struct {
 
 func (x *AddonTypeInfo) Reset() {
 	*x = AddonTypeInfo{}
-	mi := &file_plugin_proto_msgTypes[103]
+	mi := &file_plugin_proto_msgTypes[104]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -6922,7 +6991,7 @@ func (x *AddonTypeInfo) String() string {
 func (*AddonTypeInfo) ProtoMessage() {}
 
 func (x *AddonTypeInfo) ProtoReflect() protoreflect.Message {
-	mi := &file_plugin_proto_msgTypes[103]
+	mi := &file_plugin_proto_msgTypes[104]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -6935,7 +7004,7 @@ func (x *AddonTypeInfo) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use AddonTypeInfo.ProtoReflect.Descriptor instead.
 func (*AddonTypeInfo) Descriptor() ([]byte, []int) {
-	return file_plugin_proto_rawDescGZIP(), []int{103}
+	return file_plugin_proto_rawDescGZIP(), []int{104}
 }
 
 func (x *AddonTypeInfo) GetTypeId() string {
@@ -6975,7 +7044,7 @@ type AddonTypeRequest struct {
 
 func (x *AddonTypeRequest) Reset() {
 	*x = AddonTypeRequest{}
-	mi := &file_plugin_proto_msgTypes[104]
+	mi := &file_plugin_proto_msgTypes[105]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -6987,7 +7056,7 @@ func (x *AddonTypeRequest) String() string {
 func (*AddonTypeRequest) ProtoMessage() {}
 
 func (x *AddonTypeRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_plugin_proto_msgTypes[104]
+	mi := &file_plugin_proto_msgTypes[105]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -7000,7 +7069,7 @@ func (x *AddonTypeRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use AddonTypeRequest.ProtoReflect.Descriptor instead.
 func (*AddonTypeRequest) Descriptor() ([]byte, []int) {
-	return file_plugin_proto_rawDescGZIP(), []int{104}
+	return file_plugin_proto_rawDescGZIP(), []int{105}
 }
 
 func (x *AddonTypeRequest) GetTypeId() string {
@@ -7071,7 +7140,7 @@ type AddonTypeResponse struct {
 
 func (x *AddonTypeResponse) Reset() {
 	*x = AddonTypeResponse{}
-	mi := &file_plugin_proto_msgTypes[105]
+	mi := &file_plugin_proto_msgTypes[106]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -7083,7 +7152,7 @@ func (x *AddonTypeResponse) String() string {
 func (*AddonTypeResponse) ProtoMessage() {}
 
 func (x *AddonTypeResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_plugin_proto_msgTypes[105]
+	mi := &file_plugin_proto_msgTypes[106]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -7096,7 +7165,7 @@ func (x *AddonTypeResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use AddonTypeResponse.ProtoReflect.Descriptor instead.
 func (*AddonTypeResponse) Descriptor() ([]byte, []int) {
-	return file_plugin_proto_rawDescGZIP(), []int{105}
+	return file_plugin_proto_rawDescGZIP(), []int{106}
 }
 
 func (x *AddonTypeResponse) GetSuccess() bool {
@@ -7143,7 +7212,7 @@ type AddonInstallAction struct {
 
 func (x *AddonInstallAction) Reset() {
 	*x = AddonInstallAction{}
-	mi := &file_plugin_proto_msgTypes[106]
+	mi := &file_plugin_proto_msgTypes[107]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -7155,7 +7224,7 @@ func (x *AddonInstallAction) String() string {
 func (*AddonInstallAction) ProtoMessage() {}
 
 func (x *AddonInstallAction) ProtoReflect() protoreflect.Message {
-	mi := &file_plugin_proto_msgTypes[106]
+	mi := &file_plugin_proto_msgTypes[107]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -7168,7 +7237,7 @@ func (x *AddonInstallAction) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use AddonInstallAction.ProtoReflect.Descriptor instead.
 func (*AddonInstallAction) Descriptor() ([]byte, []int) {
-	return file_plugin_proto_rawDescGZIP(), []int{106}
+	return file_plugin_proto_rawDescGZIP(), []int{107}
 }
 
 func (x *AddonInstallAction) GetType() AddonInstallAction_ActionType {
@@ -7600,14 +7669,16 @@ const file_plugin_proto_rawDesc = "" +
 	"\x05files\x18\x01 \x03(\v2\x11.plugins.FileInfoR\x05files\"B\n" +
 	"\x0fFilePathRequest\x12\x1b\n" +
 	"\tserver_id\x18\x01 \x01(\tR\bserverId\x12\x12\n" +
-	"\x04path\x18\x02 \x01(\tR\x04path\";\n" +
+	"\x04path\x18\x02 \x01(\tR\x04path\"O\n" +
 	"\vFileContent\x12\x18\n" +
 	"\acontent\x18\x01 \x01(\fR\acontent\x12\x12\n" +
-	"\x04mime\x18\x02 \x01(\tR\x04mime\"]\n" +
+	"\x04mime\x18\x02 \x01(\tR\x04mime\x12\x12\n" +
+	"\x04etag\x18\x03 \x01(\tR\x04etag\"x\n" +
 	"\x10WriteFileRequest\x12\x1b\n" +
 	"\tserver_id\x18\x01 \x01(\tR\bserverId\x12\x12\n" +
 	"\x04path\x18\x02 \x01(\tR\x04path\x12\x18\n" +
-	"\acontent\x18\x03 \x01(\fR\acontent\"R\n" +
+	"\acontent\x18\x03 \x01(\fR\acontent\x12\x19\n" +
+	"\bif_match\x18\x04 \x01(\tR\aifMatch\"R\n" +
 	"\x0fMoveFileRequest\x12\x1b\n" +
 	"\tserver_id\x18\x01 \x01(\tR\bserverId\x12\x12\n" +
 	"\x04from\x18\x02 \x01(\tR\x04from\x12\x0e\n" +
@@ -7701,7 +7772,10 @@ const file_plugin_proto_rawDesc = "" +
 	"\x06reason\x18\x02 \x01(\tR\x06reason\"u\n" +
 	"\bSettings\x121\n" +
 	"\x14registration_enabled\x18\x01 \x01(\bR\x13registrationEnabled\x126\n" +
-	"\x17server_creation_enabled\x18\x02 \x01(\bR\x15serverCreationEnabled\"\xd6\x01\n" +
+	"\x17server_creation_enabled\x18\x02 \x01(\bR\x15serverCreationEnabled\"D\n" +
+	"\x13ScheduleRunResponse\x12\x15\n" +
+	"\x06run_id\x18\x01 \x01(\tR\x05runId\x12\x16\n" +
+	"\x06status\x18\x02 \x01(\tR\x06status\"\xd6\x01\n" +
 	"\vActivityLog\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\x17\n" +
 	"\auser_id\x18\x02 \x01(\tR\x06userId\x12\x1a\n" +
@@ -7828,7 +7902,7 @@ const file_plugin_proto_rawDesc = "" +
 	"\n" +
 	"OnSchedule\x12\x18.plugins.ScheduleRequest\x1a\x0e.plugins.Empty\x128\n" +
 	"\aOnMixin\x12\x15.plugins.MixinRequest\x1a\x16.plugins.MixinResponse\x12*\n" +
-	"\bShutdown\x12\x0e.plugins.Empty\x1a\x0e.plugins.Empty2\xf2)\n" +
+	"\bShutdown\x12\x0e.plugins.Empty\x1a\x0e.plugins.Empty2\xb3*\n" +
 	"\fPanelService\x12<\n" +
 	"\aConnect\x12\x16.plugins.PluginMessage\x1a\x15.plugins.PanelMessage(\x010\x01\x120\n" +
 	"\tGetServer\x12\x12.plugins.IDRequest\x1a\x0f.plugins.Server\x12H\n" +
@@ -7921,7 +7995,8 @@ const file_plugin_proto_rawDesc = "" +
 	"\vDeleteIPBan\x12\x12.plugins.IDRequest\x1a\x0e.plugins.Empty\x120\n" +
 	"\vGetSettings\x12\x0e.plugins.Empty\x1a\x11.plugins.Settings\x12>\n" +
 	"\x16SetRegistrationEnabled\x12\x14.plugins.BoolRequest\x1a\x0e.plugins.Empty\x12@\n" +
-	"\x18SetServerCreationEnabled\x12\x14.plugins.BoolRequest\x1a\x0e.plugins.Empty\x12D\n" +
+	"\x18SetServerCreationEnabled\x12\x14.plugins.BoolRequest\x1a\x0e.plugins.Empty\x12?\n" +
+	"\vRunSchedule\x12\x12.plugins.IDRequest\x1a\x1c.plugins.ScheduleRunResponse\x12D\n" +
 	"\x0fGetActivityLogs\x12\x17.plugins.GetLogsRequest\x1a\x18.plugins.GetLogsResponse\x12*\n" +
 	"\x03Log\x12\x13.plugins.LogRequest\x1a\x0e.plugins.Empty\x120\n" +
 	"\x05GetKV\x12\x12.plugins.KVRequest\x1a\x13.plugins.KVResponse\x12.\n" +
@@ -7948,7 +8023,7 @@ func file_plugin_proto_rawDescGZIP() []byte {
 }
 
 var file_plugin_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
-var file_plugin_proto_msgTypes = make([]protoimpl.MessageInfo, 118)
+var file_plugin_proto_msgTypes = make([]protoimpl.MessageInfo, 119)
 var file_plugin_proto_goTypes = []any{
 	(MixinResponse_Action)(0),          // 0: plugins.MixinResponse.Action
 	(AddonInstallAction_ActionType)(0), // 1: plugins.AddonInstallAction.ActionType
@@ -8040,36 +8115,37 @@ var file_plugin_proto_goTypes = []any{
 	(*ListIPBansResponse)(nil),         // 87: plugins.ListIPBansResponse
 	(*CreateIPBanRequest)(nil),         // 88: plugins.CreateIPBanRequest
 	(*Settings)(nil),                   // 89: plugins.Settings
-	(*ActivityLog)(nil),                // 90: plugins.ActivityLog
-	(*GetLogsRequest)(nil),             // 91: plugins.GetLogsRequest
-	(*GetLogsResponse)(nil),            // 92: plugins.GetLogsResponse
-	(*LogRequest)(nil),                 // 93: plugins.LogRequest
-	(*KVRequest)(nil),                  // 94: plugins.KVRequest
-	(*KVResponse)(nil),                 // 95: plugins.KVResponse
-	(*KVSetRequest)(nil),               // 96: plugins.KVSetRequest
-	(*QueryDBRequest)(nil),             // 97: plugins.QueryDBRequest
-	(*QueryDBResponse)(nil),            // 98: plugins.QueryDBResponse
-	(*BroadcastEventRequest)(nil),      // 99: plugins.BroadcastEventRequest
-	(*NotificationRequest)(nil),        // 100: plugins.NotificationRequest
-	(*PluginHTTPRequest)(nil),          // 101: plugins.PluginHTTPRequest
-	(*PluginHTTPResponse)(nil),         // 102: plugins.PluginHTTPResponse
-	(*CallPluginRequest)(nil),          // 103: plugins.CallPluginRequest
-	(*CallPluginResponse)(nil),         // 104: plugins.CallPluginResponse
-	(*AddonTypeInfo)(nil),              // 105: plugins.AddonTypeInfo
-	(*AddonTypeRequest)(nil),           // 106: plugins.AddonTypeRequest
-	(*AddonTypeResponse)(nil),          // 107: plugins.AddonTypeResponse
-	(*AddonInstallAction)(nil),         // 108: plugins.AddonInstallAction
-	nil,                                // 109: plugins.Event.DataEntry
-	nil,                                // 110: plugins.HTTPRequest.HeadersEntry
-	nil,                                // 111: plugins.HTTPRequest.QueryEntry
-	nil,                                // 112: plugins.HTTPResponse.HeadersEntry
-	nil,                                // 113: plugins.UpdateVariablesRequest.VariablesEntry
-	nil,                                // 114: plugins.BroadcastEventRequest.DataEntry
-	nil,                                // 115: plugins.PluginHTTPRequest.HeadersEntry
-	nil,                                // 116: plugins.PluginHTTPResponse.HeadersEntry
-	nil,                                // 117: plugins.AddonTypeRequest.SourceInfoEntry
-	nil,                                // 118: plugins.AddonTypeRequest.ServerVariablesEntry
-	nil,                                // 119: plugins.AddonInstallAction.HeadersEntry
+	(*ScheduleRunResponse)(nil),        // 90: plugins.ScheduleRunResponse
+	(*ActivityLog)(nil),                // 91: plugins.ActivityLog
+	(*GetLogsRequest)(nil),             // 92: plugins.GetLogsRequest
+	(*GetLogsResponse)(nil),            // 93: plugins.GetLogsResponse
+	(*LogRequest)(nil),                 // 94: plugins.LogRequest
+	(*KVRequest)(nil),                  // 95: plugins.KVRequest
+	(*KVResponse)(nil),                 // 96: plugins.KVResponse
+	(*KVSetRequest)(nil),               // 97: plugins.KVSetRequest
+	(*QueryDBRequest)(nil),             // 98: plugins.QueryDBRequest
+	(*QueryDBResponse)(nil),            // 99: plugins.QueryDBResponse
+	(*BroadcastEventRequest)(nil),      // 100: plugins.BroadcastEventRequest
+	(*NotificationRequest)(nil),        // 101: plugins.NotificationRequest
+	(*PluginHTTPRequest)(nil),          // 102: plugins.PluginHTTPRequest
+	(*PluginHTTPResponse)(nil),         // 103: plugins.PluginHTTPResponse
+	(*CallPluginRequest)(nil),          // 104: plugins.CallPluginRequest
+	(*CallPluginResponse)(nil),         // 105: plugins.CallPluginResponse
+	(*AddonTypeInfo)(nil),              // 106: plugins.AddonTypeInfo
+	(*AddonTypeRequest)(nil),           // 107: plugins.AddonTypeRequest
+	(*AddonTypeResponse)(nil),          // 108: plugins.AddonTypeResponse
+	(*AddonInstallAction)(nil),         // 109: plugins.AddonInstallAction
+	nil,                                // 110: plugins.Event.DataEntry
+	nil,                                // 111: plugins.HTTPRequest.HeadersEntry
+	nil,                                // 112: plugins.HTTPRequest.QueryEntry
+	nil,                                // 113: plugins.HTTPResponse.HeadersEntry
+	nil,                                // 114: plugins.UpdateVariablesRequest.VariablesEntry
+	nil,                                // 115: plugins.BroadcastEventRequest.DataEntry
+	nil,                                // 116: plugins.PluginHTTPRequest.HeadersEntry
+	nil,                                // 117: plugins.PluginHTTPResponse.HeadersEntry
+	nil,                                // 118: plugins.AddonTypeRequest.SourceInfoEntry
+	nil,                                // 119: plugins.AddonTypeRequest.ServerVariablesEntry
+	nil,                                // 120: plugins.AddonInstallAction.HeadersEntry
 }
 var file_plugin_proto_depIdxs = []int32{
 	9,   // 0: plugins.PluginMessage.register:type_name -> plugins.PluginInfo
@@ -8077,18 +8153,18 @@ var file_plugin_proto_depIdxs = []int32{
 	25,  // 2: plugins.PluginMessage.http_response:type_name -> plugins.HTTPResponse
 	4,   // 3: plugins.PluginMessage.schedule_response:type_name -> plugins.Empty
 	17,  // 4: plugins.PluginMessage.mixin_response:type_name -> plugins.MixinResponse
-	107, // 5: plugins.PluginMessage.addon_type_response:type_name -> plugins.AddonTypeResponse
+	108, // 5: plugins.PluginMessage.addon_type_response:type_name -> plugins.AddonTypeResponse
 	4,   // 6: plugins.PanelMessage.registered:type_name -> plugins.Empty
 	22,  // 7: plugins.PanelMessage.event:type_name -> plugins.Event
 	24,  // 8: plugins.PanelMessage.http:type_name -> plugins.HTTPRequest
 	26,  // 9: plugins.PanelMessage.schedule:type_name -> plugins.ScheduleRequest
 	16,  // 10: plugins.PanelMessage.mixin:type_name -> plugins.MixinRequest
 	4,   // 11: plugins.PanelMessage.shutdown:type_name -> plugins.Empty
-	106, // 12: plugins.PanelMessage.addon_type:type_name -> plugins.AddonTypeRequest
+	107, // 12: plugins.PanelMessage.addon_type:type_name -> plugins.AddonTypeRequest
 	19,  // 13: plugins.PluginInfo.routes:type_name -> plugins.RouteInfo
 	21,  // 14: plugins.PluginInfo.schedules:type_name -> plugins.ScheduleInfo
 	15,  // 15: plugins.PluginInfo.mixins:type_name -> plugins.MixinInfo
-	105, // 16: plugins.PluginInfo.addon_types:type_name -> plugins.AddonTypeInfo
+	106, // 16: plugins.PluginInfo.addon_types:type_name -> plugins.AddonTypeInfo
 	10,  // 17: plugins.PluginInfo.ui:type_name -> plugins.PluginUIInfo
 	11,  // 18: plugins.PluginUIInfo.pages:type_name -> plugins.PluginUIPage
 	12,  // 19: plugins.PluginUIInfo.tabs:type_name -> plugins.PluginUITab
@@ -8097,12 +8173,12 @@ var file_plugin_proto_depIdxs = []int32{
 	0,   // 22: plugins.MixinResponse.action:type_name -> plugins.MixinResponse.Action
 	18,  // 23: plugins.MixinResponse.notifications:type_name -> plugins.Notification
 	20,  // 24: plugins.RouteInfo.rate_limit:type_name -> plugins.RateLimitConfig
-	109, // 25: plugins.Event.data:type_name -> plugins.Event.DataEntry
-	110, // 26: plugins.HTTPRequest.headers:type_name -> plugins.HTTPRequest.HeadersEntry
-	111, // 27: plugins.HTTPRequest.query:type_name -> plugins.HTTPRequest.QueryEntry
-	112, // 28: plugins.HTTPResponse.headers:type_name -> plugins.HTTPResponse.HeadersEntry
+	110, // 25: plugins.Event.data:type_name -> plugins.Event.DataEntry
+	111, // 26: plugins.HTTPRequest.headers:type_name -> plugins.HTTPRequest.HeadersEntry
+	112, // 27: plugins.HTTPRequest.query:type_name -> plugins.HTTPRequest.QueryEntry
+	113, // 28: plugins.HTTPResponse.headers:type_name -> plugins.HTTPResponse.HeadersEntry
 	27,  // 29: plugins.ListServersResponse.servers:type_name -> plugins.Server
-	113, // 30: plugins.UpdateVariablesRequest.variables:type_name -> plugins.UpdateVariablesRequest.VariablesEntry
+	114, // 30: plugins.UpdateVariablesRequest.variables:type_name -> plugins.UpdateVariablesRequest.VariablesEntry
 	45,  // 31: plugins.SearchLogsResponse.matches:type_name -> plugins.LogMatch
 	47,  // 32: plugins.LogFilesResponse.files:type_name -> plugins.LogFileInfo
 	49,  // 33: plugins.ListUsersResponse.users:type_name -> plugins.User
@@ -8115,15 +8191,15 @@ var file_plugin_proto_depIdxs = []int32{
 	77,  // 40: plugins.NodeWithToken.node:type_name -> plugins.Node
 	82,  // 41: plugins.ListPackagesResponse.packages:type_name -> plugins.Package
 	86,  // 42: plugins.ListIPBansResponse.bans:type_name -> plugins.IPBan
-	90,  // 43: plugins.GetLogsResponse.logs:type_name -> plugins.ActivityLog
-	114, // 44: plugins.BroadcastEventRequest.data:type_name -> plugins.BroadcastEventRequest.DataEntry
-	115, // 45: plugins.PluginHTTPRequest.headers:type_name -> plugins.PluginHTTPRequest.HeadersEntry
-	116, // 46: plugins.PluginHTTPResponse.headers:type_name -> plugins.PluginHTTPResponse.HeadersEntry
-	117, // 47: plugins.AddonTypeRequest.source_info:type_name -> plugins.AddonTypeRequest.SourceInfoEntry
-	118, // 48: plugins.AddonTypeRequest.server_variables:type_name -> plugins.AddonTypeRequest.ServerVariablesEntry
-	108, // 49: plugins.AddonTypeResponse.actions:type_name -> plugins.AddonInstallAction
+	91,  // 43: plugins.GetLogsResponse.logs:type_name -> plugins.ActivityLog
+	115, // 44: plugins.BroadcastEventRequest.data:type_name -> plugins.BroadcastEventRequest.DataEntry
+	116, // 45: plugins.PluginHTTPRequest.headers:type_name -> plugins.PluginHTTPRequest.HeadersEntry
+	117, // 46: plugins.PluginHTTPResponse.headers:type_name -> plugins.PluginHTTPResponse.HeadersEntry
+	118, // 47: plugins.AddonTypeRequest.source_info:type_name -> plugins.AddonTypeRequest.SourceInfoEntry
+	119, // 48: plugins.AddonTypeRequest.server_variables:type_name -> plugins.AddonTypeRequest.ServerVariablesEntry
+	109, // 49: plugins.AddonTypeResponse.actions:type_name -> plugins.AddonInstallAction
 	1,   // 50: plugins.AddonInstallAction.type:type_name -> plugins.AddonInstallAction.ActionType
-	119, // 51: plugins.AddonInstallAction.headers:type_name -> plugins.AddonInstallAction.HeadersEntry
+	120, // 51: plugins.AddonInstallAction.headers:type_name -> plugins.AddonInstallAction.HeadersEntry
 	4,   // 52: plugins.PluginService.GetInfo:input_type -> plugins.Empty
 	22,  // 53: plugins.PluginService.OnEvent:input_type -> plugins.Event
 	24,  // 54: plugins.PluginService.OnHTTP:input_type -> plugins.HTTPRequest
@@ -8209,113 +8285,115 @@ var file_plugin_proto_depIdxs = []int32{
 	4,   // 134: plugins.PanelService.GetSettings:input_type -> plugins.Empty
 	8,   // 135: plugins.PanelService.SetRegistrationEnabled:input_type -> plugins.BoolRequest
 	8,   // 136: plugins.PanelService.SetServerCreationEnabled:input_type -> plugins.BoolRequest
-	91,  // 137: plugins.PanelService.GetActivityLogs:input_type -> plugins.GetLogsRequest
-	93,  // 138: plugins.PanelService.Log:input_type -> plugins.LogRequest
-	94,  // 139: plugins.PanelService.GetKV:input_type -> plugins.KVRequest
-	96,  // 140: plugins.PanelService.SetKV:input_type -> plugins.KVSetRequest
-	94,  // 141: plugins.PanelService.DeleteKV:input_type -> plugins.KVRequest
-	97,  // 142: plugins.PanelService.QueryDB:input_type -> plugins.QueryDBRequest
-	99,  // 143: plugins.PanelService.BroadcastEvent:input_type -> plugins.BroadcastEventRequest
-	100, // 144: plugins.PanelService.SendNotification:input_type -> plugins.NotificationRequest
-	101, // 145: plugins.PanelService.HTTPRequest:input_type -> plugins.PluginHTTPRequest
-	103, // 146: plugins.PanelService.CallPlugin:input_type -> plugins.CallPluginRequest
-	9,   // 147: plugins.PluginService.GetInfo:output_type -> plugins.PluginInfo
-	23,  // 148: plugins.PluginService.OnEvent:output_type -> plugins.EventResponse
-	25,  // 149: plugins.PluginService.OnHTTP:output_type -> plugins.HTTPResponse
-	4,   // 150: plugins.PluginService.OnSchedule:output_type -> plugins.Empty
-	17,  // 151: plugins.PluginService.OnMixin:output_type -> plugins.MixinResponse
-	4,   // 152: plugins.PluginService.Shutdown:output_type -> plugins.Empty
-	3,   // 153: plugins.PanelService.Connect:output_type -> plugins.PanelMessage
-	27,  // 154: plugins.PanelService.GetServer:output_type -> plugins.Server
-	29,  // 155: plugins.PanelService.ListServers:output_type -> plugins.ListServersResponse
-	27,  // 156: plugins.PanelService.CreateServer:output_type -> plugins.Server
-	4,   // 157: plugins.PanelService.DeleteServer:output_type -> plugins.Empty
-	27,  // 158: plugins.PanelService.UpdateServer:output_type -> plugins.Server
-	4,   // 159: plugins.PanelService.SuspendServer:output_type -> plugins.Empty
-	4,   // 160: plugins.PanelService.UnsuspendServer:output_type -> plugins.Empty
-	4,   // 161: plugins.PanelService.StartServer:output_type -> plugins.Empty
-	4,   // 162: plugins.PanelService.StopServer:output_type -> plugins.Empty
-	4,   // 163: plugins.PanelService.RestartServer:output_type -> plugins.Empty
-	4,   // 164: plugins.PanelService.KillServer:output_type -> plugins.Empty
-	4,   // 165: plugins.PanelService.ReinstallServer:output_type -> plugins.Empty
-	4,   // 166: plugins.PanelService.TransferServer:output_type -> plugins.Empty
-	34,  // 167: plugins.PanelService.GetConsoleLog:output_type -> plugins.ConsoleLogResponse
-	4,   // 168: plugins.PanelService.SendCommand:output_type -> plugins.Empty
-	41,  // 169: plugins.PanelService.StreamConsole:output_type -> plugins.ConsoleLine
-	42,  // 170: plugins.PanelService.GetFullLog:output_type -> plugins.FullLogResponse
-	44,  // 171: plugins.PanelService.SearchLogs:output_type -> plugins.SearchLogsResponse
-	46,  // 172: plugins.PanelService.ListLogFiles:output_type -> plugins.LogFilesResponse
-	42,  // 173: plugins.PanelService.ReadLogFile:output_type -> plugins.FullLogResponse
-	36,  // 174: plugins.PanelService.GetServerStats:output_type -> plugins.ServerStats
-	4,   // 175: plugins.PanelService.AddAllocation:output_type -> plugins.Empty
-	4,   // 176: plugins.PanelService.DeleteAllocation:output_type -> plugins.Empty
-	4,   // 177: plugins.PanelService.SetPrimaryAllocation:output_type -> plugins.Empty
-	4,   // 178: plugins.PanelService.UpdateServerVariables:output_type -> plugins.Empty
-	49,  // 179: plugins.PanelService.GetUser:output_type -> plugins.User
-	49,  // 180: plugins.PanelService.GetUserByEmail:output_type -> plugins.User
-	49,  // 181: plugins.PanelService.GetUserByUsername:output_type -> plugins.User
-	51,  // 182: plugins.PanelService.ListUsers:output_type -> plugins.ListUsersResponse
-	49,  // 183: plugins.PanelService.CreateUser:output_type -> plugins.User
-	4,   // 184: plugins.PanelService.DeleteUser:output_type -> plugins.Empty
-	49,  // 185: plugins.PanelService.UpdateUser:output_type -> plugins.User
-	4,   // 186: plugins.PanelService.BanUser:output_type -> plugins.Empty
-	4,   // 187: plugins.PanelService.UnbanUser:output_type -> plugins.Empty
-	4,   // 188: plugins.PanelService.SetAdmin:output_type -> plugins.Empty
-	4,   // 189: plugins.PanelService.RevokeAdmin:output_type -> plugins.Empty
-	4,   // 190: plugins.PanelService.SetUserResources:output_type -> plugins.Empty
-	4,   // 191: plugins.PanelService.ForcePasswordReset:output_type -> plugins.Empty
-	56,  // 192: plugins.PanelService.ListSubusers:output_type -> plugins.ListSubusersResponse
-	55,  // 193: plugins.PanelService.AddSubuser:output_type -> plugins.Subuser
-	4,   // 194: plugins.PanelService.UpdateSubuser:output_type -> plugins.Empty
-	4,   // 195: plugins.PanelService.RemoveSubuser:output_type -> plugins.Empty
-	61,  // 196: plugins.PanelService.ListDatabases:output_type -> plugins.ListDatabasesResponse
-	60,  // 197: plugins.PanelService.CreateDatabase:output_type -> plugins.Database
-	4,   // 198: plugins.PanelService.DeleteDatabase:output_type -> plugins.Empty
-	60,  // 199: plugins.PanelService.RotateDatabasePassword:output_type -> plugins.Database
-	64,  // 200: plugins.PanelService.ListDatabaseHosts:output_type -> plugins.ListDatabaseHostsResponse
-	63,  // 201: plugins.PanelService.CreateDatabaseHost:output_type -> plugins.DatabaseHost
-	4,   // 202: plugins.PanelService.UpdateDatabaseHost:output_type -> plugins.Empty
-	4,   // 203: plugins.PanelService.DeleteDatabaseHost:output_type -> plugins.Empty
-	68,  // 204: plugins.PanelService.ListFiles:output_type -> plugins.ListFilesResponse
-	70,  // 205: plugins.PanelService.ReadFile:output_type -> plugins.FileContent
-	4,   // 206: plugins.PanelService.WriteFile:output_type -> plugins.Empty
-	4,   // 207: plugins.PanelService.DeleteFile:output_type -> plugins.Empty
-	4,   // 208: plugins.PanelService.CreateFolder:output_type -> plugins.Empty
-	4,   // 209: plugins.PanelService.MoveFile:output_type -> plugins.Empty
-	4,   // 210: plugins.PanelService.CopyFile:output_type -> plugins.Empty
-	4,   // 211: plugins.PanelService.CompressFiles:output_type -> plugins.Empty
-	4,   // 212: plugins.PanelService.DecompressFile:output_type -> plugins.Empty
-	74,  // 213: plugins.PanelService.ListBackups:output_type -> plugins.ListBackupsResponse
-	4,   // 214: plugins.PanelService.CreateBackup:output_type -> plugins.Empty
-	4,   // 215: plugins.PanelService.DeleteBackup:output_type -> plugins.Empty
-	78,  // 216: plugins.PanelService.ListNodes:output_type -> plugins.ListNodesResponse
-	77,  // 217: plugins.PanelService.GetNode:output_type -> plugins.Node
-	80,  // 218: plugins.PanelService.CreateNode:output_type -> plugins.NodeWithToken
-	4,   // 219: plugins.PanelService.DeleteNode:output_type -> plugins.Empty
-	81,  // 220: plugins.PanelService.ResetNodeToken:output_type -> plugins.NodeToken
-	83,  // 221: plugins.PanelService.ListPackages:output_type -> plugins.ListPackagesResponse
-	82,  // 222: plugins.PanelService.GetPackage:output_type -> plugins.Package
-	82,  // 223: plugins.PanelService.CreatePackage:output_type -> plugins.Package
-	82,  // 224: plugins.PanelService.UpdatePackage:output_type -> plugins.Package
-	4,   // 225: plugins.PanelService.DeletePackage:output_type -> plugins.Empty
-	87,  // 226: plugins.PanelService.ListIPBans:output_type -> plugins.ListIPBansResponse
-	86,  // 227: plugins.PanelService.CreateIPBan:output_type -> plugins.IPBan
-	4,   // 228: plugins.PanelService.DeleteIPBan:output_type -> plugins.Empty
-	89,  // 229: plugins.PanelService.GetSettings:output_type -> plugins.Settings
-	4,   // 230: plugins.PanelService.SetRegistrationEnabled:output_type -> plugins.Empty
-	4,   // 231: plugins.PanelService.SetServerCreationEnabled:output_type -> plugins.Empty
-	92,  // 232: plugins.PanelService.GetActivityLogs:output_type -> plugins.GetLogsResponse
-	4,   // 233: plugins.PanelService.Log:output_type -> plugins.Empty
-	95,  // 234: plugins.PanelService.GetKV:output_type -> plugins.KVResponse
-	4,   // 235: plugins.PanelService.SetKV:output_type -> plugins.Empty
-	4,   // 236: plugins.PanelService.DeleteKV:output_type -> plugins.Empty
-	98,  // 237: plugins.PanelService.QueryDB:output_type -> plugins.QueryDBResponse
-	4,   // 238: plugins.PanelService.BroadcastEvent:output_type -> plugins.Empty
-	4,   // 239: plugins.PanelService.SendNotification:output_type -> plugins.Empty
-	102, // 240: plugins.PanelService.HTTPRequest:output_type -> plugins.PluginHTTPResponse
-	104, // 241: plugins.PanelService.CallPlugin:output_type -> plugins.CallPluginResponse
-	147, // [147:242] is the sub-list for method output_type
-	52,  // [52:147] is the sub-list for method input_type
+	5,   // 137: plugins.PanelService.RunSchedule:input_type -> plugins.IDRequest
+	92,  // 138: plugins.PanelService.GetActivityLogs:input_type -> plugins.GetLogsRequest
+	94,  // 139: plugins.PanelService.Log:input_type -> plugins.LogRequest
+	95,  // 140: plugins.PanelService.GetKV:input_type -> plugins.KVRequest
+	97,  // 141: plugins.PanelService.SetKV:input_type -> plugins.KVSetRequest
+	95,  // 142: plugins.PanelService.DeleteKV:input_type -> plugins.KVRequest
+	98,  // 143: plugins.PanelService.QueryDB:input_type -> plugins.QueryDBRequest
+	100, // 144: plugins.PanelService.BroadcastEvent:input_type -> plugins.BroadcastEventRequest
+	101, // 145: plugins.PanelService.SendNotification:input_type -> plugins.NotificationRequest
+	102, // 146: plugins.PanelService.HTTPRequest:input_type -> plugins.PluginHTTPRequest
+	104, // 147: plugins.PanelService.CallPlugin:input_type -> plugins.CallPluginRequest
+	9,   // 148: plugins.PluginService.GetInfo:output_type -> plugins.PluginInfo
+	23,  // 149: plugins.PluginService.OnEvent:output_type -> plugins.EventResponse
+	25,  // 150: plugins.PluginService.OnHTTP:output_type -> plugins.HTTPResponse
+	4,   // 151: plugins.PluginService.OnSchedule:output_type -> plugins.Empty
+	17,  // 152: plugins.PluginService.OnMixin:output_type -> plugins.MixinResponse
+	4,   // 153: plugins.PluginService.Shutdown:output_type -> plugins.Empty
+	3,   // 154: plugins.PanelService.Connect:output_type -> plugins.PanelMessage
+	27,  // 155: plugins.PanelService.GetServer:output_type -> plugins.Server
+	29,  // 156: plugins.PanelService.ListServers:output_type -> plugins.ListServersResponse
+	27,  // 157: plugins.PanelService.CreateServer:output_type -> plugins.Server
+	4,   // 158: plugins.PanelService.DeleteServer:output_type -> plugins.Empty
+	27,  // 159: plugins.PanelService.UpdateServer:output_type -> plugins.Server
+	4,   // 160: plugins.PanelService.SuspendServer:output_type -> plugins.Empty
+	4,   // 161: plugins.PanelService.UnsuspendServer:output_type -> plugins.Empty
+	4,   // 162: plugins.PanelService.StartServer:output_type -> plugins.Empty
+	4,   // 163: plugins.PanelService.StopServer:output_type -> plugins.Empty
+	4,   // 164: plugins.PanelService.RestartServer:output_type -> plugins.Empty
+	4,   // 165: plugins.PanelService.KillServer:output_type -> plugins.Empty
+	4,   // 166: plugins.PanelService.ReinstallServer:output_type -> plugins.Empty
+	4,   // 167: plugins.PanelService.TransferServer:output_type -> plugins.Empty
+	34,  // 168: plugins.PanelService.GetConsoleLog:output_type -> plugins.ConsoleLogResponse
+	4,   // 169: plugins.PanelService.SendCommand:output_type -> plugins.Empty
+	41,  // 170: plugins.PanelService.StreamConsole:output_type -> plugins.ConsoleLine
+	42,  // 171: plugins.PanelService.GetFullLog:output_type -> plugins.FullLogResponse
+	44,  // 172: plugins.PanelService.SearchLogs:output_type -> plugins.SearchLogsResponse
+	46,  // 173: plugins.PanelService.ListLogFiles:output_type -> plugins.LogFilesResponse
+	42,  // 174: plugins.PanelService.ReadLogFile:output_type -> plugins.FullLogResponse
+	36,  // 175: plugins.PanelService.GetServerStats:output_type -> plugins.ServerStats
+	4,   // 176: plugins.PanelService.AddAllocation:output_type -> plugins.Empty
+	4,   // 177: plugins.PanelService.DeleteAllocation:output_type -> plugins.Empty
+	4,   // 178: plugins.PanelService.SetPrimaryAllocation:output_type -> plugins.Empty
+	4,   // 179: plugins.PanelService.UpdateServerVariables:output_type -> plugins.Empty
+	49,  // 180: plugins.PanelService.GetUser:output_type -> plugins.User
+	49,  // 181: plugins.PanelService.GetUserByEmail:output_type -> plugins.User
+	49,  // 182: plugins.PanelService.GetUserByUsername:output_type -> plugins.User
+	51,  // 183: plugins.PanelService.ListUsers:output_type -> plugins.ListUsersResponse
+	49,  // 184: plugins.PanelService.CreateUser:output_type -> plugins.User
+	4,   // 185: plugins.PanelService.DeleteUser:output_type -> plugins.Empty
+	49,  // 186: plugins.PanelService.UpdateUser:output_type -> plugins.User
+	4,   // 187: plugins.PanelService.BanUser:output_type -> plugins.Empty
+	4,   // 188: plugins.PanelService.UnbanUser:output_type -> plugins.Empty
+	4,   // 189: plugins.PanelService.SetAdmin:output_type -> plugins.Empty
+	4,   // 190: plugins.PanelService.RevokeAdmin:output_type -> plugins.Empty
+	4,   // 191: plugins.PanelService.SetUserResources:output_type -> plugins.Empty
+	4,   // 192: plugins.PanelService.ForcePasswordReset:output_type -> plugins.Empty
+	56,  // 193: plugins.PanelService.ListSubusers:output_type -> plugins.ListSubusersResponse
+	55,  // 194: plugins.PanelService.AddSubuser:output_type -> plugins.Subuser
+	4,   // 195: plugins.PanelService.UpdateSubuser:output_type -> plugins.Empty
+	4,   // 196: plugins.PanelService.RemoveSubuser:output_type -> plugins.Empty
+	61,  // 197: plugins.PanelService.ListDatabases:output_type -> plugins.ListDatabasesResponse
+	60,  // 198: plugins.PanelService.CreateDatabase:output_type -> plugins.Database
+	4,   // 199: plugins.PanelService.DeleteDatabase:output_type -> plugins.Empty
+	60,  // 200: plugins.PanelService.RotateDatabasePassword:output_type -> plugins.Database
+	64,  // 201: plugins.PanelService.ListDatabaseHosts:output_type -> plugins.ListDatabaseHostsResponse
+	63,  // 202: plugins.PanelService.CreateDatabaseHost:output_type -> plugins.DatabaseHost
+	4,   // 203: plugins.PanelService.UpdateDatabaseHost:output_type -> plugins.Empty
+	4,   // 204: plugins.PanelService.DeleteDatabaseHost:output_type -> plugins.Empty
+	68,  // 205: plugins.PanelService.ListFiles:output_type -> plugins.ListFilesResponse
+	70,  // 206: plugins.PanelService.ReadFile:output_type -> plugins.FileContent
+	4,   // 207: plugins.PanelService.WriteFile:output_type -> plugins.Empty
+	4,   // 208: plugins.PanelService.DeleteFile:output_type -> plugins.Empty
+	4,   // 209: plugins.PanelService.CreateFolder:output_type -> plugins.Empty
+	4,   // 210: plugins.PanelService.MoveFile:output_type -> plugins.Empty
+	4,   // 211: plugins.PanelService.CopyFile:output_type -> plugins.Empty
+	4,   // 212: plugins.PanelService.CompressFiles:output_type -> plugins.Empty
+	4,   // 213: plugins.PanelService.DecompressFile:output_type -> plugins.Empty
+	74,  // 214: plugins.PanelService.ListBackups:output_type -> plugins.ListBackupsResponse
+	4,   // 215: plugins.PanelService.CreateBackup:output_type -> plugins.Empty
+	4,   // 216: plugins.PanelService.DeleteBackup:output_type -> plugins.Empty
+	78,  // 217: plugins.PanelService.ListNodes:output_type -> plugins.ListNodesResponse
+	77,  // 218: plugins.PanelService.GetNode:output_type -> plugins.Node
+	80,  // 219: plugins.PanelService.CreateNode:output_type -> plugins.NodeWithToken
+	4,   // 220: plugins.PanelService.DeleteNode:output_type -> plugins.Empty
+	81,  // 221: plugins.PanelService.ResetNodeToken:output_type -> plugins.NodeToken
+	83,  // 222: plugins.PanelService.ListPackages:output_type -> plugins.ListPackagesResponse
+	82,  // 223: plugins.PanelService.GetPackage:output_type -> plugins.Package
+	82,  // 224: plugins.PanelService.CreatePackage:output_type -> plugins.Package
+	82,  // 225: plugins.PanelService.UpdatePackage:output_type -> plugins.Package
+	4,   // 226: plugins.PanelService.DeletePackage:output_type -> plugins.Empty
+	87,  // 227: plugins.PanelService.ListIPBans:output_type -> plugins.ListIPBansResponse
+	86,  // 228: plugins.PanelService.CreateIPBan:output_type -> plugins.IPBan
+	4,   // 229: plugins.PanelService.DeleteIPBan:output_type -> plugins.Empty
+	89,  // 230: plugins.PanelService.GetSettings:output_type -> plugins.Settings
+	4,   // 231: plugins.PanelService.SetRegistrationEnabled:output_type -> plugins.Empty
+	4,   // 232: plugins.PanelService.SetServerCreationEnabled:output_type -> plugins.Empty
+	90,  // 233: plugins.PanelService.RunSchedule:output_type -> plugins.ScheduleRunResponse
+	93,  // 234: plugins.PanelService.GetActivityLogs:output_type -> plugins.GetLogsResponse
+	4,   // 235: plugins.PanelService.Log:output_type -> plugins.Empty
+	96,  // 236: plugins.PanelService.GetKV:output_type -> plugins.KVResponse
+	4,   // 237: plugins.PanelService.SetKV:output_type -> plugins.Empty
+	4,   // 238: plugins.PanelService.DeleteKV:output_type -> plugins.Empty
+	99,  // 239: plugins.PanelService.QueryDB:output_type -> plugins.QueryDBResponse
+	4,   // 240: plugins.PanelService.BroadcastEvent:output_type -> plugins.Empty
+	4,   // 241: plugins.PanelService.SendNotification:output_type -> plugins.Empty
+	103, // 242: plugins.PanelService.HTTPRequest:output_type -> plugins.PluginHTTPResponse
+	105, // 243: plugins.PanelService.CallPlugin:output_type -> plugins.CallPluginResponse
+	148, // [148:244] is the sub-list for method output_type
+	52,  // [52:148] is the sub-list for method input_type
 	52,  // [52:52] is the sub-list for extension type_name
 	52,  // [52:52] is the sub-list for extension extendee
 	0,   // [0:52] is the sub-list for field type_name
@@ -8349,7 +8427,7 @@ func file_plugin_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_plugin_proto_rawDesc), len(file_plugin_proto_rawDesc)),
 			NumEnums:      2,
-			NumMessages:   118,
+			NumMessages:   119,
 			NumExtensions: 0,
 			NumServices:   2,
 		},
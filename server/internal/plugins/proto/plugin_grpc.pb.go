@@ -390,6 +390,7 @@ const (
 	PanelService_GetSettings_FullMethodName              = "/plugins.PanelService/GetSettings"
 	PanelService_SetRegistrationEnabled_FullMethodName   = "/plugins.PanelService/SetRegistrationEnabled"
 	PanelService_SetServerCreationEnabled_FullMethodName = "/plugins.PanelService/SetServerCreationEnabled"
+	PanelService_RunSchedule_FullMethodName              = "/plugins.PanelService/RunSchedule"
 	PanelService_GetActivityLogs_FullMethodName          = "/plugins.PanelService/GetActivityLogs"
 	PanelService_Log_FullMethodName                      = "/plugins.PanelService/Log"
 	PanelService_GetKV_FullMethodName                    = "/plugins.PanelService/GetKV"
@@ -500,6 +501,8 @@ type PanelServiceClient interface {
 	GetSettings(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Settings, error)
 	SetRegistrationEnabled(ctx context.Context, in *BoolRequest, opts ...grpc.CallOption) (*Empty, error)
 	SetServerCreationEnabled(ctx context.Context, in *BoolRequest, opts ...grpc.CallOption) (*Empty, error)
+	// Schedules
+	RunSchedule(ctx context.Context, in *IDRequest, opts ...grpc.CallOption) (*ScheduleRunResponse, error)
 	// Activity Logs
 	GetActivityLogs(ctx context.Context, in *GetLogsRequest, opts ...grpc.CallOption) (*GetLogsResponse, error)
 	// Utility
@@ -1326,6 +1329,16 @@ func (c *panelServiceClient) SetServerCreationEnabled(ctx context.Context, in *B
 	return out, nil
 }
 
+func (c *panelServiceClient) RunSchedule(ctx context.Context, in *IDRequest, opts ...grpc.CallOption) (*ScheduleRunResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ScheduleRunResponse)
+	err := c.cc.Invoke(ctx, PanelService_RunSchedule_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *panelServiceClient) GetActivityLogs(ctx context.Context, in *GetLogsRequest, opts ...grpc.CallOption) (*GetLogsResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(GetLogsResponse)
@@ -1524,6 +1537,8 @@ type PanelServiceServer interface {
 	GetSettings(context.Context, *Empty) (*Settings, error)
 	SetRegistrationEnabled(context.Context, *BoolRequest) (*Empty, error)
 	SetServerCreationEnabled(context.Context, *BoolRequest) (*Empty, error)
+	// Schedules
+	RunSchedule(context.Context, *IDRequest) (*ScheduleRunResponse, error)
 	// Activity Logs
 	GetActivityLogs(context.Context, *GetLogsRequest) (*GetLogsResponse, error)
 	// Utility
@@ -1785,6 +1800,9 @@ func (UnimplementedPanelServiceServer) SetRegistrationEnabled(context.Context, *
 func (UnimplementedPanelServiceServer) SetServerCreationEnabled(context.Context, *BoolRequest) (*Empty, error) {
 	return nil, status.Error(codes.Unimplemented, "method SetServerCreationEnabled not implemented")
 }
+func (UnimplementedPanelServiceServer) RunSchedule(context.Context, *IDRequest) (*ScheduleRunResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method RunSchedule not implemented")
+}
 func (UnimplementedPanelServiceServer) GetActivityLogs(context.Context, *GetLogsRequest) (*GetLogsResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method GetActivityLogs not implemented")
 }
@@ -3240,6 +3258,24 @@ func _PanelService_SetServerCreationEnabled_Handler(srv interface{}, ctx context
 	return interceptor(ctx, in, info, handler)
 }
 
+func _PanelService_RunSchedule_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IDRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PanelServiceServer).RunSchedule(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PanelService_RunSchedule_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PanelServiceServer).RunSchedule(ctx, req.(*IDRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _PanelService_GetActivityLogs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(GetLogsRequest)
 	if err := dec(in); err != nil {
@@ -3735,6 +3771,10 @@ var PanelService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "SetServerCreationEnabled",
 			Handler:    _PanelService_SetServerCreationEnabled_Handler,
 		},
+		{
+			MethodName: "RunSchedule",
+			Handler:    _PanelService_RunSchedule_Handler,
+		},
 		{
 			MethodName: "GetActivityLogs",
 			Handler:    _PanelService_GetActivityLogs_Handler,
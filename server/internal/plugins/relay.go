@@ -0,0 +1,71 @@
+package plugins
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+
+	pb "birdactyl-panel-backend/internal/plugins/proto"
+	"birdactyl-panel-backend/internal/state"
+)
+
+const eventRelayChannel = "plugins:events"
+
+// relayedEvent carries an async event to the stream plugins connected to
+// other panel instances.
+type relayedEvent struct {
+	Origin    string            `json:"origin"`
+	Type      string            `json:"type"`
+	Timestamp string            `json:"timestamp"`
+	Data      map[string]string `json:"data"`
+}
+
+var eventRelay *state.Subscription
+
+func StartEventRelay() {
+	if !state.Shared() {
+		return
+	}
+	eventRelay = state.Subscribe(eventRelayChannel)
+	go func(sub *state.Subscription) {
+		for msg := range sub.C {
+			var re relayedEvent
+			if json.Unmarshal(msg, &re) != nil || re.Origin == state.InstanceID() {
+				continue
+			}
+			var streamPlugins []*PluginStream
+			for _, ps := range GetStreamRegistry().All() {
+				if StreamPluginHasEvent(ps.ID, re.Type) {
+					streamPlugins = append(streamPlugins, ps)
+				}
+			}
+			if len(streamPlugins) > 0 {
+				emitAsync(nil, streamPlugins, &pb.Event{Type: re.Type, Timestamp: re.Timestamp, Data: re.Data})
+			}
+		}
+	}(eventRelay)
+	log.Println("[plugins] event relay started")
+}
+
+func StopEventRelay() {
+	if eventRelay != nil {
+		eventRelay.Close()
+	}
+}
+
+func relayEvent(ev *pb.Event) {
+	if !state.Shared() {
+		return
+	}
+	data, err := json.Marshal(relayedEvent{Origin: state.InstanceID(), Type: ev.Type, Timestamp: ev.Timestamp, Data: ev.Data})
+	if err != nil {
+		return
+	}
+	if err := state.Publish(eventRelayChannel, data); err != nil {
+		if errors.Is(err, state.ErrPayloadTooLarge) {
+			log.Printf("[plugins] event %s too large to relay to other instances", ev.Type)
+			return
+		}
+		log.Printf("[plugins] failed to relay event %s: %v", ev.Type, err)
+	}
+}
@@ -105,10 +105,12 @@ const (
 	EventServerRestarting EventType = "server.restarting"
 	EventServerKilling    EventType = "server.killing"
 	EventServerReinstall  EventType = "server.reinstalling"
+	EventServerInstalled  EventType = "server.installed"
 	EventServerSuspended  EventType = "server.suspended"
 	EventServerUnsuspended EventType = "server.unsuspended"
 	EventServerUpdated    EventType = "server.updated"
 	EventServerTransferred EventType = "server.transferred"
+	EventServerStateChanged EventType = "server.state_changed"
 
 	EventUserRegistering EventType = "user.registering"
 	EventUserRegistered  EventType = "user.registered"
@@ -85,10 +85,24 @@ func SetupRoutes(app *fiber.App) {
 	adminRoutes.Patch("/nodes/:id", writeLimit, handlers.AdminUpdateNode)
 	adminRoutes.Delete("/nodes/:id", strictLimit, handlers.AdminDeleteNode)
 	adminRoutes.Post("/nodes/:id/reset-token", strictLimit, handlers.AdminResetNodeToken)
+	adminRoutes.Get("/nodes/:id/drift", readLimit, handlers.AdminGetNodeDrift)
+	adminRoutes.Post("/nodes/:id/sync", writeLimit, handlers.AdminSyncNode)
+	adminRoutes.Post("/nodes/:id/sync/apply", strictLimit, handlers.AdminResolveNodeDrift)
+	adminRoutes.Get("/nodes/:id/allocations", readLimit, handlers.AdminGetNodeAllocations)
+	adminRoutes.Post("/nodes/:id/allocations", writeLimit, handlers.AdminCreateNodeAllocations)
+	adminRoutes.Delete("/nodes/:id/allocations", strictLimit, handlers.AdminDeleteNodeAllocationRange)
+	adminRoutes.Patch("/nodes/:id/allocations/:allocationId", writeLimit, handlers.AdminUpdateNodeAllocation)
+	adminRoutes.Delete("/nodes/:id/allocations/:allocationId", writeLimit, handlers.AdminDeleteNodeAllocation)
 
 	adminRoutes.Get("/packages", readLimit, handlers.AdminGetPackages)
 	adminRoutes.Post("/packages", strictLimit, handlers.AdminCreatePackage)
+	adminRoutes.Post("/packages/import/egg", strictLimit, handlers.AdminImportEgg)
 	adminRoutes.Get("/packages/:id", readLimit, handlers.AdminGetPackage)
+	adminRoutes.Get("/packages/:id/export", readLimit, handlers.AdminExportPackage)
+	adminRoutes.Get("/packages/:id/revisions", readLimit, handlers.AdminListPackageRevisions)
+	adminRoutes.Get("/packages/:id/revisions/diff", readLimit, handlers.AdminDiffPackageRevisions)
+	adminRoutes.Get("/packages/:id/rollout", readLimit, handlers.AdminGetPackageRollout)
+	adminRoutes.Post("/packages/:id/rollout", strictLimit, handlers.AdminRolloutPackage)
 	adminRoutes.Patch("/packages/:id", writeLimit, handlers.AdminUpdatePackage)
 	adminRoutes.Delete("/packages/:id", strictLimit, handlers.AdminDeletePackage)
 
@@ -104,6 +118,8 @@ func SetupRoutes(app *fiber.App) {
 	adminRoutes.Get("/transfers/:transferId", readLimit, server.AdminGetTransferStatus)
 
 	adminRoutes.Get("/logs", readLimit, admin.AdminGetLogs)
+	adminRoutes.Get("/logs/:id/attachments", readLimit, admin.AdminGetLogAttachments)
+	adminRoutes.Get("/logs/attachments/:attachmentId", readLimit, admin.AdminDownloadLogAttachment)
 
 	adminRoutes.Get("/ip-bans", readLimit, admin.AdminGetIPBans)
 	adminRoutes.Post("/ip-bans", strictLimit, admin.AdminCreateIPBan)
@@ -143,15 +159,25 @@ func SetupRoutes(app *fiber.App) {
 		return fiber.ErrUpgradeRequired
 	}, middleware.WebSocketAuth())
 	api.Get("/servers/:id/logs", websocket.New(handlers.ServerLogsWS))
+	api.Use("/servers/:id/shell", func(c *fiber.Ctx) error {
+		if websocket.IsWebSocketUpgrade(c) {
+			return c.Next()
+		}
+		return fiber.ErrUpgradeRequired
+	}, middleware.WebSocketAuth())
+	api.Get("/servers/:id/shell", websocket.New(handlers.ServerShellWS))
 
 	servers := api.Group("/servers", middleware.RequireAuth())
 	servers.Get("/", readLimit, server.GetServers)
 	servers.Post("/", strictLimit, server.CreateServer)
+	servers.Post("/:id/clone", strictLimit, server.CloneServer)
 	servers.Get("/:id", readLimit, server.GetServer)
 	servers.Post("/:id/start", writeLimit, server.StartServer)
 	servers.Post("/:id/stop", writeLimit, server.StopServer)
 	servers.Post("/:id/restart", writeLimit, server.RestartServer)
 	servers.Post("/:id/reinstall", writeLimit, server.ReinstallServer)
+	servers.Get("/:id/install", readLimit, server.GetInstallLog)
+	servers.Post("/:id/install/retry", strictLimit, server.RetryInstall)
 	servers.Post("/:id/kill", writeLimit, server.KillServer)
 	servers.Post("/:id/command", writeLimit, server.SendCommand)
 	servers.Get("/:id/status", readLimit, server.GetServerStatus)
@@ -183,6 +209,9 @@ func SetupRoutes(app *fiber.App) {
 	servers.Post("/:id/files/bulk-delete", writeLimit, server.BulkDeleteFiles)
 	servers.Post("/:id/files/bulk-copy", writeLimit, server.BulkCopyFiles)
 	servers.Post("/:id/files/bulk-compress", strictLimit, server.BulkCompressFiles)
+	servers.Get("/:id/files/trash", readLimit, server.ListTrash)
+	servers.Post("/:id/files/trash/restore", writeLimit, server.RestoreTrash)
+	servers.Post("/:id/files/trash/purge", strictLimit, server.PurgeTrash)
 	servers.Get("/:id/permissions", readLimit, handlers.GetMyPermissions)
 	servers.Get("/:id/subusers", readLimit, handlers.GetSubusers)
 	servers.Post("/:id/subusers", writeLimit, handlers.AddSubuser)
@@ -208,6 +237,7 @@ func SetupRoutes(app *fiber.App) {
 	servers.Put("/:id/schedules/:scheduleId", writeLimit, handlers.UpdateSchedule)
 	servers.Delete("/:id/schedules/:scheduleId", writeLimit, handlers.DeleteSchedule)
 	servers.Post("/:id/schedules/:scheduleId/run", writeLimit, handlers.RunScheduleNow)
+	servers.Get("/:id/schedules/:scheduleId/runs", readLimit, handlers.GetScheduleRuns)
 	servers.Get("/:id/activity", readLimit, server.GetServerActivity)
 	servers.Get("/:id/sftp", readLimit, server.GetSFTPDetails)
 	servers.Post("/:id/sftp/password", writeLimit, server.ResetSFTPPassword)
@@ -215,6 +245,10 @@ func SetupRoutes(app *fiber.App) {
 	internal := api.Group("/internal")
 	nodes := internal.Group("/nodes", middleware.RequireNodeAuth())
 	nodes.Post("/heartbeat", handlers.NodeHeartbeat)
+	nodes.Post("/reconcile", handlers.NodeReconcileReport)
+
+	internal.Post("/servers/:id/install", middleware.RequireNodeAuth(), handlers.NodeInstallFinished)
+	internal.Post("/servers/:id/state", middleware.RequireNodeAuth(), handlers.NodeServerState)
 
 	internal.Post("/sftp/auth", middleware.RequireNodeAuth(), handlers.ValidateSFTPAuth)
 }
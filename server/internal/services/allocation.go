@@ -0,0 +1,259 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+
+	"birdactyl-panel-backend/internal/database"
+	"birdactyl-panel-backend/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	defaultAllocationStart = 25565
+	defaultAllocationEnd   = 30000
+	maxAllocationRange     = 10000
+	allocationBatchSize    = 500
+	allocationClaimRetries = 3
+	allocationsBackfilled  = "allocations_backfilled"
+)
+
+var (
+	ErrAllocationNotFound = errors.New("allocation not found")
+	ErrAllocationAssigned = errors.New("allocation is assigned to a server")
+	ErrNoFreeAllocation   = errors.New("no free allocation on this node")
+)
+
+func GetNodeAllocations(nodeID uuid.UUID) ([]models.Allocation, error) {
+	allocations := []models.Allocation{}
+	err := database.DB.Where("node_id = ?", nodeID).Order("ip, port").Find(&allocations).Error
+	return allocations, err
+}
+
+// CreateAllocations adds the ports start to end on ip to the node's pool.
+// Ports the node already offers on that IP are skipped, and so are ports
+// that would clash with an allocation on all interfaces, since Docker
+// cannot bind both. It returns how many allocations were added.
+func CreateAllocations(nodeID uuid.UUID, ip string, start, end int, alias string) (int, error) {
+	var node models.Node
+	if err := database.DB.Where("id = ?", nodeID).First(&node).Error; err != nil {
+		return 0, ErrNodeNotFound
+	}
+
+	if ip == "" {
+		ip = models.AllocationAnyIP
+	}
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return 0, fmt.Errorf("invalid IP address")
+	}
+	ip = parsed.String()
+
+	if end == 0 {
+		end = start
+	}
+	if start < 1 || end > 65535 || start > end {
+		return 0, fmt.Errorf("ports must be a range between 1 and 65535")
+	}
+	if end-start+1 > maxAllocationRange {
+		return 0, fmt.Errorf("a range can hold at most %d ports", maxAllocationRange)
+	}
+
+	var existing []models.Allocation
+	database.DB.Where("node_id = ? AND port BETWEEN ? AND ?", nodeID, start, end).Find(&existing)
+	taken := make(map[int]bool, len(existing))
+	for _, a := range existing {
+		if a.IP == ip || a.IP == models.AllocationAnyIP || ip == models.AllocationAnyIP {
+			taken[a.Port] = true
+		}
+	}
+
+	var rows []models.Allocation
+	for port := start; port <= end; port++ {
+		if !taken[port] {
+			rows = append(rows, models.Allocation{NodeID: nodeID, IP: ip, Port: port, Alias: alias})
+		}
+	}
+	if len(rows) == 0 {
+		return 0, nil
+	}
+	result := database.DB.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(&rows, allocationBatchSize)
+	return int(result.RowsAffected), result.Error
+}
+
+func UpdateAllocationAlias(nodeID, id uuid.UUID, alias string) (*models.Allocation, error) {
+	var a models.Allocation
+	if err := database.DB.Where("id = ? AND node_id = ?", id, nodeID).First(&a).Error; err != nil {
+		return nil, ErrAllocationNotFound
+	}
+	if err := database.DB.Model(&a).Update("alias", alias).Error; err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+func DeleteNodeAllocation(nodeID, id uuid.UUID) error {
+	result := database.DB.Where("id = ? AND node_id = ? AND server_id IS NULL", id, nodeID).Delete(&models.Allocation{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		var count int64
+		database.DB.Model(&models.Allocation{}).Where("id = ? AND node_id = ?", id, nodeID).Count(&count)
+		if count > 0 {
+			return ErrAllocationAssigned
+		}
+		return ErrAllocationNotFound
+	}
+	return nil
+}
+
+// DeleteAllocationRange removes the free allocations between start and end,
+// on ip or on every IP when ip is empty. Assigned ones are kept.
+func DeleteAllocationRange(nodeID uuid.UUID, ip string, start, end int) (int, error) {
+	if end == 0 {
+		end = start
+	}
+	query := database.DB.Where("node_id = ? AND server_id IS NULL AND port BETWEEN ? AND ?", nodeID, start, end)
+	if ip != "" {
+		query = query.Where("ip = ?", ip)
+	}
+	result := query.Delete(&models.Allocation{})
+	return int(result.RowsAffected), result.Error
+}
+
+// CountFreeAllocations returns how many allocations of the node no server
+// holds.
+func CountFreeAllocations(nodeID uuid.UUID) int {
+	var count int64
+	database.DB.Model(&models.Allocation{}).Where("node_id = ? AND server_id IS NULL", nodeID).Count(&count)
+	return int(count)
+}
+
+// claimAllocation hands a free allocation of the node to the server,
+// preferring ip when given and skipping ports the server already holds. A
+// claim only matches while the row is still free, so two claims, even from
+// different panel instances, never get the same allocation.
+func claimAllocation(tx *gorm.DB, nodeID, serverID uuid.UUID, ip string, skip []int) (*models.Allocation, error) {
+	tiers := []string{""}
+	if ip != "" {
+		tiers = []string{ip, ""}
+	}
+	for _, tierIP := range tiers {
+		for attempt := 0; attempt < allocationClaimRetries; attempt++ {
+			query := tx.Where("node_id = ? AND server_id IS NULL", nodeID)
+			if tierIP != "" {
+				query = query.Where("ip = ?", tierIP)
+			}
+			if len(skip) > 0 {
+				query = query.Where("port NOT IN ?", skip)
+			}
+			var free []models.Allocation
+			if err := query.Order("port").Limit(20).Find(&free).Error; err != nil {
+				return nil, err
+			}
+			if len(free) == 0 {
+				break
+			}
+			for i := range free {
+				result := tx.Model(&models.Allocation{}).
+					Where("id = ? AND server_id IS NULL", free[i].ID).
+					Update("server_id", serverID)
+				if result.Error != nil {
+					return nil, result.Error
+				}
+				if result.RowsAffected == 1 {
+					free[i].ServerID = &serverID
+					return &free[i], nil
+				}
+			}
+		}
+	}
+	return nil, ErrNoFreeAllocation
+}
+
+// assignAllocations claims one allocation on the node for each of ports,
+// keeping their primary flags. Later ports prefer the IP of the first.
+func assignAllocations(tx *gorm.DB, nodeID, serverID uuid.UUID, ports []models.ServerPort) ([]models.ServerPort, error) {
+	assigned := make([]models.ServerPort, 0, len(ports))
+	var ip string
+	var taken []int
+	for _, p := range ports {
+		a, err := claimAllocation(tx, nodeID, serverID, ip, taken)
+		if err != nil {
+			return nil, err
+		}
+		if ip == "" {
+			ip = a.IP
+		}
+		taken = append(taken, a.Port)
+		assigned = append(assigned, models.ServerPort{IP: a.IP, Port: a.Port, Primary: p.Primary})
+	}
+	return assigned, nil
+}
+
+func releaseAllocations(tx *gorm.DB, serverID uuid.UUID) error {
+	return tx.Model(&models.Allocation{}).Where("server_id = ?", serverID).Update("server_id", nil).Error
+}
+
+func seedDefaultAllocations(nodeID uuid.UUID) {
+	if _, err := CreateAllocations(nodeID, models.AllocationAnyIP, defaultAllocationStart, defaultAllocationEnd, ""); err != nil {
+		log.Printf("[allocations] failed to seed default allocations for node %s: %v", nodeID, err)
+	}
+}
+
+// BackfillAllocations runs once when upgrading to allocation pools. Every
+// node gets the range ports were picked from before, on all interfaces, and
+// the ports existing servers use are recorded as theirs.
+func BackfillAllocations() error {
+	if GetSetting(allocationsBackfilled) == "true" {
+		return nil
+	}
+
+	var nodes []models.Node
+	if err := database.DB.Find(&nodes).Error; err != nil {
+		return err
+	}
+	for _, node := range nodes {
+		var servers []models.Server
+		database.DB.Where("node_id = ?", node.ID).Find(&servers)
+
+		owners := make(map[int]uuid.UUID)
+		for _, s := range servers {
+			var ports []models.ServerPort
+			json.Unmarshal(s.Ports, &ports)
+			for _, p := range ports {
+				if other, dup := owners[p.Port]; dup {
+					log.Printf("[allocations] port %d on node %s is used by both %s and %s", p.Port, node.Name, other, s.ID)
+					continue
+				}
+				owners[p.Port] = s.ID
+			}
+		}
+
+		var rows []models.Allocation
+		for port := defaultAllocationStart; port <= defaultAllocationEnd; port++ {
+			rows = append(rows, models.Allocation{NodeID: node.ID, IP: models.AllocationAnyIP, Port: port})
+		}
+		for port := range owners {
+			if port < defaultAllocationStart || port > defaultAllocationEnd {
+				rows = append(rows, models.Allocation{NodeID: node.ID, IP: models.AllocationAnyIP, Port: port})
+			}
+		}
+		for i := range rows {
+			if id, ok := owners[rows[i].Port]; ok {
+				rows[i].ServerID = &id
+			}
+		}
+		if err := database.DB.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(&rows, allocationBatchSize).Error; err != nil {
+			return err
+		}
+	}
+	return SetSetting(allocationsBackfilled, "true")
+}
@@ -0,0 +1,95 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"birdactyl-panel-backend/internal/database"
+	"birdactyl-panel-backend/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var ErrCloneSourceBusy = errors.New("server has a transfer in progress")
+
+// CloneServer creates a copy of source on the given node with the same
+// package, variables and limits but its own allocations from the node's
+// pool, and has the node copy the files. The copy belongs to the source's
+// owner and stays installing until the node reports the file copy
+// finished. Files are copied on the node when both servers share it and
+// through the source's transfer archive otherwise.
+func CloneServer(source *models.Server, nodeID uuid.UUID, name string) (*models.Server, error) {
+	var node models.Node
+	if err := database.DB.Where("id = ?", nodeID).First(&node).Error; err != nil {
+		return nil, ErrNodeNotFound
+	}
+	if !node.IsOnline {
+		return nil, ErrNodeOffline
+	}
+
+	var sourceNode models.Node
+	if err := database.DB.Where("id = ?", source.NodeID).First(&sourceNode).Error; err != nil {
+		return nil, ErrNodeNotFound
+	}
+	if !sourceNode.IsOnline {
+		return nil, ErrNodeOffline
+	}
+
+	var active int64
+	database.DB.Model(&models.Transfer{}).
+		Where("server_id = ? AND stage NOT IN ?", source.ID, []string{models.TransferStageComplete, models.TransferStageFailed}).
+		Count(&active)
+	if active > 0 {
+		return nil, ErrCloneSourceBusy
+	}
+
+	if name == "" {
+		name = source.Name + " (copy)"
+	}
+
+	var ports []models.ServerPort
+	json.Unmarshal(source.Ports, &ports)
+
+	clone := &models.Server{
+		ID:              uuid.New(),
+		Name:            name,
+		Description:     source.Description,
+		UserID:          source.UserID,
+		NodeID:          nodeID,
+		PackageID:       source.PackageID,
+		PackageRevision: source.PackageRevision,
+		Status:          models.ServerStatusInstalling,
+		Memory:          source.Memory,
+		CPU:             source.CPU,
+		Disk:            source.Disk,
+		Startup:         source.Startup,
+		DockerImage:     source.DockerImage,
+		Variables:       source.Variables,
+	}
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		assigned, err := assignAllocations(tx, nodeID, clone.ID, ports)
+		if err != nil {
+			return err
+		}
+		clone.Ports, _ = json.Marshal(assigned)
+		return tx.Create(clone).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	body := map[string]interface{}{"source_id": source.ID.String()}
+	if nodeID != source.NodeID {
+		body["url"] = getNodeURL(&sourceNode) + fmt.Sprintf("/api/servers/%s", source.ID)
+		body["token"] = sourceNode.DaemonToken
+	}
+	if err := sendToNode(&node, "POST", fmt.Sprintf("/api/servers/%s/clone", clone.ID), body); err != nil {
+		deleteServerRow(clone.ID)
+		return nil, err
+	}
+
+	database.DB.Preload("Node").Preload("Package").First(clone, clone.ID)
+	return clone, nil
+}
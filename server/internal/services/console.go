@@ -0,0 +1,209 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"birdactyl-panel-backend/internal/state"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	consoleRelayTick  = 10 * time.Second
+	consoleLeaseTTL   = 30 * time.Second
+	consoleMaxLineLen = 4096
+)
+
+// consoleRelay fans one server's console out to the subscribers on this
+// instance. Across all instances watching the server, only the holder of
+// the server's console lease keeps a websocket to the node, and it
+// publishes each line for everyone else.
+type consoleRelay struct {
+	serverID uuid.UUID
+	subs     map[chan string]struct{}
+}
+
+var (
+	consoleRelays   = make(map[uuid.UUID]*consoleRelay)
+	consoleRelaysMu sync.Mutex
+)
+
+func consoleChannel(serverID uuid.UUID) string { return "console:" + serverID.String() }
+
+func consoleLeaseKey(serverID uuid.UUID) string { return "console:lease:" + serverID.String() }
+
+func consoleWatchPrefix(serverID uuid.UUID) string {
+	return "console:watch:" + serverID.String() + ":"
+}
+
+func SubscribeConsole(serverID uuid.UUID) chan string {
+	ch := make(chan string, 100)
+	consoleRelaysMu.Lock()
+	r := consoleRelays[serverID]
+	if r == nil {
+		r = &consoleRelay{serverID: serverID, subs: make(map[chan string]struct{})}
+		consoleRelays[serverID] = r
+		go r.run()
+	}
+	r.subs[ch] = struct{}{}
+	consoleRelaysMu.Unlock()
+	return ch
+}
+
+func UnsubscribeConsole(serverID uuid.UUID, ch chan string) {
+	consoleRelaysMu.Lock()
+	defer consoleRelaysMu.Unlock()
+	if r := consoleRelays[serverID]; r != nil {
+		if _, ok := r.subs[ch]; ok {
+			delete(r.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+func (r *consoleRelay) run() {
+	sub := state.Subscribe(consoleChannel(r.serverID))
+	defer sub.Close()
+
+	owner := state.InstanceID()
+	watchKey := consoleWatchPrefix(r.serverID) + owner
+	leaseKey := consoleLeaseKey(r.serverID)
+	var streamStop, streamDone chan struct{}
+	stopStream := func() {
+		if streamStop != nil {
+			close(streamStop)
+			streamStop, streamDone = nil, nil
+		}
+	}
+	defer func() {
+		stopStream()
+		state.Release(leaseKey, owner)
+		state.Delete(watchKey)
+	}()
+
+	maintain := func() bool {
+		if !r.keepAlive(watchKey, streamStop != nil) {
+			return false
+		}
+		held, err := state.Acquire(leaseKey, owner, consoleLeaseTTL)
+		if err != nil {
+			log.Printf("[console] lease for %s: %v", r.serverID, err)
+		}
+		if held && streamStop == nil {
+			streamStop, streamDone = make(chan struct{}), make(chan struct{})
+			go streamFromNode(r.serverID, streamStop, streamDone)
+		} else if !held {
+			stopStream()
+		}
+		return true
+	}
+
+	ticker := time.NewTicker(consoleRelayTick)
+	defer ticker.Stop()
+	if !maintain() {
+		return
+	}
+	for {
+		select {
+		case msg, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			r.deliver(string(msg))
+		case <-streamDone:
+			stopStream()
+			state.Release(leaseKey, owner)
+		case <-ticker.C:
+			if !maintain() {
+				return
+			}
+		}
+	}
+}
+
+// keepAlive refreshes this instance's interest in the console and decides
+// whether the relay should keep going. A relay without local subscribers
+// keeps streaming while other instances are still watching.
+func (r *consoleRelay) keepAlive(watchKey string, streaming bool) bool {
+	consoleRelaysMu.Lock()
+	local := len(r.subs)
+	consoleRelaysMu.Unlock()
+
+	if local > 0 {
+		state.Set(watchKey, nil, consoleLeaseTTL)
+		return true
+	}
+	state.Delete(watchKey)
+	if streaming {
+		if watchers, err := state.List(consoleWatchPrefix(r.serverID)); err == nil && len(watchers) > 0 {
+			return true
+		}
+	}
+
+	consoleRelaysMu.Lock()
+	defer consoleRelaysMu.Unlock()
+	if len(r.subs) > 0 {
+		return true
+	}
+	delete(consoleRelays, r.serverID)
+	return false
+}
+
+func (r *consoleRelay) deliver(line string) {
+	consoleRelaysMu.Lock()
+	defer consoleRelaysMu.Unlock()
+	for ch := range r.subs {
+		select {
+		case ch <- line:
+		default:
+		}
+	}
+}
+
+// streamFromNode publishes the server's console lines until stop is closed
+// or the node connection ends, then closes done.
+func streamFromNode(serverID uuid.UUID, stop, done chan struct{}) {
+	defer close(done)
+	server, node, err := getServerAndNode(serverID)
+	if err != nil {
+		return
+	}
+	url := fmt.Sprintf("ws://%s:%d/api/servers/%s/ws?token=%s", node.FQDN, node.Port, server.ID, node.DaemonToken)
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	go func() {
+		<-stop
+		conn.Close()
+	}()
+
+	channel := consoleChannel(serverID)
+	for {
+		_, msg, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		var data struct {
+			Type string `json:"type"`
+			Data string `json:"data"`
+		}
+		if json.Unmarshal(msg, &data) != nil || data.Type != "log" {
+			continue
+		}
+		line := data.Data
+		if len(line) > consoleMaxLineLen {
+			line = strings.ToValidUTF8(line[:consoleMaxLineLen], "")
+		}
+		if err := state.Publish(channel, []byte(line)); err != nil {
+			log.Printf("[console] publish for %s: %v", serverID, err)
+		}
+	}
+}
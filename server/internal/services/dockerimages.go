@@ -0,0 +1,83 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"birdactyl-panel-backend/internal/models"
+)
+
+func PackageDockerImages(pkg *models.Package) []models.PackageDockerImage {
+	var images []models.PackageDockerImage
+	json.Unmarshal(pkg.DockerImages, &images)
+	return images
+}
+
+func ValidateDockerImages(images []models.PackageDockerImage) error {
+	seen := make(map[string]bool, len(images))
+	for _, img := range images {
+		if img.Name == "" || img.Image == "" {
+			return fmt.Errorf("docker images need both a name and an image")
+		}
+		if strings.ContainsAny(img.Name, "/:") {
+			return fmt.Errorf("docker image name %q cannot contain / or :", img.Name)
+		}
+		if seen[img.Name] {
+			return fmt.Errorf("docker image name %q is used more than once", img.Name)
+		}
+		seen[img.Name] = true
+	}
+	return nil
+}
+
+// ResolveDockerImage returns the image a server runs. selected is either the
+// name of one of the package's images or an image reference an admin set;
+// names cannot contain / or :, so a name the package no longer lists falls
+// back to the package default instead of being pulled as an image.
+func ResolveDockerImage(pkg *models.Package, selected string) string {
+	if selected == "" {
+		return pkg.DockerImage
+	}
+	for _, img := range PackageDockerImages(pkg) {
+		if img.Name == selected || img.Image == selected {
+			return img.Image
+		}
+	}
+	if strings.ContainsAny(selected, "/:") {
+		return selected
+	}
+	return pkg.DockerImage
+}
+
+// SelectDockerImage validates a requested image against the package's allowed
+// list and returns the value to store on the server. Entries from the list are
+// stored by name so package updates to an image tag reach existing servers.
+func SelectDockerImage(pkg *models.Package, requested, current string, isAdmin bool) (string, error) {
+	if requested == "" || requested == current {
+		return requested, nil
+	}
+
+	for _, img := range PackageDockerImages(pkg) {
+		if img.Name == requested || img.Image == requested {
+			if !pkg.DockerImageEditable && !isAdmin {
+				break
+			}
+			return img.Name, nil
+		}
+	}
+	if requested == pkg.DockerImage {
+		return "", nil
+	}
+
+	if isAdmin {
+		if !strings.ContainsAny(requested, "/:") {
+			requested += ":latest"
+		}
+		return requested, nil
+	}
+	if !pkg.DockerImageEditable {
+		return "", &VariableValidationError{Fields: map[string]string{"docker_image": "The docker image cannot be changed."}}
+	}
+	return "", &VariableValidationError{Fields: map[string]string{"docker_image": "Must be one of the package's docker images."}}
+}
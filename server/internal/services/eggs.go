@@ -0,0 +1,452 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"birdactyl-panel-backend/internal/models"
+)
+
+var ErrInvalidEgg = errors.New("invalid egg: expected PTDL_v1 or PTDL_v2 export")
+
+type Egg struct {
+	Meta         EggMeta         `json:"meta"`
+	ExportedAt   string          `json:"exported_at,omitempty"`
+	Name         string          `json:"name"`
+	Author       string          `json:"author"`
+	Description  string          `json:"description"`
+	Features     []string        `json:"features,omitempty"`
+	DockerImages json.RawMessage `json:"docker_images,omitempty"`
+	Image        string          `json:"image,omitempty"`
+	Images       []string        `json:"images,omitempty"`
+	FileDenylist []string        `json:"file_denylist,omitempty"`
+	Startup      string          `json:"startup"`
+	Config       EggConfig       `json:"config"`
+	Scripts      EggScripts      `json:"scripts"`
+	Variables    []EggVariable   `json:"variables"`
+}
+
+type EggMeta struct {
+	Version   string  `json:"version"`
+	UpdateURL *string `json:"update_url"`
+}
+
+type EggConfig struct {
+	Files   json.RawMessage `json:"files"`
+	Startup json.RawMessage `json:"startup"`
+	Logs    json.RawMessage `json:"logs"`
+	Stop    string          `json:"stop"`
+	Extends *string         `json:"extends,omitempty"`
+}
+
+type EggScripts struct {
+	Installation EggInstallScript `json:"installation"`
+}
+
+type EggInstallScript struct {
+	Script     string `json:"script"`
+	Container  string `json:"container"`
+	Entrypoint string `json:"entrypoint"`
+}
+
+type EggVariable struct {
+	Name         string `json:"name"`
+	Description  string `json:"description"`
+	EnvVariable  string `json:"env_variable"`
+	DefaultValue string `json:"default_value"`
+	UserViewable bool   `json:"user_viewable"`
+	UserEditable bool   `json:"user_editable"`
+	Rules        string `json:"rules"`
+	FieldType    string `json:"field_type,omitempty"`
+}
+
+type eggConfigFile struct {
+	Parser string                     `json:"parser"`
+	Find   map[string]json.RawMessage `json:"find"`
+}
+
+func decodeEggJSON(raw json.RawMessage, v interface{}) error {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil
+	}
+	if raw[0] == '"' {
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return err
+		}
+		if strings.TrimSpace(s) == "" {
+			return nil
+		}
+		raw = []byte(s)
+	}
+	return json.Unmarshal(raw, v)
+}
+
+func eggDockerImages(egg *Egg) ([]models.PackageDockerImage, error) {
+	var images []models.PackageDockerImage
+	raw := bytes.TrimSpace(egg.DockerImages)
+	if len(raw) > 0 && string(raw) != "null" {
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+			return nil, fmt.Errorf("docker_images must be an object")
+		}
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			var image string
+			if err := dec.Decode(&image); err != nil {
+				return nil, err
+			}
+			images = append(images, models.PackageDockerImage{Name: key.(string), Image: image})
+		}
+	}
+	if len(images) == 0 {
+		for _, image := range egg.Images {
+			images = append(images, models.PackageDockerImage{Name: image, Image: image})
+		}
+	}
+	if len(images) == 0 && egg.Image != "" {
+		images = append(images, models.PackageDockerImage{Name: egg.Image, Image: egg.Image})
+	}
+	return images, nil
+}
+
+func ImportEgg(data []byte) (*models.Package, []string, error) {
+	var egg Egg
+	if err := json.Unmarshal(data, &egg); err != nil {
+		return nil, nil, ErrInvalidEgg
+	}
+	if egg.Meta.Version != "PTDL_v1" && egg.Meta.Version != "PTDL_v2" {
+		return nil, nil, ErrInvalidEgg
+	}
+	if egg.Name == "" || egg.Startup == "" {
+		return nil, nil, fmt.Errorf("egg is missing name or startup")
+	}
+
+	var warnings []string
+	warn := func(format string, args ...interface{}) {
+		warnings = append(warnings, fmt.Sprintf(format, args...))
+	}
+
+	images, err := eggDockerImages(&egg)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(images) == 0 {
+		return nil, nil, fmt.Errorf("egg does not define a docker image")
+	}
+	imagesJSON, _ := json.Marshal(images)
+
+	pkg := &models.Package{
+		Name:                egg.Name,
+		Version:             "1.0.0",
+		Author:              egg.Author,
+		Description:         egg.Description,
+		DockerImage:         images[0].Image,
+		DockerImages:        imagesJSON,
+		DockerImageEditable: len(images) > 1,
+		Startup:             egg.Startup,
+		StopSignal:          "SIGTERM",
+		StopTimeout:         30,
+	}
+
+	switch stop := strings.TrimSpace(egg.Config.Stop); {
+	case stop == "^C" || stop == "^^C":
+		pkg.StopSignal = "SIGINT"
+	case strings.HasPrefix(stop, "^"):
+		pkg.StopSignal = "SIGTERM"
+		warn("stop signal %q is not supported; using SIGTERM", stop)
+	default:
+		pkg.StopCommand = stop
+	}
+
+	install := egg.Scripts.Installation
+	if install.Script != "" {
+		pkg.InstallImage = install.Container
+		pkg.InstallScript = strings.ReplaceAll(strings.ReplaceAll(install.Script, "\r\n", "\n"), "/mnt/server", "/home/container")
+		if strings.Contains(install.Script, "/mnt/server") {
+			warn("install script paths under /mnt/server were rewritten to /home/container")
+		}
+		switch install.Entrypoint {
+		case "", "sh", "ash", "bash":
+			if install.Entrypoint == "bash" {
+				pkg.InstallScript = "bash -c " + shellQuote(pkg.InstallScript)
+			}
+		default:
+			warn("install entrypoint %q is not supported; the script is run with sh", install.Entrypoint)
+		}
+	}
+
+	vars := make([]models.PackageVariable, 0, len(egg.Variables))
+	for _, ev := range egg.Variables {
+		if ev.EnvVariable == "" {
+			warn("variable %q has no env_variable and was skipped", ev.Name)
+			continue
+		}
+		rules, dropped := SanitizeVariableRules(normalizeEggRules(ev.Rules))
+		for _, d := range dropped {
+			warn("variable %s: rule %q is not supported and was dropped", ev.EnvVariable, d)
+		}
+		if !ev.UserViewable {
+			warn("variable %s is hidden from users in the egg; Birdactyl has no hidden variables", ev.EnvVariable)
+		}
+		description := ev.Description
+		if ev.Name != "" && ev.Name != ev.EnvVariable {
+			description = strings.TrimSpace(ev.Name + ": " + description)
+		}
+		vars = append(vars, models.PackageVariable{
+			Name:         ev.EnvVariable,
+			Description:  description,
+			Default:      ev.DefaultValue,
+			UserEditable: ev.UserEditable,
+			Rules:        rules,
+		})
+	}
+
+	var files map[string]eggConfigFile
+	if err := decodeEggJSON(egg.Config.Files, &files); err != nil {
+		warn("config.files could not be parsed: %v", err)
+	}
+	configFiles := make([]models.PackageConfigFile, 0, len(files))
+	for _, path := range sortedEggKeys(files) {
+		f := files[path]
+		switch f.Parser {
+		case "properties", "yaml", "json", "ini":
+		default:
+			warn("config file %s uses the %q parser, which is not supported", path, f.Parser)
+			continue
+		}
+		replace := make(map[string]string, len(f.Find))
+		for key, raw := range f.Find {
+			if strings.ContainsAny(key, "[]*") {
+				warn("config file %s: key %q uses array or wildcard matching, which is not supported", path, key)
+				continue
+			}
+			var value string
+			if err := json.Unmarshal(raw, &value); err != nil {
+				var other interface{}
+				json.Unmarshal(raw, &other)
+				if _, isObject := other.(map[string]interface{}); isObject {
+					warn("config file %s: conditional replacement for %q is not supported", path, key)
+					continue
+				}
+				value = strings.TrimSpace(string(raw))
+			}
+			replace[key] = value
+		}
+		if len(replace) == 0 {
+			continue
+		}
+		configFiles = append(configFiles, models.PackageConfigFile{Path: path, Parser: f.Parser, Replace: replace})
+	}
+
+	var startup struct {
+		Done json.RawMessage `json:"done"`
+	}
+	if err := decodeEggJSON(egg.Config.Startup, &startup); err != nil {
+		warn("config.startup could not be parsed: %v", err)
+	}
+	var done []string
+	if len(startup.Done) > 0 && json.Unmarshal(startup.Done, &done) != nil {
+		var single string
+		if json.Unmarshal(startup.Done, &single) == nil && single != "" {
+			done = []string{single}
+		}
+	}
+	if err := ValidateStartupDone(done); err != nil {
+		warn("config.startup: %v; startup detection was not imported", err)
+		done = nil
+	}
+	pkg.StartupDone, _ = json.Marshal(done)
+	pkg.StartupTimeout = 300
+	if raw := bytes.TrimSpace(egg.Config.Logs); len(raw) > 0 && string(raw) != "null" && string(raw) != `"{}"` && string(raw) != "{}" {
+		warn("config.logs is not supported")
+	}
+	if egg.Config.Extends != nil && *egg.Config.Extends != "" {
+		warn("config.extends is not supported; configuration from the parent egg was not imported")
+	}
+	if len(egg.Features) > 0 {
+		warn("egg features are not supported: %s", strings.Join(egg.Features, ", "))
+	}
+	if len(egg.FileDenylist) > 0 {
+		warn("file_denylist is not supported")
+	}
+	warn("eggs do not define ports; add the package ports manually")
+
+	portsJSON, _ := json.Marshal([]models.PackagePort{})
+	varsJSON, _ := json.Marshal(vars)
+	configJSON, _ := json.Marshal(configFiles)
+	pkg.Ports = portsJSON
+	pkg.Variables = varsJSON
+	pkg.ConfigFiles = configJSON
+	pkg.AddonSources = []byte("[]")
+
+	return pkg, warnings, nil
+}
+
+func eggImagesJSON(pkg *models.Package) json.RawMessage {
+	images := []models.PackageDockerImage{{Name: pkg.DockerImage, Image: pkg.DockerImage}}
+	for _, img := range PackageDockerImages(pkg) {
+		if img.Image == pkg.DockerImage {
+			images[0].Name = img.Name
+			continue
+		}
+		images = append(images, img)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, img := range images {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, _ := json.Marshal(img.Name)
+		image, _ := json.Marshal(img.Image)
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(image)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes()
+}
+
+func ExportEgg(pkg *models.Package) (*Egg, []string) {
+	var warnings []string
+	warn := func(format string, args ...interface{}) {
+		warnings = append(warnings, fmt.Sprintf(format, args...))
+	}
+
+	images := eggImagesJSON(pkg)
+
+	stop := pkg.StopCommand
+	if stop == "" {
+		stop = "^C"
+		if pkg.StopSignal != "" && pkg.StopSignal != "SIGINT" {
+			warn("stop signal %s cannot be expressed in an egg; exported as ^C (SIGINT)", pkg.StopSignal)
+		}
+	}
+
+	var pkgVars []models.PackageVariable
+	json.Unmarshal(pkg.Variables, &pkgVars)
+	vars := make([]EggVariable, 0, len(pkgVars))
+	for _, pv := range pkgVars {
+		vars = append(vars, EggVariable{
+			Name:         pv.Name,
+			Description:  pv.Description,
+			EnvVariable:  pv.Name,
+			DefaultValue: pv.Default,
+			UserViewable: true,
+			UserEditable: pv.UserEditable,
+			Rules:        pv.Rules,
+			FieldType:    "text",
+		})
+	}
+
+	var configFiles []models.PackageConfigFile
+	json.Unmarshal(pkg.ConfigFiles, &configFiles)
+	files := make(map[string]eggConfigFile)
+	for _, cf := range configFiles {
+		switch cf.Parser {
+		case "properties", "yaml", "json", "ini":
+		default:
+			parser := cf.Parser
+			if parser == "" {
+				parser = "file"
+			}
+			warn("config file %s uses the %s parser, which eggs do not support", cf.Path, parser)
+			continue
+		}
+		find := make(map[string]json.RawMessage, len(cf.Replace))
+		for k, v := range cf.Replace {
+			raw, _ := json.Marshal(v)
+			find[k] = raw
+		}
+		files[cf.Path] = eggConfigFile{Parser: cf.Parser, Find: find}
+	}
+	filesJSON, _ := json.Marshal(files)
+	filesStr, _ := json.Marshal(string(filesJSON))
+
+	var done []string
+	json.Unmarshal(pkg.StartupDone, &done)
+	startupJSON, _ := json.Marshal(map[string]interface{}{"done": done})
+	startupStr, _ := json.Marshal(string(startupJSON))
+	if len(done) == 0 {
+		startupStr = []byte(`"{}"`)
+	}
+	if pkg.StartupProbe {
+		warn("the startup port probe is not part of the egg format")
+	}
+
+	var ports []models.PackagePort
+	json.Unmarshal(pkg.Ports, &ports)
+	if len(ports) > 0 {
+		warn("package ports are not part of the egg format")
+	}
+	var addons []models.AddonSource
+	json.Unmarshal(pkg.AddonSources, &addons)
+	if len(addons) > 0 {
+		warn("addon sources are not part of the egg format")
+	}
+
+	script := strings.ReplaceAll(pkg.InstallScript, "/home/container", "/mnt/server")
+	container := pkg.InstallImage
+	if container == "" {
+		container = "alpine:latest"
+	}
+	entrypoint := "bash"
+	if strings.Contains(container, "alpine") {
+		entrypoint = "ash"
+	}
+
+	return &Egg{
+		Meta:         EggMeta{Version: "PTDL_v2"},
+		ExportedAt:   time.Now().Format(time.RFC3339),
+		Name:         pkg.Name,
+		Author:       pkg.Author,
+		Description:  pkg.Description,
+		DockerImages: images,
+		Startup:      pkg.Startup,
+		Config: EggConfig{
+			Files:   filesStr,
+			Startup: startupStr,
+			Logs:    json.RawMessage(`"{}"`),
+			Stop:    stop,
+		},
+		Scripts:   EggScripts{Installation: EggInstallScript{Script: script, Container: container, Entrypoint: entrypoint}},
+		Variables: vars,
+	}, warnings
+}
+
+func normalizeEggRules(rules string) string {
+	parts := strings.Split(rules, "|")
+	for i, part := range parts {
+		if strings.HasPrefix(strings.TrimSpace(part), "regex:") {
+			break
+		}
+		if strings.TrimSpace(part) == "numeric" {
+			parts[i] = "integer"
+		}
+	}
+	return strings.Join(parts, "|")
+}
+
+func sortedEggKeys(m map[string]eggConfigFile) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'"'"'`) + "'"
+}
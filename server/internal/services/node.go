@@ -17,6 +17,7 @@ import (
 	"birdactyl-panel-backend/internal/models"
 
 	"github.com/google/uuid"
+	"gorm.io/datatypes"
 )
 
 var (
@@ -58,6 +59,7 @@ func CreateNode(name, fqdn string, port int) (*models.Node, *NodeToken, error) {
 	if err := database.DB.Create(node).Error; err != nil {
 		return nil, nil, err
 	}
+	seedDefaultAllocations(node.ID)
 
 	return node, &NodeToken{TokenID: tokenID, Token: token, DaemonToken: daemonToken}, nil
 }
@@ -112,7 +114,10 @@ func DeleteNode(id uuid.UUID) error {
 	if result.RowsAffected == 0 {
 		return ErrNodeNotFound
 	}
-	return result.Error
+	if result.Error != nil {
+		return result.Error
+	}
+	return database.DB.Where("node_id = ?", id).Delete(&models.Allocation{}).Error
 }
 
 func UpdateNode(id uuid.UUID, name, icon string) (*models.Node, error) {
@@ -186,6 +191,13 @@ func NodeHeartbeat(nodeID uuid.UUID, systemInfo models.SystemInfo, displayIP str
 	return database.DB.Model(&models.Node{}).Where("id = ?", nodeID).Updates(updates).Error
 }
 
+func RecordNodeReconcile(nodeID uuid.UUID, report []byte) error {
+	return database.DB.Model(&models.Node{}).Where("id = ?", nodeID).Updates(map[string]interface{}{
+		"reconcile_report": datatypes.JSON(report),
+		"last_reconcile":   time.Now(),
+	}).Error
+}
+
 func generateNodeToken() (tokenID, token, hash string) {
 	idBytes := make([]byte, 8)
 	rand.Read(idBytes)
@@ -342,6 +354,7 @@ func PairWithNode(name, fqdn string, port int, panelURL, code string) (*models.N
 	if err := database.DB.Create(node).Error; err != nil {
 		return nil, nil, err
 	}
+	seedDefaultAllocations(node.ID)
 
 	return node, &NodeToken{TokenID: result.TokenID, Token: result.Token, DaemonToken: daemonToken}, nil
 }
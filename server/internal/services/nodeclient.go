@@ -3,38 +3,44 @@ package services
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
-	"sync"
+	"net/url"
+	"strconv"
 	"time"
 
 	"birdactyl-panel-backend/internal/database"
 	"birdactyl-panel-backend/internal/models"
 
 	"github.com/google/uuid"
-	"github.com/gorilla/websocket"
 )
 
 type NodeServerConfig struct {
-	ID            string            `json:"id"`
-	Name          string            `json:"name"`
-	DockerImage   string            `json:"docker_image"`
-	InstallImage  string            `json:"install_image"`
-	InstallScript string            `json:"install_script"`
-	Startup       string            `json:"startup"`
-	Memory        int               `json:"memory"`
-	CPU           int               `json:"cpu"`
-	Disk          int               `json:"disk"`
-	Ports         []NodePortConfig  `json:"ports"`
-	Variables     map[string]string `json:"variables"`
-	StopSignal    string            `json:"stop_signal"`
-	StopCommand   string            `json:"stop_command"`
-	StopTimeout   int               `json:"stop_timeout"`
+	ID             string                     `json:"id"`
+	Name           string                     `json:"name"`
+	DockerImage    string                     `json:"docker_image"`
+	InstallImage   string                     `json:"install_image"`
+	InstallScript  string                     `json:"install_script"`
+	Startup        string                     `json:"startup"`
+	Memory         int                        `json:"memory"`
+	CPU            int                        `json:"cpu"`
+	Disk           int                        `json:"disk"`
+	Ports          []NodePortConfig           `json:"ports"`
+	Variables      map[string]string          `json:"variables"`
+	StopSignal     string                     `json:"stop_signal"`
+	StopCommand    string                     `json:"stop_command"`
+	StopTimeout    int                        `json:"stop_timeout"`
+	ConfigFiles    []models.PackageConfigFile `json:"config_files"`
+	StartupDone    []string                   `json:"startup_done"`
+	StartupProbe   bool                       `json:"startup_probe"`
+	StartupTimeout int                        `json:"startup_timeout"`
 }
 
 type NodePortConfig struct {
+	IP        string `json:"ip,omitempty"`
 	Host      int    `json:"host"`
 	Container int    `json:"container"`
 	Protocol  string `json:"protocol"`
@@ -47,17 +53,7 @@ func getNodeURL(node *models.Node) string {
 	return fmt.Sprintf("http://%s:%d", node.FQDN, node.Port)
 }
 
-func SendCreateServer(server *models.Server) error {
-	var node models.Node
-	if err := database.DB.Where("id = ?", server.NodeID).First(&node).Error; err != nil {
-		return fmt.Errorf("node not found")
-	}
-
-	var pkg models.Package
-	if err := database.DB.Where("id = ?", server.PackageID).First(&pkg).Error; err != nil {
-		return fmt.Errorf("package not found")
-	}
-
+func buildNodeServerConfig(server *models.Server, pkg *models.Package) NodeServerConfig {
 	var pkgPorts []models.PackagePort
 	json.Unmarshal(pkg.Ports, &pkgPorts)
 
@@ -66,11 +62,12 @@ func SendCreateServer(server *models.Server) error {
 
 	ports := make([]NodePortConfig, 0)
 	for i, pp := range pkgPorts {
-		hostPort := pp.Default
+		hostPort, ip := pp.Default, ""
 		if i < len(serverPorts) {
-			hostPort = serverPorts[i].Port
+			hostPort, ip = serverPorts[i].Port, serverPorts[i].IP
 		}
 		ports = append(ports, NodePortConfig{
+			IP:        ip,
 			Host:      hostPort,
 			Container: pp.Default,
 			Protocol:  pp.Protocol,
@@ -99,96 +96,62 @@ func SendCreateServer(server *models.Server) error {
 	}
 	finalVars["SERVER_MEMORY"] = fmt.Sprintf("%d", server.Memory)
 
-	cfg := NodeServerConfig{
-		ID:            server.ID.String(),
-		Name:          server.Name,
-		DockerImage:   orDefault(server.DockerImage, pkg.DockerImage),
-		InstallImage:  pkg.InstallImage,
-		InstallScript: pkg.InstallScript,
-		Startup:       orDefault(server.Startup, pkg.Startup),
-		Memory:        server.Memory,
-		CPU:           server.CPU,
-		Disk:          server.Disk,
-		Ports:         ports,
-		Variables:     finalVars,
-		StopSignal:    pkg.StopSignal,
-		StopCommand:   pkg.StopCommand,
-		StopTimeout:   pkg.StopTimeout,
+	var configFiles []models.PackageConfigFile
+	json.Unmarshal(pkg.ConfigFiles, &configFiles)
+
+	var startupDone []string
+	json.Unmarshal(pkg.StartupDone, &startupDone)
+
+	return NodeServerConfig{
+		ID:             server.ID.String(),
+		Name:           server.Name,
+		DockerImage:    ResolveDockerImage(pkg, server.DockerImage),
+		InstallImage:   pkg.InstallImage,
+		InstallScript:  pkg.InstallScript,
+		Startup:        orDefault(server.Startup, pkg.Startup),
+		Memory:         server.Memory,
+		CPU:            server.CPU,
+		Disk:           server.Disk,
+		Ports:          ports,
+		Variables:      finalVars,
+		StopSignal:     pkg.StopSignal,
+		StopCommand:    pkg.StopCommand,
+		StopTimeout:    pkg.StopTimeout,
+		ConfigFiles:    configFiles,
+		StartupDone:    startupDone,
+		StartupProbe:   pkg.StartupProbe,
+		StartupTimeout: pkg.StartupTimeout,
 	}
-
-	return sendToNode(&node, "POST", "/api/servers", cfg)
 }
 
-func SendStartServer(serverID uuid.UUID) error {
-	server, node, err := getServerAndNode(serverID)
-	if err != nil {
-		return err
-	}
-
-
-	
-	var pkg models.Package
-	if err := database.DB.Where("id = ?", server.PackageID).First(&pkg).Error; err != nil {
-		return fmt.Errorf("package not found")
+func SendCreateServer(server *models.Server) error {
+	var node models.Node
+	if err := database.DB.Where("id = ?", server.NodeID).First(&node).Error; err != nil {
+		return fmt.Errorf("node not found")
 	}
 
-	var pkgPorts []models.PackagePort
-	json.Unmarshal(pkg.Ports, &pkgPorts)
-
-	var serverPorts []models.ServerPort
-	json.Unmarshal(server.Ports, &serverPorts)
-
-	ports := make([]NodePortConfig, 0)
-	for i, pp := range pkgPorts {
-		hostPort := pp.Default
-		if i < len(serverPorts) {
-			hostPort = serverPorts[i].Port
-		}
-		ports = append(ports, NodePortConfig{
-			Host:      hostPort,
-			Container: pp.Default,
-			Protocol:  pp.Protocol,
-		})
+	pkg, err := ResolveServerPackage(server)
+	if err != nil {
+		return err
 	}
 
-	var pkgVars []models.PackageVariable
-	json.Unmarshal(pkg.Variables, &pkgVars)
+	cfg := buildNodeServerConfig(server, pkg)
 
-	var serverVars map[string]string
-	json.Unmarshal(server.Variables, &serverVars)
-	if serverVars == nil {
-		serverVars = make(map[string]string)
-	}
+	return sendToNode(&node, "POST", "/api/servers", cfg)
+}
 
-	finalVars := make(map[string]string)
-	for _, pv := range pkgVars {
-		finalVars[pv.Name] = pv.Default
-	}
-	for k, v := range serverVars {
-		finalVars[k] = v
+func SendStartServer(serverID uuid.UUID) error {
+	server, node, err := getServerAndNode(serverID)
+	if err != nil {
+		return err
 	}
 
-	if len(ports) > 0 {
-		finalVars["SERVER_PORT"] = fmt.Sprintf("%d", ports[0].Container)
+	pkg, err := ResolveServerPackage(server)
+	if err != nil {
+		return err
 	}
-	finalVars["SERVER_MEMORY"] = fmt.Sprintf("%d", server.Memory)
 
-	cfg := NodeServerConfig{
-		ID:            server.ID.String(),
-		Name:          server.Name,
-		DockerImage:   orDefault(server.DockerImage, pkg.DockerImage),
-		InstallImage:  pkg.InstallImage,
-		InstallScript: pkg.InstallScript,
-		Startup:       orDefault(server.Startup, pkg.Startup),
-		Memory:        server.Memory,
-		CPU:           server.CPU,
-		Disk:          server.Disk,
-		Ports:         ports,
-		Variables:     finalVars,
-		StopSignal:    pkg.StopSignal,
-		StopCommand:   pkg.StopCommand,
-		StopTimeout:   pkg.StopTimeout,
-	}
+	cfg := buildNodeServerConfig(server, pkg)
 
 	return sendToNode(node, "POST", fmt.Sprintf("/api/servers/%s/start", server.ID), cfg)
 }
@@ -199,8 +162,8 @@ func SendStopServer(serverID uuid.UUID) error {
 		return err
 	}
 
-	var pkg models.Package
-	if err := database.DB.Where("id = ?", server.PackageID).First(&pkg).Error; err != nil {
+	pkg, err := ResolveServerPackage(server)
+	if err != nil {
 		return sendToNode(node, "POST", fmt.Sprintf("/api/servers/%s/stop", server.ID), nil)
 	}
 
@@ -227,8 +190,8 @@ func SendRestartServer(serverID uuid.UUID) error {
 		return err
 	}
 
-	var pkg models.Package
-	if err := database.DB.Where("id = ?", server.PackageID).First(&pkg).Error; err != nil {
+	pkg, err := ResolveServerPackage(server)
+	if err != nil {
 		return sendToNode(node, "POST", fmt.Sprintf("/api/servers/%s/restart", server.ID), nil)
 	}
 
@@ -247,68 +210,12 @@ func SendReinstallServer(server *models.Server) error {
 		return fmt.Errorf("node not found")
 	}
 
-	var pkg models.Package
-	if err := database.DB.Where("id = ?", server.PackageID).First(&pkg).Error; err != nil {
-		return fmt.Errorf("package not found")
-	}
-
-	var pkgPorts []models.PackagePort
-	json.Unmarshal(pkg.Ports, &pkgPorts)
-
-	var serverPorts []models.ServerPort
-	json.Unmarshal(server.Ports, &serverPorts)
-
-	ports := make([]NodePortConfig, 0)
-	for i, pp := range pkgPorts {
-		hostPort := pp.Default
-		if i < len(serverPorts) {
-			hostPort = serverPorts[i].Port
-		}
-		ports = append(ports, NodePortConfig{
-			Host:      hostPort,
-			Container: pp.Default,
-			Protocol:  pp.Protocol,
-		})
-	}
-
-	var pkgVars []models.PackageVariable
-	json.Unmarshal(pkg.Variables, &pkgVars)
-
-	var serverVars map[string]string
-	json.Unmarshal(server.Variables, &serverVars)
-	if serverVars == nil {
-		serverVars = make(map[string]string)
-	}
-
-	finalVars := make(map[string]string)
-	for _, pv := range pkgVars {
-		finalVars[pv.Name] = pv.Default
-	}
-	for k, v := range serverVars {
-		finalVars[k] = v
-	}
-
-	if len(ports) > 0 {
-		finalVars["SERVER_PORT"] = fmt.Sprintf("%d", ports[0].Container)
+	pkg, err := ResolveServerPackage(server)
+	if err != nil {
+		return err
 	}
-	finalVars["SERVER_MEMORY"] = fmt.Sprintf("%d", server.Memory)
 
-	cfg := NodeServerConfig{
-		ID:            server.ID.String(),
-		Name:          server.Name,
-		DockerImage:   orDefault(server.DockerImage, pkg.DockerImage),
-		InstallImage:  pkg.InstallImage,
-		InstallScript: pkg.InstallScript,
-		Startup:       orDefault(server.Startup, pkg.Startup),
-		Memory:        server.Memory,
-		CPU:           server.CPU,
-		Disk:          server.Disk,
-		Ports:         ports,
-		Variables:     finalVars,
-		StopSignal:    pkg.StopSignal,
-		StopCommand:   pkg.StopCommand,
-		StopTimeout:   pkg.StopTimeout,
-	}
+	cfg := buildNodeServerConfig(server, pkg)
 
 	return sendToNode(&node, "POST", fmt.Sprintf("/api/servers/%s/reinstall", server.ID), cfg)
 }
@@ -364,14 +271,29 @@ func sendToNode(node *models.Node, method, path string, body interface{}) error
 		var result map[string]interface{}
 		json.NewDecoder(resp.Body).Decode(&result)
 		if errMsg, ok := result["error"].(string); ok {
-			return fmt.Errorf("node error: %s", errMsg)
+			return &NodeError{StatusCode: resp.StatusCode, Message: "node error: " + errMsg}
 		}
-		return fmt.Errorf("node returned status %d", resp.StatusCode)
+		return &NodeError{StatusCode: resp.StatusCode, Message: fmt.Sprintf("node returned status %d", resp.StatusCode)}
 	}
 
 	return nil
 }
 
+// NodeError is a non-2xx reply from Axis.
+type NodeError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *NodeError) Error() string { return e.Message }
+
+// IsNodeConflict reports whether Axis refused the request because another
+// operation holds the server.
+func IsNodeConflict(err error) bool {
+	var nodeErr *NodeError
+	return errors.As(err, &nodeErr) && nodeErr.StatusCode == http.StatusConflict
+}
+
 type NodeResponse struct {
 	StatusCode int
 	Body       []byte
@@ -382,7 +304,10 @@ func ProxyToNode(server *models.Server, method, path string, body interface{}) (
 	if err := database.DB.Where("id = ?", server.NodeID).First(&node).Error; err != nil {
 		return nil, fmt.Errorf("node not found")
 	}
+	return RequestNode(&node, method, path, body)
+}
 
+func RequestNode(node *models.Node, method, path string, body interface{}) (*NodeResponse, error) {
 	var reqBody []byte
 	if body != nil {
 		var err error
@@ -392,7 +317,7 @@ func ProxyToNode(server *models.Server, method, path string, body interface{}) (
 		}
 	}
 
-	url := getNodeURL(&node) + path
+	url := getNodeURL(node) + path
 	req, err := http.NewRequest(method, url, bytes.NewReader(reqBody))
 	if err != nil {
 		return nil, err
@@ -574,12 +499,70 @@ func SendCommand(serverID uuid.UUID, command string) error {
 	return sendToNode(node, "POST", fmt.Sprintf("/api/servers/%s/command", server.ID), map[string]string{"command": command})
 }
 
-func CreateServerArchive(serverID uuid.UUID) error {
-	server, node, err := getServerAndNode(serverID)
+// ArchiveInfo describes a transfer archive created on a node.
+type ArchiveInfo struct {
+	Size     int64  `json:"size"`
+	Checksum string `json:"checksum"`
+}
+
+// TransferJobStatus is a target node's progress on a transfer import or
+// sync.
+type TransferJobStatus struct {
+	Stage      string `json:"stage"`
+	BytesDone  int64  `json:"bytes_done"`
+	BytesTotal int64  `json:"bytes_total"`
+	FilesDone  int64  `json:"files_done"`
+	FilesTotal int64  `json:"files_total"`
+	Error      string `json:"error,omitempty"`
+}
+
+// transferRequest calls a node without a timeout and decodes the data field
+// of its reply into out.
+func transferRequest(node *models.Node, method, path string, body, out interface{}) error {
+	var reqBody []byte
+	if body != nil {
+		var err error
+		if reqBody, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequest(method, getNodeURL(node)+path, bytes.NewReader(reqBody))
 	if err != nil {
 		return err
 	}
-	return sendToNode(node, "POST", fmt.Sprintf("/api/servers/%s/archive", server.ID), nil)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+node.DaemonToken)
+
+	resp, err := transferClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to connect to node %s: %w", node.Name, err)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Error string          `json:"error"`
+		Data  json.RawMessage `json:"data"`
+	}
+	json.NewDecoder(resp.Body).Decode(&result)
+	if resp.StatusCode >= 400 {
+		if result.Error != "" {
+			return &NodeError{StatusCode: resp.StatusCode, Message: fmt.Sprintf("node %s: %s", node.Name, result.Error)}
+		}
+		return &NodeError{StatusCode: resp.StatusCode, Message: fmt.Sprintf("node %s returned status %d", node.Name, resp.StatusCode)}
+	}
+	if out != nil && len(result.Data) > 0 {
+		return json.Unmarshal(result.Data, out)
+	}
+	return nil
+}
+
+func CreateServerArchive(node *models.Node, serverID uuid.UUID) (*ArchiveInfo, error) {
+	var info ArchiveInfo
+	if err := transferRequest(node, "POST", fmt.Sprintf("/api/servers/%s/archive", serverID), nil, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
 }
 
 func DownloadServerArchive(serverID uuid.UUID) (*http.Response, error) {
@@ -598,47 +581,57 @@ func DownloadServerArchive(serverID uuid.UUID) (*http.Response, error) {
 	return transferClient.Do(req)
 }
 
-func DeleteServerArchive(serverID uuid.UUID) error {
-	server, node, err := getServerAndNode(serverID)
-	if err != nil {
-		return err
+func DeleteServerArchive(node *models.Node, serverID uuid.UUID) error {
+	return sendToNode(node, "DELETE", fmt.Sprintf("/api/servers/%s/archive", serverID), nil)
+}
+
+// StartServerImport has the target node fetch the archive from the source
+// node in the background. The target checks the size and checksum before
+// extracting it.
+func StartServerImport(target, source *models.Node, serverID uuid.UUID, archive *ArchiveInfo) error {
+	body := map[string]interface{}{
+		"url":      getNodeURL(source) + fmt.Sprintf("/api/servers/%s/archive/download", serverID),
+		"token":    source.DaemonToken,
+		"checksum": archive.Checksum,
+		"size":     archive.Size,
 	}
-	return sendToNode(node, "DELETE", fmt.Sprintf("/api/servers/%s/archive", server.ID), nil)
+	return transferRequest(target, "POST", fmt.Sprintf("/api/servers/%s/import", serverID), body, nil)
 }
 
-func ImportServerArchive(targetNode *models.Node, serverID string, sourceNode *models.Node) error {
-	url := getNodeURL(targetNode) + fmt.Sprintf("/api/servers/%s/import", serverID)
-	sourceURL := getNodeURL(sourceNode) + fmt.Sprintf("/api/servers/%s/archive/download", serverID)
+// GetServerImport returns the target node's latest import for the server,
+// or nil when it has none.
+func GetServerImport(target *models.Node, serverID uuid.UUID) (*TransferJobStatus, error) {
+	return getTransferJob(target, fmt.Sprintf("/api/servers/%s/import", serverID))
+}
 
-	body := map[string]string{
-		"url":   sourceURL,
-		"token": sourceNode.DaemonToken,
+// StartServerSync has the target node copy the files that differ from the
+// source node. A final sync fails on any file that changes while it runs.
+func StartServerSync(target, source *models.Node, serverID uuid.UUID, final bool) error {
+	body := map[string]interface{}{
+		"url":   getNodeURL(source) + fmt.Sprintf("/api/servers/%s", serverID),
+		"token": source.DaemonToken,
+		"final": final,
 	}
-	jsonBody, _ := json.Marshal(body)
+	return transferRequest(target, "POST", fmt.Sprintf("/api/servers/%s/sync", serverID), body, nil)
+}
 
-	req, err := http.NewRequest("POST", url, bytes.NewReader(jsonBody))
-	if err != nil {
-		return err
-	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+targetNode.DaemonToken)
+// GetServerSync returns the target node's latest sync for the server, or nil
+// when it has none.
+func GetServerSync(target *models.Node, serverID uuid.UUID) (*TransferJobStatus, error) {
+	return getTransferJob(target, fmt.Sprintf("/api/servers/%s/sync", serverID))
+}
 
-	resp, err := transferClient.Do(req)
-	if err != nil {
-		return fmt.Errorf("failed to connect to target node: %w", err)
+func getTransferJob(target *models.Node, path string) (*TransferJobStatus, error) {
+	var status TransferJobStatus
+	err := transferRequest(target, "GET", path, nil, &status)
+	var nodeErr *NodeError
+	if errors.As(err, &nodeErr) && nodeErr.StatusCode == http.StatusNotFound {
+		return nil, nil
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode >= 400 {
-		var result map[string]interface{}
-		json.NewDecoder(resp.Body).Decode(&result)
-		if errMsg, ok := result["error"].(string); ok {
-			return fmt.Errorf("target node error: %s", errMsg)
-		}
-		return fmt.Errorf("target node returned status %d", resp.StatusCode)
+	if err != nil {
+		return nil, err
 	}
-
-	return nil
+	return &status, nil
 }
 
 func orDefault(val, def string) string {
@@ -649,9 +642,21 @@ func orDefault(val, def string) string {
 }
 
 type LogMatch struct {
-	Line       string
-	LineNumber int
-	Timestamp  int64
+	Source     string   `json:"source"`
+	Line       string   `json:"line"`
+	LineNumber int      `json:"line_number"`
+	Timestamp  int64    `json:"timestamp"`
+	Before     []string `json:"before,omitempty"`
+	After      []string `json:"after,omitempty"`
+}
+
+type LogSearchOptions struct {
+	Pattern string
+	Regex   bool
+	Limit   int
+	Since   int64
+	Until   int64
+	Context int
 }
 
 type LogFileInfo struct {
@@ -660,62 +665,6 @@ type LogFileInfo struct {
 	Modified string
 }
 
-var (
-	consoleSubscribers   = make(map[uuid.UUID][]chan string)
-	consoleSubscribersMu sync.RWMutex
-)
-
-func SubscribeConsole(serverID uuid.UUID) chan string {
-	ch := make(chan string, 100)
-	consoleSubscribersMu.Lock()
-	consoleSubscribers[serverID] = append(consoleSubscribers[serverID], ch)
-	consoleSubscribersMu.Unlock()
-	go streamFromNode(serverID, ch)
-	return ch
-}
-
-func UnsubscribeConsole(serverID uuid.UUID, ch chan string) {
-	consoleSubscribersMu.Lock()
-	defer consoleSubscribersMu.Unlock()
-	subs := consoleSubscribers[serverID]
-	for i, sub := range subs {
-		if sub == ch {
-			consoleSubscribers[serverID] = append(subs[:i], subs[i+1:]...)
-			close(ch)
-			break
-		}
-	}
-}
-
-func streamFromNode(serverID uuid.UUID, ch chan string) {
-	server, node, err := getServerAndNode(serverID)
-	if err != nil {
-		return
-	}
-	url := fmt.Sprintf("ws://%s:%d/api/servers/%s/ws?token=%s", node.FQDN, node.Port, server.ID, node.DaemonToken)
-	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
-	if err != nil {
-		return
-	}
-	defer conn.Close()
-	for {
-		_, msg, err := conn.ReadMessage()
-		if err != nil {
-			return
-		}
-		var data struct {
-			Type string `json:"type"`
-			Data string `json:"data"`
-		}
-		if json.Unmarshal(msg, &data) == nil && data.Type == "log" {
-			select {
-			case ch <- data.Data:
-			default:
-			}
-		}
-	}
-}
-
 func GetFullLog(serverID uuid.UUID) ([]byte, int64) {
 	server, node, err := getServerAndNode(serverID)
 	if err != nil {
@@ -735,15 +684,20 @@ func GetFullLog(serverID uuid.UUID) ([]byte, int64) {
 	return content, int64(len(content))
 }
 
-func SearchLogs(serverID uuid.UUID, pattern string, regex bool, limit int, since int64) []LogMatch {
+func SearchLogs(serverID uuid.UUID, opts LogSearchOptions) []LogMatch {
 	server, node, err := getServerAndNode(serverID)
 	if err != nil {
 		log.Printf("[nodeclient] SearchLogs: %v", err)
 		return nil
 	}
-	url := fmt.Sprintf("%s/api/servers/%s/logs/search?pattern=%s&regex=%t&limit=%d&since=%d",
-		getNodeURL(node), server.ID, pattern, regex, limit, since)
-	req, _ := http.NewRequest("GET", url, nil)
+	query := url.Values{}
+	query.Set("pattern", opts.Pattern)
+	query.Set("regex", strconv.FormatBool(opts.Regex))
+	query.Set("limit", strconv.Itoa(opts.Limit))
+	query.Set("since", strconv.FormatInt(opts.Since, 10))
+	query.Set("until", strconv.FormatInt(opts.Until, 10))
+	query.Set("context", strconv.Itoa(opts.Context))
+	req, _ := http.NewRequest("GET", fmt.Sprintf("%s/api/servers/%s/logs/search?%s", getNodeURL(node), server.ID, query.Encode()), nil)
 	req.Header.Set("Authorization", "Bearer "+node.DaemonToken)
 	resp, err := httpClient.Do(req)
 	if err != nil {
@@ -0,0 +1,175 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"birdactyl-panel-backend/internal/config"
+	"birdactyl-panel-backend/internal/database"
+	"birdactyl-panel-backend/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+var (
+	nodeSyncStop chan struct{}
+	nodeSyncOnce sync.Once
+)
+
+type nodeSyncRequest struct {
+	Servers       []NodeServerConfig `json:"servers"`
+	RemoveOrphans []string           `json:"remove_orphans,omitempty"`
+	Recreate      []string           `json:"recreate,omitempty"`
+}
+
+func StartNodeSync() {
+	nodeSyncOnce.Do(func() {
+		nodeSyncStop = make(chan struct{})
+		interval := time.Duration(config.Get().Scheduler.NodeSyncInterval) * time.Minute
+		go func() {
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ticker.C:
+					syncAllNodes(interval)
+				case <-nodeSyncStop:
+					return
+				}
+			}
+		}()
+	})
+}
+
+func StopNodeSync() {
+	if nodeSyncStop != nil {
+		close(nodeSyncStop)
+	}
+}
+
+func syncAllNodes(interval time.Duration) {
+	nodes, err := GetOnlineNodes()
+	if err != nil {
+		return
+	}
+	for i := range nodes {
+		if !claimNodeSync(&nodes[i], interval) {
+			continue
+		}
+		if _, err := SyncNode(&nodes[i]); err != nil {
+			log.Printf("[nodesync] %s: %v", nodes[i].Name, err)
+		}
+	}
+}
+
+// claimNodeSync moves the node's last_sync to now unless it was synced
+// within the last half interval. The update only matches while last_sync
+// still holds the value read, so when several panels share the database
+// exactly one of them syncs the node each interval.
+func claimNodeSync(node *models.Node, interval time.Duration) bool {
+	now := time.Now()
+	if node.LastSync != nil && now.Sub(*node.LastSync) < interval/2 {
+		return false
+	}
+	claim := database.DB.Model(&models.Node{}).Where("id = ?", node.ID)
+	if node.LastSync == nil {
+		claim = claim.Where("last_sync IS NULL")
+	} else {
+		claim = claim.Where("last_sync = ?", *node.LastSync)
+	}
+	result := claim.Update("last_sync", now)
+	return result.Error == nil && result.RowsAffected == 1
+}
+
+func desiredServerConfigs(nodeID uuid.UUID) ([]NodeServerConfig, error) {
+	var servers []models.Server
+	if err := database.DB.Where("node_id = ?", nodeID).Find(&servers).Error; err != nil {
+		return nil, err
+	}
+
+	configs := make([]NodeServerConfig, 0, len(servers))
+	for i := range servers {
+		pkg, err := ResolveServerPackage(&servers[i])
+		if err != nil {
+			pkg = &models.Package{}
+		}
+		configs = append(configs, buildNodeServerConfig(&servers[i], pkg))
+	}
+	return configs, nil
+}
+
+func postNodeSync(node *models.Node, path string, req nodeSyncRequest) (json.RawMessage, error) {
+	resp, err := RequestNode(node, "POST", path, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Success bool            `json:"success"`
+		Error   string          `json:"error"`
+		Data    json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(resp.Body, &result); err != nil {
+		return nil, fmt.Errorf("node returned status %d", resp.StatusCode)
+	}
+	if !result.Success {
+		return nil, fmt.Errorf("node error: %s", result.Error)
+	}
+	return result.Data, nil
+}
+
+// SyncNode pushes the panel's server list for a node and stores the drift the
+// node reports. Nothing is changed on the node.
+func SyncNode(node *models.Node) (json.RawMessage, error) {
+	configs, err := desiredServerConfigs(node.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := postNodeSync(node, "/api/sync", nodeSyncRequest{Servers: configs})
+	if err != nil {
+		return nil, err
+	}
+
+	database.DB.Model(&models.Node{}).Where("id = ?", node.ID).Updates(map[string]interface{}{
+		"drift_report": datatypes.JSON(report),
+		"last_sync":    time.Now(),
+	})
+	return report, nil
+}
+
+func ResolveNodeDrift(node *models.Node, removeOrphans, recreate []string) (json.RawMessage, error) {
+	configs, err := desiredServerConfigs(node.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := postNodeSync(node, "/api/sync/apply", nodeSyncRequest{
+		Servers:       configs,
+		RemoveOrphans: removeOrphans,
+		Recreate:      recreate,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var applied struct {
+		Result json.RawMessage `json:"result"`
+		Drift  json.RawMessage `json:"drift"`
+	}
+	json.Unmarshal(data, &applied)
+
+	updates := map[string]interface{}{
+		"sync_result": datatypes.JSON(applied.Result),
+		"last_sync":   time.Now(),
+	}
+	if len(applied.Drift) > 0 && string(applied.Drift) != "null" {
+		updates["drift_report"] = datatypes.JSON(applied.Drift)
+	}
+	database.DB.Model(&models.Node{}).Where("id = ?", node.ID).Updates(updates)
+	return data, nil
+}
@@ -3,11 +3,15 @@ package services
 import (
 	"errors"
 	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
 
 	"birdactyl-panel-backend/internal/database"
 	"birdactyl-panel-backend/internal/models"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 var (
@@ -15,12 +19,18 @@ var (
 	ErrPackageNameTaken = errors.New("package name already exists")
 )
 
-func CreatePackage(pkg *models.Package) error {
+func CreatePackage(pkg *models.Package, actor *uuid.UUID) error {
 	var existing models.Package
 	if err := database.DB.Where("name = ? AND version = ?", pkg.Name, pkg.Version).First(&existing).Error; err == nil {
 		return ErrPackageNameTaken
 	}
-	return database.DB.Create(pkg).Error
+	pkg.Revision = 1
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(pkg).Error; err != nil {
+			return err
+		}
+		return recordPackageRevision(tx, pkg, actor)
+	})
 }
 
 func GetPackages() ([]models.Package, error) {
@@ -37,12 +47,30 @@ func GetPackageByID(id uuid.UUID) (*models.Package, error) {
 	return &pkg, nil
 }
 
-func UpdatePackage(id uuid.UUID, updates map[string]interface{}) (*models.Package, error) {
+func UpdatePackage(id uuid.UUID, updates map[string]interface{}, actor *uuid.UUID) (*models.Package, error) {
 	var pkg models.Package
-	if err := database.DB.Where("id = ?", id).First(&pkg).Error; err != nil {
-		return nil, ErrPackageNotFound
-	}
-	if err := database.DB.Model(&pkg).Updates(updates).Error; err != nil {
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ?", id).First(&pkg).Error; err != nil {
+			return ErrPackageNotFound
+		}
+		before := packageFields(&pkg)
+		delete(updates, "revision")
+		if err := tx.Model(&pkg).Updates(updates).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("id = ?", id).First(&pkg).Error; err != nil {
+			return err
+		}
+		if reflect.DeepEqual(before, packageFields(&pkg)) {
+			return nil
+		}
+		pkg.Revision++
+		if err := tx.Model(&pkg).Update("revision", pkg.Revision).Error; err != nil {
+			return err
+		}
+		return recordPackageRevision(tx, &pkg, actor)
+	})
+	if err != nil {
 		return nil, err
 	}
 	return &pkg, nil
@@ -65,3 +93,41 @@ func DeletePackage(id uuid.UUID) error {
 	}
 	return nil
 }
+
+var configFileParsers = map[string]bool{
+	"": true, "file": true, "properties": true, "yaml": true, "json": true, "toml": true, "ini": true,
+}
+
+// ValidateStartupDone checks done patterns. Patterns are substrings unless
+// prefixed with "regex:".
+func ValidateStartupDone(patterns []string) error {
+	for i, p := range patterns {
+		if strings.TrimSpace(p) == "" {
+			return fmt.Errorf("startup_done[%d]: pattern is empty", i)
+		}
+		if expr, ok := strings.CutPrefix(p, "regex:"); ok {
+			if _, err := regexp.Compile(expr); err != nil {
+				return fmt.Errorf("startup_done[%d]: %v", i, err)
+			}
+		}
+	}
+	return nil
+}
+
+func ValidateConfigFiles(files []models.PackageConfigFile) error {
+	for i, f := range files {
+		if f.Path == "" {
+			return fmt.Errorf("config_files[%d]: path is required", i)
+		}
+		if !configFileParsers[f.Parser] {
+			return fmt.Errorf("config_files[%d]: unsupported parser %q", i, f.Parser)
+		}
+		if (f.Parser == "" || f.Parser == "file") && f.Template == "" {
+			return fmt.Errorf("config_files[%d]: template is required", i)
+		}
+		if f.Parser != "" && f.Parser != "file" && len(f.Replace) == 0 {
+			return fmt.Errorf("config_files[%d]: replace is required for parser %q", i, f.Parser)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,258 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sort"
+	"time"
+
+	"birdactyl-panel-backend/internal/database"
+	"birdactyl-panel-backend/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var ErrRevisionNotFound = errors.New("package revision not found")
+
+var revisionIgnoredFields = map[string]bool{
+	"id": true, "revision": true, "created_at": true, "updated_at": true,
+}
+
+type PackageRevisionInfo struct {
+	Revision  int        `json:"revision"`
+	CreatedBy *uuid.UUID `json:"created_by,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	Servers   int64      `json:"servers"`
+	Current   bool       `json:"current"`
+}
+
+type PackageFieldChange struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
+
+type RolloutServer struct {
+	ID       uuid.UUID           `json:"id"`
+	Name     string              `json:"name"`
+	Status   models.ServerStatus `json:"status"`
+	Revision int                 `json:"revision"`
+	Behind   int                 `json:"behind"`
+}
+
+func recordPackageRevision(tx *gorm.DB, pkg *models.Package, actor *uuid.UUID) error {
+	snapshot, err := json.Marshal(pkg)
+	if err != nil {
+		return err
+	}
+	return tx.Create(&models.PackageRevision{
+		PackageID: pkg.ID,
+		Revision:  pkg.Revision,
+		Snapshot:  snapshot,
+		CreatedBy: actor,
+	}).Error
+}
+
+func BackfillPackageRevisions() error {
+	var packages []models.Package
+	if err := database.DB.Find(&packages).Error; err != nil {
+		return err
+	}
+
+	for i := range packages {
+		pkg := &packages[i]
+		if pkg.Revision == 0 {
+			pkg.Revision = 1
+			database.DB.Model(pkg).Update("revision", 1)
+		}
+
+		var count int64
+		database.DB.Model(&models.PackageRevision{}).Where("package_id = ?", pkg.ID).Count(&count)
+		if count == 0 {
+			if err := recordPackageRevision(database.DB, pkg, nil); err != nil {
+				return err
+			}
+		}
+
+		database.DB.Model(&models.Server{}).
+			Where("package_id = ? AND (package_revision = 0 OR package_revision IS NULL)", pkg.ID).
+			Update("package_revision", pkg.Revision)
+	}
+	return nil
+}
+
+func GetPackageRevision(packageID uuid.UUID, revision int) (*models.Package, error) {
+	var rev models.PackageRevision
+	if err := database.DB.Where("package_id = ? AND revision = ?", packageID, revision).First(&rev).Error; err != nil {
+		return nil, ErrRevisionNotFound
+	}
+	var pkg models.Package
+	if err := json.Unmarshal(rev.Snapshot, &pkg); err != nil {
+		return nil, err
+	}
+	return &pkg, nil
+}
+
+func ResolveServerPackage(server *models.Server) (*models.Package, error) {
+	var pkg models.Package
+	if err := database.DB.Where("id = ?", server.PackageID).First(&pkg).Error; err != nil {
+		return nil, ErrPackageNotFound
+	}
+	if server.PackageRevision == 0 || server.PackageRevision == pkg.Revision {
+		return &pkg, nil
+	}
+	return GetPackageRevision(pkg.ID, server.PackageRevision)
+}
+
+// packageFields returns the fields of pkg a revision snapshot compares,
+// leaving out bookkeeping such as the revision number and timestamps.
+func packageFields(pkg *models.Package) map[string]interface{} {
+	fields := map[string]interface{}{}
+	data, _ := json.Marshal(pkg)
+	json.Unmarshal(data, &fields)
+	for k := range revisionIgnoredFields {
+		delete(fields, k)
+	}
+	return fields
+}
+
+func ListPackageRevisions(packageID uuid.UUID) ([]PackageRevisionInfo, error) {
+	pkg, err := GetPackageByID(packageID)
+	if err != nil {
+		return nil, err
+	}
+
+	var revisions []models.PackageRevision
+	if err := database.DB.Select("revision, created_by, created_at").Where("package_id = ?", packageID).Order("revision DESC").Find(&revisions).Error; err != nil {
+		return nil, err
+	}
+
+	type revCount struct {
+		PackageRevision int
+		Count           int64
+	}
+	var counts []revCount
+	database.DB.Model(&models.Server{}).Select("package_revision, count(*) as count").Where("package_id = ?", packageID).Group("package_revision").Scan(&counts)
+	byRev := make(map[int]int64, len(counts))
+	for _, c := range counts {
+		byRev[c.PackageRevision] = c.Count
+	}
+
+	result := make([]PackageRevisionInfo, len(revisions))
+	for i, r := range revisions {
+		result[i] = PackageRevisionInfo{
+			Revision:  r.Revision,
+			CreatedBy: r.CreatedBy,
+			CreatedAt: r.CreatedAt,
+			Servers:   byRev[r.Revision],
+			Current:   r.Revision == pkg.Revision,
+		}
+	}
+	return result, nil
+}
+
+func DiffPackageRevisions(packageID uuid.UUID, from, to int) ([]PackageFieldChange, error) {
+	load := func(revision int) (map[string]interface{}, error) {
+		var rev models.PackageRevision
+		if err := database.DB.Where("package_id = ? AND revision = ?", packageID, revision).First(&rev).Error; err != nil {
+			return nil, ErrRevisionNotFound
+		}
+		fields := map[string]interface{}{}
+		if err := json.Unmarshal(rev.Snapshot, &fields); err != nil {
+			return nil, err
+		}
+		return fields, nil
+	}
+
+	a, err := load(from)
+	if err != nil {
+		return nil, err
+	}
+	b, err := load(to)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]bool)
+	for k := range a {
+		keys[k] = true
+	}
+	for k := range b {
+		keys[k] = true
+	}
+
+	changes := []PackageFieldChange{}
+	for k := range keys {
+		if revisionIgnoredFields[k] || reflect.DeepEqual(a[k], b[k]) {
+			continue
+		}
+		changes = append(changes, PackageFieldChange{Field: k, From: a[k], To: b[k]})
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes, nil
+}
+
+func GetRolloutStatus(packageID uuid.UUID) (int, []RolloutServer, error) {
+	pkg, err := GetPackageByID(packageID)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var servers []models.Server
+	database.DB.Select("id, name, status, package_revision").
+		Where("package_id = ? AND package_revision < ?", packageID, pkg.Revision).
+		Order("package_revision ASC, name ASC").Find(&servers)
+
+	result := make([]RolloutServer, len(servers))
+	for i, s := range servers {
+		result[i] = RolloutServer{
+			ID:       s.ID,
+			Name:     s.Name,
+			Status:   s.Status,
+			Revision: s.PackageRevision,
+			Behind:   pkg.Revision - s.PackageRevision,
+		}
+	}
+	return pkg.Revision, result, nil
+}
+
+func RolloutPackageRevision(packageID uuid.UUID, revision int, serverIDs []uuid.UUID) ([]models.Server, error) {
+	pkg, err := GetPackageByID(packageID)
+	if err != nil {
+		return nil, err
+	}
+	if revision == 0 {
+		revision = pkg.Revision
+	}
+
+	var count int64
+	database.DB.Model(&models.PackageRevision{}).Where("package_id = ? AND revision = ?", packageID, revision).Count(&count)
+	if count == 0 {
+		return nil, ErrRevisionNotFound
+	}
+
+	query := database.DB.Where("package_id = ? AND package_revision <> ?", packageID, revision)
+	if len(serverIDs) > 0 {
+		query = query.Where("id IN ?", serverIDs)
+	}
+
+	var servers []models.Server
+	if err := query.Find(&servers).Error; err != nil {
+		return nil, err
+	}
+	if len(servers) == 0 {
+		return servers, nil
+	}
+
+	ids := make([]uuid.UUID, len(servers))
+	for i := range servers {
+		ids[i] = servers[i].ID
+		servers[i].PackageRevision = revision
+	}
+	if err := database.DB.Model(&models.Server{}).Where("id IN ?", ids).Update("package_revision", revision).Error; err != nil {
+		return nil, err
+	}
+	return servers, nil
+}
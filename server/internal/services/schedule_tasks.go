@@ -0,0 +1,734 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"birdactyl-panel-backend/internal/database"
+	"birdactyl-panel-backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+const (
+	maxTaskDelay       = 3600
+	maxTaskWait        = 900
+	defaultTaskWait    = 300
+	backupTaskTimeout  = 2 * time.Hour
+	playerCaptureMs    = 2000
+	webhookTaskTimeout = 15 * time.Second
+
+	defaultBackupName    = "{schedule} {date} {time}"
+	defaultPlayerCommand = "list"
+	defaultPlayerPattern = `There are (\d+)`
+)
+
+var defaultCountdownMarks = []int{3600, 1800, 900, 600, 300, 240, 180, 120, 60, 30, 10, 5, 4, 3, 2, 1}
+
+var errWebhookAddress = errors.New("webhook address is not allowed")
+
+// webhookClient only connects to public addresses. The check runs on the
+// address actually dialed, so redirects and DNS answers that point at
+// loopback, private or metadata addresses are refused too.
+var webhookClient = &http.Client{
+	Timeout: webhookTaskTimeout,
+	Transport: &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout: webhookTaskTimeout,
+			Control: func(network, address string, _ syscall.RawConn) error {
+				host, _, err := net.SplitHostPort(address)
+				if err != nil {
+					return err
+				}
+				if ip := net.ParseIP(host); ip == nil || !publicWebhookIP(ip) {
+					return errWebhookAddress
+				}
+				return nil
+			},
+		}).DialContext,
+		TLSHandshakeTimeout: webhookTaskTimeout,
+	},
+}
+
+var carrierGradeNAT = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+func publicWebhookIP(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsMulticast() ||
+		carrierGradeNAT.Contains(ip))
+}
+
+// taskContext is what a running task knows about its schedule, used for
+// templates and node calls.
+type taskContext struct {
+	server   *models.Server
+	schedule *models.Schedule
+	runID    uuid.UUID
+	now      time.Time
+}
+
+// scheduleSkip ends a run early without counting as a failure.
+type scheduleSkip struct {
+	reason string
+}
+
+func (s *scheduleSkip) Error() string { return s.reason }
+
+type backupTaskPayload struct {
+	Name string `json:"name"`
+	Keep int    `json:"keep"`
+}
+
+type waitStateTaskPayload struct {
+	State   string `json:"state"`
+	Timeout int    `json:"timeout"`
+}
+
+type deleteFilesTaskPayload struct {
+	Pattern        string `json:"pattern"`
+	OlderThanHours int    `json:"older_than_hours"`
+}
+
+type countdownTaskPayload struct {
+	Command string `json:"command"`
+	Seconds int    `json:"seconds"`
+	At      []int  `json:"at"`
+}
+
+type webhookTaskPayload struct {
+	URL     string            `json:"url"`
+	Method  string            `json:"method"`
+	Headers map[string]string `json:"headers"`
+	Body    string            `json:"body"`
+}
+
+type playersTaskPayload struct {
+	Command string `json:"command"`
+	Pattern string `json:"pattern"`
+}
+
+// taskPermissions lists the server permissions a task runs with, which the
+// user saving the schedule must hold.
+var taskPermissions = map[string][]string{
+	"delete_files": {models.PermFileDelete},
+	"backup":       {models.PermBackupCreate},
+}
+
+// ValidateScheduleTasks checks every task's action and payload so broken
+// schedules are rejected when saved rather than failing at run time. can
+// reports whether the user saving the schedule holds a server permission.
+func ValidateScheduleTasks(tasks []models.ScheduleTask, can func(perm string) bool) error {
+	for i, task := range tasks {
+		if err := validateTask(task, can); err != nil {
+			return fmt.Errorf("task %d (%s): %w", i+1, task.Action, err)
+		}
+	}
+	return nil
+}
+
+func validateTask(task models.ScheduleTask, can func(perm string) bool) error {
+	for _, perm := range taskPermissions[task.Action] {
+		if !can(perm) {
+			return fmt.Errorf("requires the %s permission", perm)
+		}
+	}
+
+	var err error
+	switch task.Action {
+	case "command":
+		if strings.TrimSpace(task.Payload) == "" {
+			return fmt.Errorf("command is required")
+		}
+	case "power":
+		switch task.Payload {
+		case "start", "stop", "restart", "kill":
+		default:
+			return fmt.Errorf("unknown power action %q", task.Payload)
+		}
+	case "delay":
+		_, err = decodeDelay(task.Payload)
+	case "wait_ready":
+		_, err = decodeWaitReady(task.Payload)
+	case "wait_state":
+		_, err = decodeWaitState(task.Payload)
+	case "backup":
+		var p backupTaskPayload
+		p, err = decodeBackupTask(task.Payload)
+		if err == nil && p.Keep > 0 && !can(models.PermBackupDelete) {
+			return fmt.Errorf("keep requires the %s permission", models.PermBackupDelete)
+		}
+	case "delete_files":
+		_, err = decodeDeleteFiles(task.Payload)
+	case "countdown":
+		_, err = decodeCountdown(task.Payload)
+	case "webhook":
+		_, err = decodeWebhook(task.Payload)
+	case "skip_if_players_online":
+		_, _, err = decodePlayersCheck(task.Payload)
+	default:
+		return fmt.Errorf("unknown action")
+	}
+	return err
+}
+
+func decodeTaskJSON(payload string, v interface{}) error {
+	if strings.TrimSpace(payload) == "" {
+		return nil
+	}
+	if err := json.Unmarshal([]byte(payload), v); err != nil {
+		return fmt.Errorf("payload must be a JSON object: %w", err)
+	}
+	return nil
+}
+
+func decodeDelay(payload string) (int, error) {
+	seconds, err := strconv.Atoi(strings.TrimSpace(payload))
+	if err != nil || seconds <= 0 || seconds > maxTaskDelay {
+		return 0, fmt.Errorf("delay must be between 1 and %d seconds", maxTaskDelay)
+	}
+	return seconds, nil
+}
+
+func decodeWaitReady(payload string) (int, error) {
+	if strings.TrimSpace(payload) == "" {
+		return defaultTaskWait, nil
+	}
+	seconds, err := strconv.Atoi(strings.TrimSpace(payload))
+	if err != nil || seconds <= 0 || seconds > maxTaskWait {
+		return 0, fmt.Errorf("timeout must be between 1 and %d seconds", maxTaskWait)
+	}
+	return seconds, nil
+}
+
+func decodeWaitState(payload string) (waitStateTaskPayload, error) {
+	p := waitStateTaskPayload{Timeout: defaultTaskWait}
+	if err := decodeTaskJSON(payload, &p); err != nil {
+		return p, err
+	}
+	switch p.State {
+	case "stopped", "running", "ready":
+	default:
+		return p, fmt.Errorf("state must be stopped, running or ready")
+	}
+	if p.Timeout <= 0 || p.Timeout > maxTaskWait {
+		return p, fmt.Errorf("timeout must be between 1 and %d seconds", maxTaskWait)
+	}
+	return p, nil
+}
+
+func decodeBackupTask(payload string) (backupTaskPayload, error) {
+	var p backupTaskPayload
+	if err := decodeTaskJSON(payload, &p); err != nil {
+		return p, err
+	}
+	if p.Name == "" {
+		p.Name = defaultBackupName
+	}
+	if len(p.Name) > 200 {
+		return p, fmt.Errorf("name is too long")
+	}
+	if p.Keep < 0 {
+		return p, fmt.Errorf("keep cannot be negative")
+	}
+	return p, nil
+}
+
+func decodeDeleteFiles(payload string) (deleteFilesTaskPayload, error) {
+	var p deleteFilesTaskPayload
+	if err := decodeTaskJSON(payload, &p); err != nil {
+		return p, err
+	}
+	pattern := strings.Trim(path.Clean("/"+p.Pattern), "/")
+	if !globHasLiteral(pattern[strings.LastIndex(pattern, "/")+1:]) {
+		return p, fmt.Errorf("pattern must not match every file")
+	}
+	if _, err := path.Match(strings.ReplaceAll(pattern, "**", "*"), ""); err != nil {
+		return p, fmt.Errorf("invalid pattern: %w", err)
+	}
+	if p.OlderThanHours < 0 {
+		return p, fmt.Errorf("older_than_hours cannot be negative")
+	}
+	p.Pattern = pattern
+	return p, nil
+}
+
+// globHasLiteral reports whether a glob segment names anything beyond
+// wildcards, character classes and dots, so "*.*" or "[!.]*" count as
+// matching every file.
+func globHasLiteral(segment string) bool {
+	for i := 0; i < len(segment); i++ {
+		switch segment[i] {
+		case '*', '?', '.':
+		case '[':
+			end := strings.IndexByte(segment[i+1:], ']')
+			if end < 0 {
+				return true
+			}
+			i += end + 1
+		case '\\':
+			if i+1 < len(segment) && segment[i+1] != '.' {
+				return true
+			}
+			i++
+		default:
+			return true
+		}
+	}
+	return false
+}
+
+func decodeCountdown(payload string) (countdownTaskPayload, error) {
+	var p countdownTaskPayload
+	if err := decodeTaskJSON(payload, &p); err != nil {
+		return p, err
+	}
+	if strings.TrimSpace(p.Command) == "" {
+		return p, fmt.Errorf("command is required")
+	}
+	if p.Seconds <= 0 || p.Seconds > maxTaskDelay {
+		return p, fmt.Errorf("seconds must be between 1 and %d", maxTaskDelay)
+	}
+	marks := p.At
+	if len(marks) == 0 {
+		marks = defaultCountdownMarks
+	}
+	seen := make(map[int]bool)
+	p.At = nil
+	for _, m := range marks {
+		if m <= 0 || m > p.Seconds || seen[m] {
+			continue
+		}
+		seen[m] = true
+		p.At = append(p.At, m)
+	}
+	if len(p.At) == 0 {
+		p.At = []int{p.Seconds}
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(p.At)))
+	return p, nil
+}
+
+func decodeWebhook(payload string) (webhookTaskPayload, error) {
+	var p webhookTaskPayload
+	if err := decodeTaskJSON(payload, &p); err != nil {
+		return p, err
+	}
+	u, err := url.Parse(p.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return p, fmt.Errorf("url must be an http or https URL")
+	}
+	if ip := net.ParseIP(u.Hostname()); (ip != nil && !publicWebhookIP(ip)) || strings.EqualFold(u.Hostname(), "localhost") {
+		return p, fmt.Errorf("url must point to a public address")
+	}
+	p.Method = strings.ToUpper(p.Method)
+	switch p.Method {
+	case "":
+		p.Method = http.MethodPost
+	case http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch:
+	default:
+		return p, fmt.Errorf("method must be GET, POST, PUT or PATCH")
+	}
+	return p, nil
+}
+
+func decodePlayersCheck(payload string) (playersTaskPayload, *regexp.Regexp, error) {
+	var p playersTaskPayload
+	if err := decodeTaskJSON(payload, &p); err != nil {
+		return p, nil, err
+	}
+	if p.Command == "" {
+		p.Command = defaultPlayerCommand
+	}
+	if p.Pattern == "" {
+		p.Pattern = defaultPlayerPattern
+	}
+	re, err := regexp.Compile(p.Pattern)
+	if err != nil {
+		return p, nil, fmt.Errorf("invalid pattern: %w", err)
+	}
+	if re.NumSubexp() < 1 {
+		return p, nil, fmt.Errorf("pattern must capture the player count in a group")
+	}
+	return p, re, nil
+}
+
+func executeTask(tc *taskContext, task models.ScheduleTask) error {
+	serverID := tc.server.ID
+	switch task.Action {
+	case "command":
+		return SendCommand(serverID, task.Payload)
+	case "power":
+		switch task.Payload {
+		case "start":
+			return SendStartServer(serverID)
+		case "stop":
+			return SendStopServer(serverID)
+		case "restart":
+			return SendRestartServer(serverID)
+		case "kill":
+			return SendKillServer(serverID)
+		default:
+			return fmt.Errorf("unknown power action %q", task.Payload)
+		}
+	case "delay":
+		seconds, err := decodeDelay(task.Payload)
+		if err != nil {
+			return err
+		}
+		time.Sleep(time.Duration(seconds) * time.Second)
+		return nil
+	case "wait_ready":
+		seconds, err := decodeWaitReady(task.Payload)
+		if err != nil {
+			return err
+		}
+		return waitForServerState(tc.server, "ready", time.Duration(seconds)*time.Second)
+	case "wait_state":
+		p, err := decodeWaitState(task.Payload)
+		if err != nil {
+			return err
+		}
+		return waitForServerState(tc.server, p.State, time.Duration(p.Timeout)*time.Second)
+	case "backup":
+		p, err := decodeBackupTask(task.Payload)
+		if err != nil {
+			return err
+		}
+		return runBackupTask(tc, p)
+	case "delete_files":
+		p, err := decodeDeleteFiles(task.Payload)
+		if err != nil {
+			return err
+		}
+		return nodeCall(tc.server, "POST", "/files/delete-matching", map[string]interface{}{
+			"pattern":    p.Pattern,
+			"older_than": p.OlderThanHours * 3600,
+		}, nil)
+	case "countdown":
+		p, err := decodeCountdown(task.Payload)
+		if err != nil {
+			return err
+		}
+		return runCountdown(tc, p)
+	case "webhook":
+		p, err := decodeWebhook(task.Payload)
+		if err != nil {
+			return err
+		}
+		return runWebhook(tc, p)
+	case "skip_if_players_online":
+		p, re, err := decodePlayersCheck(task.Payload)
+		if err != nil {
+			return err
+		}
+		return checkPlayersOnline(tc, p, re)
+	default:
+		return fmt.Errorf("unknown action %q", task.Action)
+	}
+}
+
+// nodeCall sends a request for the server to its node and decodes the reply
+// into out, turning error replies into a *NodeError.
+func nodeCall(server *models.Server, method, path string, body, out interface{}) error {
+	resp, err := ProxyToNode(server, method, fmt.Sprintf("/api/servers/%s%s", server.ID, path), body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 400 {
+		var result struct {
+			Error string `json:"error"`
+		}
+		json.Unmarshal(resp.Body, &result)
+		if result.Error != "" {
+			return &NodeError{StatusCode: resp.StatusCode, Message: "node error: " + result.Error}
+		}
+		return &NodeError{StatusCode: resp.StatusCode, Message: fmt.Sprintf("node returned status %d", resp.StatusCode)}
+	}
+	if out != nil {
+		return json.Unmarshal(resp.Body, out)
+	}
+	return nil
+}
+
+// waitForState is waitForServerState for callers holding only the ID.
+func waitForState(serverID uuid.UUID, want string, timeout time.Duration) error {
+	var server models.Server
+	if err := database.DB.Where("id = ?", serverID).First(&server).Error; err != nil {
+		return fmt.Errorf("server not found")
+	}
+	return waitForServerState(&server, want, timeout)
+}
+
+// waitForServerState blocks until the server reaches want, asking its node for
+// the live state since the stored one lags behind power actions. "running"
+// accepts a server that is still starting, "ready" waits for the node to
+// report it as running, which for packages with done patterns means startup
+// has finished. An offline server only fails a running or ready wait once
+// the node had time to report a start.
+func waitForServerState(server *models.Server, want string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	grace := time.Now().Add(15 * time.Second)
+	for {
+		var reply struct {
+			Data struct {
+				State string `json:"state"`
+			} `json:"data"`
+		}
+		err := nodeCall(server, "GET", "/state", nil, &reply)
+		state := reply.Data.State
+		switch {
+		case err != nil:
+		case want == "stopped" && state == "offline":
+			return nil
+		case want == "running" && (state == "starting" || state == "running"):
+			return nil
+		case want == "ready" && state == "running":
+			return nil
+		case want != "stopped" && state == "offline" && time.Now().After(grace):
+			return fmt.Errorf("server is offline")
+		}
+		if time.Now().After(deadline) {
+			if err != nil {
+				return fmt.Errorf("server was not %s after %s: %w", want, timeout, err)
+			}
+			return fmt.Errorf("server was not %s after %s", want, timeout)
+		}
+		time.Sleep(2 * time.Second)
+	}
+}
+
+func (tc *taskContext) render(s string) string {
+	return strings.NewReplacer(
+		"{server}", tc.server.Name,
+		"{server_id}", tc.server.ID.String(),
+		"{schedule}", tc.schedule.Name,
+		"{schedule_id}", tc.schedule.ID.String(),
+		"{run_id}", tc.runID.String(),
+		"{date}", tc.now.Format("2006-01-02"),
+		"{time}", tc.now.Format("15:04:05"),
+		"{timestamp}", strconv.FormatInt(tc.now.Unix(), 10),
+	).Replace(s)
+}
+
+type nodeBackup struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	CreatedAt int64  `json:"created_at"`
+	Completed bool   `json:"completed"`
+}
+
+// backupID mirrors how Axis turns a backup name into its file name.
+func backupID(name string) string {
+	return strings.NewReplacer(" ", "-", ":", "-", "/", "-", "\\", "-").Replace(name)
+}
+
+var backupTimePlaceholder = regexp.MustCompile(`\{(date|time|timestamp)\}`)
+
+// backupNamePattern matches the IDs of backups made from a name template by
+// this schedule, whatever date and time they were taken at.
+func (tc *taskContext) backupNamePattern(template string) *regexp.Regexp {
+	fixed := strings.NewReplacer(
+		"{server}", tc.server.Name,
+		"{server_id}", tc.server.ID.String(),
+		"{schedule}", tc.schedule.Name,
+		"{schedule_id}", tc.schedule.ID.String(),
+	).Replace(template)
+	var expr strings.Builder
+	expr.WriteString("^")
+	last := 0
+	for _, loc := range backupTimePlaceholder.FindAllStringIndex(fixed, -1) {
+		expr.WriteString(regexp.QuoteMeta(backupID(fixed[last:loc[0]])))
+		expr.WriteString(".+")
+		last = loc[1]
+	}
+	expr.WriteString(regexp.QuoteMeta(backupID(fixed[last:])))
+	expr.WriteString("$")
+	return regexp.MustCompile(expr.String())
+}
+
+func runBackupTask(tc *taskContext, p backupTaskPayload) error {
+	name := strings.NewReplacer("/", "-", "\\", "-").Replace(tc.render(p.Name))
+	var created struct {
+		Data nodeBackup `json:"data"`
+	}
+	if err := nodeCall(tc.server, "POST", "/backups", map[string]string{"name": name}, &created); err != nil {
+		return err
+	}
+	if err := waitForBackup(tc.server, created.Data.ID); err != nil {
+		return err
+	}
+	if p.Keep > 0 {
+		return pruneScheduledBackups(tc, p)
+	}
+	return nil
+}
+
+func listNodeBackups(server *models.Server) ([]nodeBackup, error) {
+	var list struct {
+		Data []nodeBackup `json:"data"`
+	}
+	if err := nodeCall(server, "GET", "/backups", nil, &list); err != nil {
+		return nil, err
+	}
+	return list.Data, nil
+}
+
+func waitForBackup(server *models.Server, id string) error {
+	deadline := time.Now().Add(backupTaskTimeout)
+	for time.Now().Before(deadline) {
+		time.Sleep(5 * time.Second)
+		backups, err := listNodeBackups(server)
+		if err != nil {
+			return err
+		}
+		found := false
+		for _, b := range backups {
+			if b.ID != id {
+				continue
+			}
+			if b.Completed {
+				return nil
+			}
+			found = true
+		}
+		if !found {
+			return fmt.Errorf("backup %s failed, see the server console", id)
+		}
+	}
+	return fmt.Errorf("backup %s did not finish within %s", id, backupTaskTimeout)
+}
+
+// pruneScheduledBackups deletes the oldest completed backups whose names fit
+// the task's template until only keep remain. Other backups are left alone.
+func pruneScheduledBackups(tc *taskContext, p backupTaskPayload) error {
+	backups, err := listNodeBackups(tc.server)
+	if err != nil {
+		return err
+	}
+	pattern := tc.backupNamePattern(p.Name)
+	var matched []nodeBackup
+	for _, b := range backups {
+		if b.Completed && pattern.MatchString(b.ID) {
+			matched = append(matched, b)
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool { return matched[i].CreatedAt > matched[j].CreatedAt })
+	if len(matched) <= p.Keep {
+		return nil
+	}
+	for _, b := range matched[p.Keep:] {
+		if err := nodeCall(tc.server, "DELETE", "/backups/"+url.PathEscape(b.ID), nil, nil); err != nil {
+			return fmt.Errorf("failed to delete old backup %s: %w", b.ID, err)
+		}
+	}
+	return nil
+}
+
+func formatRemaining(seconds int) string {
+	unit, n := "second", seconds
+	if seconds >= 60 && seconds%60 == 0 {
+		unit, n = "minute", seconds/60
+	}
+	if n != 1 {
+		unit += "s"
+	}
+	return fmt.Sprintf("%d %s", n, unit)
+}
+
+// runCountdown sends the command at each mark before the countdown ends and
+// returns once it has run out.
+func runCountdown(tc *taskContext, p countdownTaskPayload) error {
+	end := time.Now().Add(time.Duration(p.Seconds) * time.Second)
+	for _, mark := range p.At {
+		time.Sleep(time.Until(end.Add(-time.Duration(mark) * time.Second)))
+		command := strings.NewReplacer(
+			"{remaining}", formatRemaining(mark),
+			"{seconds}", strconv.Itoa(mark),
+		).Replace(tc.render(p.Command))
+		if err := SendCommand(tc.server.ID, command); err != nil {
+			return err
+		}
+	}
+	time.Sleep(time.Until(end))
+	return nil
+}
+
+func runWebhook(tc *taskContext, p webhookTaskPayload) error {
+	body := tc.render(p.Body)
+	if p.Body == "" && p.Method != http.MethodGet {
+		data, _ := json.Marshal(map[string]interface{}{
+			"event":         "schedule.task",
+			"server_id":     tc.server.ID,
+			"server_name":   tc.server.Name,
+			"schedule_id":   tc.schedule.ID,
+			"schedule_name": tc.schedule.Name,
+			"run_id":        tc.runID,
+			"at":            tc.now,
+		})
+		body = string(data)
+	}
+	req, err := http.NewRequest(p.Method, tc.render(p.URL), bytes.NewReader([]byte(body)))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Birdactyl-Scheduler")
+	for k, v := range p.Headers {
+		req.Header.Set(k, tc.render(v))
+	}
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook failed: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// checkPlayersOnline asks the server for its player list through the console
+// and skips the rest of the run when anyone is on. Offline servers have no
+// players.
+func checkPlayersOnline(tc *taskContext, p playersTaskPayload, re *regexp.Regexp) error {
+	stats := GetServerStats(tc.server.ID)
+	if stats == nil || stats.State != "running" {
+		return nil
+	}
+	var reply struct {
+		Output []string `json:"output"`
+	}
+	if err := nodeCall(tc.server, "POST", "/command", map[string]interface{}{
+		"command":    p.Command,
+		"capture_ms": playerCaptureMs,
+	}, &reply); err != nil {
+		return err
+	}
+	for i := len(reply.Output) - 1; i >= 0; i-- {
+		m := re.FindStringSubmatch(reply.Output[i])
+		if m == nil {
+			continue
+		}
+		count, err := strconv.Atoi(m[1])
+		if err != nil {
+			return fmt.Errorf("player count %q is not a number", m[1])
+		}
+		if count > 0 {
+			return &scheduleSkip{reason: fmt.Sprintf("%d player(s) online", count)}
+		}
+		return nil
+	}
+	return fmt.Errorf("no line of the %q output matched the player count pattern", p.Command)
+}
@@ -2,105 +2,202 @@ package services
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"sync"
 	"time"
 
+	"birdactyl-panel-backend/internal/config"
 	"birdactyl-panel-backend/internal/database"
 	"birdactyl-panel-backend/internal/models"
+	"birdactyl-panel-backend/internal/state"
 
 	"github.com/google/uuid"
 	"github.com/robfig/cron/v3"
 )
 
+const (
+	scheduleRunRetention  = 50
+	schedulePollInterval  = time.Second
+	scheduleMissedAfter   = 30 * time.Second
+	runHeartbeatInterval  = 30 * time.Second
+	runHeartbeatExpiry    = 2 * time.Minute
+	maxCatchUpSlotLookups = 100000
+)
+
 var (
-	scheduler     *cron.Cron
+	schedulerStop chan struct{}
 	schedulerOnce sync.Once
-	entryMap      = make(map[uuid.UUID]cron.EntryID)
-	entryMapMu    sync.RWMutex
+	cronParser    = cron.NewParser(cron.Second | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
 )
 
+// InitScheduler starts polling the database for due schedules. Every panel
+// instance sharing the database polls, and each run is claimed by exactly one
+// of them.
 func InitScheduler() {
 	schedulerOnce.Do(func() {
-		scheduler = cron.New(cron.WithSeconds())
-		scheduler.Start()
+		schedulerStop = make(chan struct{})
+		failInterruptedRuns()
+		loadExistingSchedules()
+		go runScheduler(schedulerStop)
 		log.Println("[scheduler] started")
-		go loadExistingSchedules()
 	})
 }
 
 func StopScheduler() {
-	if scheduler != nil {
-		scheduler.Stop()
+	if schedulerStop != nil {
+		close(schedulerStop)
+	}
+}
+
+func runScheduler(stop chan struct{}) {
+	poll := time.NewTicker(schedulePollInterval)
+	defer poll.Stop()
+	sweep := time.NewTicker(time.Minute)
+	defer sweep.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-poll.C:
+			pollDueSchedules()
+		case <-sweep.C:
+			failInterruptedRuns()
+		}
 	}
 }
 
+// loadExistingSchedules gives active schedules without a next run one. Ones
+// that already have a next run keep it, so runs missed while every panel was
+// down are still seen as due.
 func loadExistingSchedules() {
 	var schedules []models.Schedule
-	database.DB.Where("is_active = ?", true).Find(&schedules)
-	for _, s := range schedules {
-		RegisterSchedule(&s)
+	database.DB.Where("is_active = ? AND next_run_at IS NULL", true).Find(&schedules)
+	for i := range schedules {
+		if err := scheduleNextRun(&schedules[i]); err != nil {
+			log.Printf("[scheduler] schedule %s: %v", schedules[i].ID, err)
+		}
 	}
 }
 
-func RegisterSchedule(s *models.Schedule) error {
-	if scheduler == nil || !s.IsActive {
-		return nil
+func ValidateCronExpression(expr string) error {
+	if _, err := cronParser.Parse(expr); err != nil {
+		return fmt.Errorf("invalid cron expression: %w", err)
 	}
+	return nil
+}
 
-	entryMapMu.Lock()
-	if oldID, exists := entryMap[s.ID]; exists {
-		scheduler.Remove(oldID)
+// scheduleNextRun sets next_run_at from now, or clears it for inactive
+// schedules.
+func scheduleNextRun(s *models.Schedule) error {
+	if !s.IsActive {
+		s.NextRunAt = nil
+		return database.DB.Model(&models.Schedule{}).Where("id = ?", s.ID).Update("next_run_at", nil).Error
 	}
-	entryMapMu.Unlock()
-
-	entryID, err := scheduler.AddFunc(s.CronExpression, func() {
-		executeSchedule(s.ID)
-	})
+	sched, err := cronParser.Parse(s.CronExpression)
 	if err != nil {
 		return err
 	}
+	next := sched.Next(time.Now())
+	s.NextRunAt = &next
+	return database.DB.Model(&models.Schedule{}).Where("id = ?", s.ID).Update("next_run_at", next).Error
+}
+
+func pollDueSchedules() {
+	now := time.Now()
+	var due []models.Schedule
+	if err := database.DB.Where("is_active = ? AND next_run_at <= ?", true, now).Find(&due).Error; err != nil {
+		return
+	}
+	for i := range due {
+		claimScheduleRun(&due[i], now)
+	}
+}
 
-	entryMapMu.Lock()
-	entryMap[s.ID] = entryID
-	entryMapMu.Unlock()
+// claimScheduleRun moves a due schedule's next_run_at past the slot it holds.
+// The update only matches while next_run_at still holds that slot, so when
+// several panels poll the same database exactly one of them wins each run.
+func claimScheduleRun(s *models.Schedule, now time.Time) {
+	due := *s.NextRunAt
+	sched, err := cronParser.Parse(s.CronExpression)
+	if err != nil {
+		log.Printf("[scheduler] schedule %s has an invalid cron expression: %v", s.ID, err)
+		database.DB.Model(&models.Schedule{}).Where("id = ? AND next_run_at = ?", s.ID, due).Update("next_run_at", nil)
+		return
+	}
 
-	entry := scheduler.Entry(entryID)
-	nextRun := entry.Next
-	database.DB.Model(&models.Schedule{}).Where("id = ?", s.ID).Update("next_run_at", nextRun)
+	trigger := models.ScheduleTriggerCron
+	next := sched.Next(due)
+	if now.Sub(due) > scheduleMissedAfter {
+		next = sched.Next(now)
+		trigger = ""
+		if catchUpMissed(sched, due, now) {
+			trigger = models.ScheduleTriggerCatchUp
+		}
+	}
 
-	return nil
+	res := database.DB.Model(&models.Schedule{}).Where("id = ? AND next_run_at = ?", s.ID, due).Update("next_run_at", next)
+	if res.Error != nil || res.RowsAffected != 1 {
+		return
+	}
+	if trigger == "" {
+		log.Printf("[scheduler] schedule %s missed its run at %s", s.ID, due.Format(time.RFC3339))
+		return
+	}
+	go executeSchedule(s.ID, trigger, nil)
 }
 
-func UnregisterSchedule(id uuid.UUID) {
-	entryMapMu.Lock()
-	defer entryMapMu.Unlock()
-	if entryID, exists := entryMap[id]; exists {
-		scheduler.Remove(entryID)
-		delete(entryMap, id)
+// catchUpMissed reports whether a schedule whose runs from due onwards were
+// missed should run once now, following the configured catch-up policy.
+func catchUpMissed(sched cron.Schedule, due, now time.Time) bool {
+	cfg := config.Get()
+	if cfg == nil || cfg.Scheduler.CatchUp != config.CatchUpOnce {
+		return false
 	}
+	if cfg.Scheduler.CatchUpWindow <= 0 {
+		return true
+	}
+	last := due
+	for i := 0; i < maxCatchUpSlotLookups; i++ {
+		t := sched.Next(last)
+		if t.After(now) {
+			break
+		}
+		last = t
+	}
+	return now.Sub(last) <= time.Duration(cfg.Scheduler.CatchUpWindow)*time.Second
 }
 
-func executeSchedule(scheduleID uuid.UUID) {
+// executeSchedule runs a schedule's tasks in order and records the outcome
+// as a ScheduleRun. run is created here unless the caller already has one.
+func executeSchedule(scheduleID uuid.UUID, trigger string, run *models.ScheduleRun) {
 	var schedule models.Schedule
 	if err := database.DB.First(&schedule, "id = ?", scheduleID).Error; err != nil {
+		if run != nil {
+			finishScheduleRun(run, models.ScheduleRunFailed, "schedule not found", nil)
+		}
 		return
 	}
 
-	if !schedule.IsActive {
+	if !schedule.IsActive && (trigger == models.ScheduleTriggerCron || trigger == models.ScheduleTriggerCatchUp) {
 		return
 	}
 
-	var server models.Server
-	if err := database.DB.First(&server, "id = ?", schedule.ServerID).Error; err != nil {
-		return
+	if run == nil {
+		run = newScheduleRun(&schedule, trigger)
+		if err := database.DB.Create(run).Error; err != nil {
+			log.Printf("[scheduler] failed to record run for %s: %v", scheduleID, err)
+		}
 	}
+	stopHeartbeat := make(chan struct{})
+	defer close(stopHeartbeat)
+	go heartbeatRun(run.ID, stopHeartbeat)
 
 	if schedule.OnlyWhenOnline {
 		stats := GetServerStats(schedule.ServerID)
 		if stats == nil || stats.State != "running" {
-			updateNextRun(scheduleID)
+			finishScheduleRun(run, models.ScheduleRunSkipped, "server is not running", nil)
 			return
 		}
 	}
@@ -108,56 +205,146 @@ func executeSchedule(scheduleID uuid.UUID) {
 	var tasks []models.ScheduleTask
 	json.Unmarshal(schedule.Tasks, &tasks)
 
-	for _, task := range tasks {
-		executeTask(schedule.ServerID, task)
+	var server models.Server
+	if err := database.DB.First(&server, "id = ?", schedule.ServerID).Error; err != nil {
+		finishScheduleRun(run, models.ScheduleRunFailed, "server not found", nil)
+		return
+	}
+	tc := &taskContext{server: &server, schedule: &schedule, runID: run.ID, now: run.StartedAt}
+
+	results := make([]models.ScheduleTaskResult, 0, len(tasks))
+	status := models.ScheduleRunSucceeded
+	var runErr string
+	for i, task := range tasks {
+		started := time.Now()
+		err := executeTask(tc, task)
+		result := models.ScheduleTaskResult{
+			Sequence:   task.Sequence,
+			Action:     task.Action,
+			Status:     models.ScheduleRunSucceeded,
+			DurationMs: time.Since(started).Milliseconds(),
+		}
+		var skip *scheduleSkip
+		if err != nil && !errors.As(err, &skip) {
+			result.Status = models.ScheduleRunFailed
+			result.Error = err.Error()
+		}
+		results = append(results, result)
+		if err == nil {
+			continue
+		}
+		if skip == nil && task.ContinueOnFailure {
+			status = models.ScheduleRunPartial
+			continue
+		}
+		if skip != nil {
+			status, runErr = models.ScheduleRunSkipped, skip.reason
+		} else {
+			status = models.ScheduleRunFailed
+			runErr = fmt.Sprintf("task %d (%s) failed: %v", task.Sequence, task.Action, err)
+			log.Printf("[scheduler] schedule %s stopped: %s", scheduleID, runErr)
+		}
+		for _, rest := range tasks[i+1:] {
+			results = append(results, models.ScheduleTaskResult{Sequence: rest.Sequence, Action: rest.Action, Status: models.ScheduleRunSkipped})
+		}
+		break
 	}
 
+	finishScheduleRun(run, status, runErr, results)
+
 	now := time.Now()
 	database.DB.Model(&schedule).Update("last_run_at", now)
-	updateNextRun(scheduleID)
-}
-
-func executeTask(serverID uuid.UUID, task models.ScheduleTask) error {
-	switch task.Action {
-	case "command":
-		return SendCommand(serverID, task.Payload)
-	case "power":
-		switch task.Payload {
-		case "start":
-			return SendStartServer(serverID)
-		case "stop":
-			return SendStopServer(serverID)
-		case "restart":
-			return SendRestartServer(serverID)
-		case "kill":
-			return SendKillServer(serverID)
-		}
-	case "delay":
-		var seconds int
-		fmt.Sscanf(task.Payload, "%d", &seconds)
-		if seconds > 0 && seconds <= 300 {
-			time.Sleep(time.Duration(seconds) * time.Second)
+}
+
+func newScheduleRun(schedule *models.Schedule, trigger string) *models.ScheduleRun {
+	now := time.Now()
+	return &models.ScheduleRun{
+		ScheduleID:  schedule.ID,
+		ServerID:    schedule.ServerID,
+		Trigger:     trigger,
+		Status:      models.ScheduleRunRunning,
+		Instance:    state.InstanceID(),
+		StartedAt:   now,
+		HeartbeatAt: &now,
+	}
+}
+
+func finishScheduleRun(run *models.ScheduleRun, status, errMsg string, results []models.ScheduleTaskResult) {
+	if results == nil {
+		results = []models.ScheduleTaskResult{}
+	}
+	tasksJSON, _ := json.Marshal(results)
+	now := time.Now()
+	run.Status, run.Error, run.Tasks, run.FinishedAt = status, errMsg, tasksJSON, &now
+	database.DB.Model(run).Updates(map[string]interface{}{
+		"status":      status,
+		"error":       errMsg,
+		"tasks":       tasksJSON,
+		"finished_at": now,
+	})
+	pruneScheduleRuns(run.ScheduleID)
+}
+
+// pruneScheduleRuns keeps the most recent runs of a schedule.
+func pruneScheduleRuns(scheduleID uuid.UUID) {
+	var cutoff models.ScheduleRun
+	err := database.DB.Select("started_at").Where("schedule_id = ?", scheduleID).
+		Order("started_at desc").Offset(scheduleRunRetention).First(&cutoff).Error
+	if err != nil {
+		return
+	}
+	database.DB.Where("schedule_id = ? AND started_at <= ?", scheduleID, cutoff.StartedAt).Delete(&models.ScheduleRun{})
+}
+
+// heartbeatRun keeps a running run's heartbeat fresh until stop is closed,
+// so other instances can tell it apart from one whose panel went away.
+func heartbeatRun(runID uuid.UUID, stop chan struct{}) {
+	ticker := time.NewTicker(runHeartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			database.DB.Model(&models.ScheduleRun{}).Where("id = ?", runID).Update("heartbeat_at", now)
 		}
-	case "backup":
-		return CreateServerArchive(serverID)
 	}
-	return nil
 }
 
-func updateNextRun(scheduleID uuid.UUID) {
-	entryMapMu.RLock()
-	entryID, exists := entryMap[scheduleID]
-	entryMapMu.RUnlock()
+// failInterruptedRuns closes runs whose panel stopped heartbeating them,
+// whichever instance that was.
+func failInterruptedRuns() {
+	now := time.Now()
+	database.DB.Model(&models.ScheduleRun{}).
+		Where("status = ? AND (heartbeat_at IS NULL OR heartbeat_at < ?)", models.ScheduleRunRunning, now.Add(-runHeartbeatExpiry)).
+		Updates(map[string]interface{}{
+			"status":      models.ScheduleRunFailed,
+			"error":       "interrupted: the panel running it stopped",
+			"finished_at": now,
+		})
+}
 
-	if exists && scheduler != nil {
-		entry := scheduler.Entry(entryID)
-		database.DB.Model(&models.Schedule{}).Where("id = ?", scheduleID).Update("next_run_at", entry.Next)
+// RunScheduleNow starts a schedule outside its cron timing and returns the
+// run record so callers can follow it.
+func RunScheduleNow(scheduleID uuid.UUID, trigger string) (*models.ScheduleRun, error) {
+	schedule, err := GetScheduleByID(scheduleID)
+	if err != nil {
+		return nil, err
 	}
+	run := newScheduleRun(schedule, trigger)
+	if err := database.DB.Create(run).Error; err != nil {
+		return nil, err
+	}
+	go executeSchedule(scheduleID, trigger, run)
+	return run, nil
 }
 
-func RunScheduleNow(scheduleID uuid.UUID) error {
-	go executeSchedule(scheduleID)
-	return nil
+func GetScheduleRuns(scheduleID uuid.UUID, limit, offset int) ([]models.ScheduleRun, int64, error) {
+	var total int64
+	database.DB.Model(&models.ScheduleRun{}).Where("schedule_id = ?", scheduleID).Count(&total)
+	var runs []models.ScheduleRun
+	err := database.DB.Where("schedule_id = ?", scheduleID).Order("started_at desc").Limit(limit).Offset(offset).Find(&runs).Error
+	return runs, total, err
 }
 
 func GetSchedulesByServer(serverID uuid.UUID) ([]models.Schedule, error) {
@@ -180,7 +367,7 @@ func CreateSchedule(schedule *models.Schedule) error {
 		return err
 	}
 	if schedule.IsActive {
-		RegisterSchedule(schedule)
+		return scheduleNextRun(schedule)
 	}
 	return nil
 }
@@ -197,15 +384,14 @@ func UpdateSchedule(id uuid.UUID, updates map[string]interface{}) (*models.Sched
 
 	database.DB.First(&schedule, "id = ?", id)
 
-	UnregisterSchedule(id)
-	if schedule.IsActive {
-		RegisterSchedule(&schedule)
+	if err := scheduleNextRun(&schedule); err != nil {
+		return nil, err
 	}
 
 	return &schedule, nil
 }
 
 func DeleteSchedule(id uuid.UUID) error {
-	UnregisterSchedule(id)
+	database.DB.Where("schedule_id = ?", id).Delete(&models.ScheduleRun{})
 	return database.DB.Delete(&models.Schedule{}, "id = ?", id).Error
 }
@@ -3,21 +3,19 @@ package services
 import (
 	"encoding/json"
 	"errors"
-	"math/rand"
-	"sync"
+	"time"
 
 	"birdactyl-panel-backend/internal/database"
 	"birdactyl-panel-backend/internal/models"
 
 	"github.com/google/uuid"
-	"gorm.io/datatypes"
+	"gorm.io/gorm"
 )
 
 var (
-	ErrServerNotFound   = errors.New("server not found")
-	ErrNodeOffline      = errors.New("node is offline")
-	ErrServerSuspended  = errors.New("server is suspended")
-	portAllocationMu    sync.Mutex
+	ErrServerNotFound  = errors.New("server not found")
+	ErrNodeOffline     = errors.New("node is offline")
+	ErrServerSuspended = errors.New("server is suspended")
 )
 
 type ResourceUsage struct {
@@ -50,36 +48,7 @@ type CreateServerRequest struct {
 	Disk        int               `json:"disk"`
 	Ports       []models.ServerPort `json:"ports"`
 	Variables   map[string]string `json:"variables"`
-}
-
-func allocatePort(nodeID uuid.UUID) int {
-	portAllocationMu.Lock()
-	defer portAllocationMu.Unlock()
-
-	var servers []models.Server
-	database.DB.Where("node_id = ?", nodeID).Find(&servers)
-	
-	usedPorts := make(map[int]bool)
-	for _, s := range servers {
-		var ports []models.ServerPort
-		json.Unmarshal(s.Ports, &ports)
-		for _, p := range ports {
-			usedPorts[p.Port] = true
-		}
-	}
-	
-	for attempts := 0; attempts < 1000; attempts++ {
-		port := 25565 + rand.Intn(4436)
-		if !usedPorts[port] {
-			return port
-		}
-	}
-	for port := 25565; port <= 30000; port++ {
-		if !usedPorts[port] {
-			return port
-		}
-	}
-	return 25565
+	IsAdmin     bool              `json:"-"`
 }
 
 func CreateServer(userID uuid.UUID, req CreateServerRequest) (*models.Server, error) {
@@ -96,28 +65,36 @@ func CreateServer(userID uuid.UUID, req CreateServerRequest) (*models.Server, er
 		return nil, ErrPackageNotFound
 	}
 
-	for i := range req.Ports {
-		req.Ports[i].Port = allocatePort(req.NodeID)
+	if err := ValidateServerVariables(&pkg, req.Variables, nil, req.IsAdmin); err != nil {
+		return nil, err
 	}
 
-	portsJSON, _ := json.Marshal(req.Ports)
 	varsJSON, _ := json.Marshal(req.Variables)
 
 	server := &models.Server{
-		Name:        req.Name,
-		Description: req.Description,
-		UserID:      userID,
-		NodeID:      req.NodeID,
-		PackageID:   req.PackageID,
-		Status:      models.ServerStatusInstalling,
-		Memory:      req.Memory,
-		CPU:         req.CPU,
-		Disk:        req.Disk,
-		Ports:       portsJSON,
-		Variables:   varsJSON,
-	}
-
-	if err := database.DB.Create(server).Error; err != nil {
+		ID:              uuid.New(),
+		Name:            req.Name,
+		Description:     req.Description,
+		UserID:          userID,
+		NodeID:          req.NodeID,
+		PackageID:       req.PackageID,
+		PackageRevision: pkg.Revision,
+		Status:          models.ServerStatusInstalling,
+		Memory:          req.Memory,
+		CPU:             req.CPU,
+		Disk:            req.Disk,
+		Variables:       varsJSON,
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		ports, err := assignAllocations(tx, req.NodeID, server.ID, req.Ports)
+		if err != nil {
+			return err
+		}
+		server.Ports, _ = json.Marshal(ports)
+		return tx.Create(server).Error
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -201,8 +178,7 @@ func DeleteServer(serverID, userID uuid.UUID, isAdmin bool) error {
 	database.DB.Where("server_id = ?", serverID).Delete(&models.Subuser{})
 	database.DB.Where("server_id = ?", serverID).Delete(&models.Schedule{})
 	
-	result := database.DB.Where("id = ?", serverID).Delete(&models.Server{})
-	return result.Error
+	return deleteServerRow(serverID)
 }
 
 func AddAllocation(serverID, userID uuid.UUID, isAdmin bool) (*models.Server, error) {
@@ -214,13 +190,25 @@ func AddAllocation(serverID, userID uuid.UUID, isAdmin bool) (*models.Server, er
 	var ports []models.ServerPort
 	json.Unmarshal(server.Ports, &ports)
 
-	newPort := allocatePort(server.NodeID)
-	ports = append(ports, models.ServerPort{Port: newPort, Primary: false})
-
-	portsJSON, _ := json.Marshal(ports)
-	server.Ports = portsJSON
+	var ip string
+	taken := make([]int, 0, len(ports))
+	for _, p := range ports {
+		if p.Primary || ip == "" {
+			ip = p.IP
+		}
+		taken = append(taken, p.Port)
+	}
 
-	if err := database.DB.Save(server).Error; err != nil {
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		a, err := claimAllocation(tx, server.NodeID, server.ID, ip, taken)
+		if err != nil {
+			return err
+		}
+		ports = append(ports, models.ServerPort{IP: a.IP, Port: a.Port, Primary: false})
+		server.Ports, _ = json.Marshal(ports)
+		return tx.Save(server).Error
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -289,7 +277,13 @@ func DeleteAllocation(serverID, userID uuid.UUID, port int, isAdmin bool) (*mode
 	portsJSON, _ := json.Marshal(ports)
 	server.Ports = portsJSON
 
-	if err := database.DB.Save(server).Error; err != nil {
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Allocation{}).Where("server_id = ? AND port = ?", server.ID, port).Update("server_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Save(server).Error
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -363,6 +357,28 @@ func UpdateServerVariables(serverID, userID uuid.UUID, variables map[string]stri
 		return nil, err
 	}
 
+	if variables == nil {
+		variables = map[string]string{}
+	}
+	if server.Package != nil {
+		pkg, err := ResolveServerPackage(server)
+		if err != nil {
+			return nil, err
+		}
+		var current map[string]string
+		json.Unmarshal(server.Variables, &current)
+		if err := ValidateServerVariables(pkg, variables, current, isAdmin); err != nil {
+			return nil, err
+		}
+		if !isAdmin {
+			KeepLockedVariables(pkg, variables, current)
+		}
+		dockerImage, err = SelectDockerImage(pkg, dockerImage, server.DockerImage, isAdmin)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	varsJSON, _ := json.Marshal(variables)
 	server.Variables = varsJSON
 	server.Startup = startup
@@ -380,11 +396,25 @@ func SuspendServer(serverID uuid.UUID) error {
 }
 
 func DeleteServerAdmin(serverID uuid.UUID) error {
-	result := database.DB.Where("id = ?", serverID).Delete(&models.Server{})
-	if result.RowsAffected == 0 {
-		return ErrServerNotFound
-	}
-	return result.Error
+	return deleteServerRow(serverID)
+}
+
+// deleteServerRow frees the server's allocations and deletes it in one
+// transaction, so a failed delete never leaves a server without its ports.
+func deleteServerRow(serverID uuid.UUID) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := releaseAllocations(tx, serverID); err != nil {
+			return err
+		}
+		result := tx.Where("id = ?", serverID).Delete(&models.Server{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrServerNotFound
+		}
+		return nil
+	})
 }
 
 func UnsuspendServer(serverID uuid.UUID) error {
@@ -399,14 +429,44 @@ func IsServerSuspended(serverID uuid.UUID) (bool, error) {
 	return server.IsSuspended, nil
 }
 
-func AllocatePortsForNode(nodeID uuid.UUID, existingPorts datatypes.JSON) datatypes.JSON {
-	var ports []models.ServerPort
-	json.Unmarshal(existingPorts, &ports)
-
-	for i := range ports {
-		ports[i].Port = allocatePort(nodeID)
+// RecordServerState stores a power state reported by the server's node.
+// Reports older than the stored one are ignored since they can arrive out
+// of order. It returns false when the report was stale.
+func RecordServerState(nodeID, serverID uuid.UUID, state string, at time.Time) (bool, error) {
+	result := database.DB.Model(&models.Server{}).
+		Where("id = ? AND node_id = ? AND (power_state_at IS NULL OR power_state_at <= ?)", serverID, nodeID, at).
+		Updates(map[string]interface{}{"power_state": state, "power_state_at": at})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		var count int64
+		database.DB.Model(&models.Server{}).Where("id = ? AND node_id = ?", serverID, nodeID).Count(&count)
+		if count == 0 {
+			return false, ErrServerNotFound
+		}
+		return false, nil
 	}
+	return true, nil
+}
 
-	newPorts, _ := json.Marshal(ports)
-	return newPorts
+func FinishServerInstall(nodeID, serverID uuid.UUID, success bool) error {
+	status := models.ServerStatusStopped
+	if !success {
+		status = models.ServerStatusFailed
+	}
+	result := database.DB.Model(&models.Server{}).
+		Where("id = ? AND node_id = ? AND status = ?", serverID, nodeID, models.ServerStatusInstalling).
+		Update("status", status)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		var count int64
+		database.DB.Model(&models.Server{}).Where("id = ? AND node_id = ?", serverID, nodeID).Count(&count)
+		if count == 0 {
+			return ErrServerNotFound
+		}
+	}
+	return nil
 }
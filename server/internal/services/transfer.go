@@ -1,68 +1,95 @@
 package services
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"net/http"
 	"sync"
 	"time"
 
 	"birdactyl-panel-backend/internal/database"
 	"birdactyl-panel-backend/internal/models"
+	"birdactyl-panel-backend/internal/state"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
-type TransferStage string
-
 const (
-	TransferStagePending     TransferStage = "pending"
-	TransferStageStopping    TransferStage = "stopping"
-	TransferStageArchiving   TransferStage = "archiving"
-	TransferStageDownloading TransferStage = "downloading"
-	TransferStageUploading   TransferStage = "uploading"
-	TransferStageImporting   TransferStage = "importing"
-	TransferStageCleanup     TransferStage = "cleanup"
-	TransferStageComplete    TransferStage = "complete"
-	TransferStageFailed      TransferStage = "failed"
+	transferHeartbeatInterval = 30 * time.Second
+	transferHeartbeatExpiry   = 2 * time.Minute
+	transferPollInterval      = 2 * time.Second
+	transferStopTimeout       = 5 * time.Minute
+	transferHistory           = 24 * time.Hour
+	maxPreSyncPasses          = 3
+	preSyncSettledBytes       = 64 << 20
 )
 
-type TransferStatus struct {
-	ID           string        `json:"id"`
-	ServerID     string        `json:"server_id"`
-	ServerName   string        `json:"server_name"`
-	FromNodeID   string        `json:"from_node_id"`
-	FromNodeName string        `json:"from_node_name"`
-	ToNodeID     string        `json:"to_node_id"`
-	ToNodeName   string        `json:"to_node_name"`
-	Stage        TransferStage `json:"stage"`
-	Progress     int           `json:"progress"`
-	Error        string        `json:"error,omitempty"`
-	StartedAt    time.Time     `json:"started_at"`
-	CompletedAt  *time.Time    `json:"completed_at,omitempty"`
-}
-
 var (
-	transfers   = make(map[string]*TransferStatus)
-	transfersMu sync.RWMutex
+	transferWorkerStop chan struct{}
+	transferWorkerOnce sync.Once
 )
 
-func GetTransferStatus(transferID string) *TransferStatus {
-	transfersMu.RLock()
-	defer transfersMu.RUnlock()
-	return transfers[transferID]
+// StartTransferWorker resumes transfers whose panel stopped heartbeating
+// them, at startup and then every minute.
+func StartTransferWorker() {
+	transferWorkerOnce.Do(func() {
+		transferWorkerStop = make(chan struct{})
+		go func(stop chan struct{}) {
+			resumeTransfers()
+			ticker := time.NewTicker(time.Minute)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-stop:
+					return
+				case <-ticker.C:
+					resumeTransfers()
+				}
+			}
+		}(transferWorkerStop)
+	})
 }
 
-func GetAllTransfers() []*TransferStatus {
-	transfersMu.RLock()
-	defer transfersMu.RUnlock()
-	result := make([]*TransferStatus, 0, len(transfers))
-	for _, t := range transfers {
-		result = append(result, t)
+func StopTransferWorker() {
+	if transferWorkerStop != nil {
+		close(transferWorkerStop)
+	}
+}
+
+func GetTransferStatus(transferID string) *models.Transfer {
+	id, err := uuid.Parse(transferID)
+	if err != nil {
+		return nil
+	}
+	var t models.Transfer
+	if err := database.DB.Where("id = ?", id).First(&t).Error; err != nil {
+		return nil
 	}
-	return result
+	return &t
 }
 
-func StartTransfer(serverID, targetNodeID uuid.UUID) (string, error) {
+// GetAllTransfers returns running transfers and the ones that finished in
+// the last day.
+func GetAllTransfers() []models.Transfer {
+	transfers := []models.Transfer{}
+	database.DB.Where("stage NOT IN ? OR completed_at > ?",
+		[]string{models.TransferStageComplete, models.TransferStageFailed}, time.Now().Add(-transferHistory)).
+		Order("started_at DESC").Find(&transfers)
+	return transfers
+}
+
+func StartTransfer(serverID, targetNodeID uuid.UUID, mode string) (string, error) {
+	switch mode {
+	case "":
+		mode = models.TransferModeSync
+	case models.TransferModeSync, models.TransferModeArchive:
+	default:
+		return "", fmt.Errorf("mode must be sync or archive")
+	}
+
 	var server models.Server
 	if err := database.DB.Preload("Node").Where("id = ?", serverID).First(&server).Error; err != nil {
 		return "", fmt.Errorf("server not found")
@@ -81,109 +108,384 @@ func StartTransfer(serverID, targetNodeID uuid.UUID) (string, error) {
 		return "", fmt.Errorf("target node is offline")
 	}
 
-	if server.Node != nil && !server.Node.IsOnline {
+	if server.Node == nil || !server.Node.IsOnline {
 		return "", fmt.Errorf("source node is offline")
 	}
 
-	transferID := uuid.New().String()[:8]
+	var ports []models.ServerPort
+	json.Unmarshal(server.Ports, &ports)
+	if CountFreeAllocations(targetNodeID) < len(ports) {
+		return "", fmt.Errorf("target node does not have %d free allocations", len(ports))
+	}
 
-	status := &TransferStatus{
-		ID:           transferID,
-		ServerID:     serverID.String(),
+	var active int64
+	database.DB.Model(&models.Transfer{}).
+		Where("server_id = ? AND stage NOT IN ?", serverID, []string{models.TransferStageComplete, models.TransferStageFailed}).
+		Count(&active)
+	if active > 0 {
+		return "", fmt.Errorf("server already has a transfer in progress")
+	}
+
+	now := time.Now()
+	t := &models.Transfer{
+		ServerID:     serverID,
 		ServerName:   server.Name,
-		FromNodeID:   server.NodeID.String(),
+		FromNodeID:   server.NodeID,
 		FromNodeName: server.Node.Name,
-		ToNodeID:     targetNodeID.String(),
+		ToNodeID:     targetNodeID,
 		ToNodeName:   targetNode.Name,
-		Stage:        TransferStagePending,
-		Progress:     0,
-		StartedAt:    time.Now(),
+		Mode:         mode,
+		Stage:        models.TransferStagePending,
+		Instance:     state.InstanceID(),
+		HeartbeatAt:  &now,
+		StartedAt:    now,
+	}
+	if err := database.DB.Create(t).Error; err != nil {
+		return "", err
 	}
 
-	transfersMu.Lock()
-	transfers[transferID] = status
-	transfersMu.Unlock()
+	go runTransfer(t)
 
-	go runTransfer(status, &server, &targetNode)
+	return t.ID.String(), nil
+}
+
+// resumeTransfers claims unfinished transfers with a stale heartbeat and
+// continues them from their stored stage. The claim only matches while the
+// heartbeat is unchanged, so one panel resumes each transfer.
+func resumeTransfers() {
+	var stale []models.Transfer
+	database.DB.Where("stage NOT IN ? AND (heartbeat_at IS NULL OR heartbeat_at < ?)",
+		[]string{models.TransferStageComplete, models.TransferStageFailed}, time.Now().Add(-transferHeartbeatExpiry)).
+		Find(&stale)
 
-	return transferID, nil
+	for i := range stale {
+		t := &stale[i]
+		now := time.Now()
+		claim := database.DB.Model(&models.Transfer{}).Where("id = ?", t.ID)
+		if t.HeartbeatAt == nil {
+			claim = claim.Where("heartbeat_at IS NULL")
+		} else {
+			claim = claim.Where("heartbeat_at = ?", *t.HeartbeatAt)
+		}
+		result := claim.Updates(map[string]interface{}{"instance": state.InstanceID(), "heartbeat_at": now})
+		if result.Error != nil || result.RowsAffected == 0 {
+			continue
+		}
+		t.Instance, t.HeartbeatAt = state.InstanceID(), &now
+		log.Printf("[Transfer] Resuming transfer %s of server %s at stage %s", t.ID, t.ServerID, t.Stage)
+		go runTransfer(t)
+	}
 }
 
-func updateTransfer(status *TransferStatus, stage TransferStage, progress int) {
-	transfersMu.Lock()
-	status.Stage = stage
-	status.Progress = progress
-	transfersMu.Unlock()
+func heartbeatTransfer(id uuid.UUID, stop chan struct{}) {
+	ticker := time.NewTicker(transferHeartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			database.DB.Model(&models.Transfer{}).Where("id = ?", id).Update("heartbeat_at", now)
+		}
+	}
 }
 
-func failTransfer(status *TransferStatus, err error) {
-	transfersMu.Lock()
-	status.Stage = TransferStageFailed
-	status.Error = err.Error()
+func saveTransfer(t *models.Transfer) {
 	now := time.Now()
-	status.CompletedAt = &now
-	transfersMu.Unlock()
+	t.HeartbeatAt = &now
+	if err := database.DB.Save(t).Error; err != nil {
+		log.Printf("[Transfer] failed to save transfer %s: %v", t.ID, err)
+	}
 }
 
-func runTransfer(status *TransferStatus, server *models.Server, targetNode *models.Node) {
-	serverID := server.ID
-	log.Printf("[Transfer] Starting transfer for server %s to node %s", serverID, targetNode.Name)
+func updateTransfer(t *models.Transfer, stage string, progress int) {
+	t.Stage = stage
+	t.Progress = progress
+	saveTransfer(t)
+}
+
+func runTransfer(t *models.Transfer) {
+	stop := make(chan struct{})
+	go heartbeatTransfer(t.ID, stop)
+	defer close(stop)
 
-	var sourceNode models.Node
-	if err := database.DB.Where("id = ?", server.NodeID).First(&sourceNode).Error; err != nil {
-		failTransfer(status, fmt.Errorf("source node not found"))
+	var source, target models.Node
+	if err := database.DB.Where("id = ?", t.FromNodeID).First(&source).Error; err != nil {
+		failTransfer(t, nil, nil, fmt.Errorf("source node not found"))
+		return
+	}
+	if err := database.DB.Where("id = ?", t.ToNodeID).First(&target).Error; err != nil {
+		failTransfer(t, &source, nil, fmt.Errorf("target node not found"))
 		return
 	}
 
-	updateTransfer(status, TransferStageStopping, 5)
-	if server.Status == models.ServerStatusRunning {
-		log.Printf("[Transfer] Stopping server %s", serverID)
-		SendStopServer(serverID)
-		UpdateServerStatus(serverID, models.ServerStatusStopped, "")
-		time.Sleep(2 * time.Second)
+	log.Printf("[Transfer] Transfer %s: server %s from %s to %s", t.ID, t.ServerID, source.Name, target.Name)
+	if err := advanceTransfer(t, &source, &target); err != nil {
+		failTransfer(t, &source, &target, err)
 	}
+}
 
-	updateTransfer(status, TransferStageArchiving, 15)
-	log.Printf("[Transfer] Creating archive for %s", serverID)
-	start := time.Now()
-	if err := CreateServerArchive(serverID); err != nil {
-		failTransfer(status, fmt.Errorf("failed to create archive: %w", err))
-		return
+// advanceTransfer runs the remaining stages. The server only moves to the
+// target node in the database once the target confirmed the import.
+func advanceTransfer(t *models.Transfer, source, target *models.Node) error {
+	var archive *ArchiveInfo
+	for {
+		switch t.Stage {
+		case models.TransferStagePending:
+			if t.Mode == models.TransferModeSync {
+				updateTransfer(t, models.TransferStageSyncing, 5)
+			} else {
+				updateTransfer(t, models.TransferStageStopping, 5)
+			}
+
+		case models.TransferStageSyncing:
+			if err := preSyncTransfer(t, source, target); err != nil {
+				return err
+			}
+			updateTransfer(t, models.TransferStageStopping, 70)
+
+		case models.TransferStageStopping:
+			if err := stopForTransfer(t); err != nil {
+				return err
+			}
+			if t.Mode == models.TransferModeSync {
+				updateTransfer(t, models.TransferStageFinalizing, 75)
+			} else {
+				updateTransfer(t, models.TransferStageArchiving, 10)
+			}
+
+		case models.TransferStageArchiving:
+			start := time.Now()
+			info, err := CreateServerArchive(source, t.ServerID)
+			if err != nil {
+				return fmt.Errorf("failed to create archive: %w", err)
+			}
+			log.Printf("[Transfer] Archive for %s created in %v (%d bytes)", t.ServerID, time.Since(start), info.Size)
+			archive = info
+			t.Checksum, t.BytesTotal, t.BytesDone = info.Checksum, info.Size, 0
+			updateTransfer(t, models.TransferStageDownloading, 15)
+
+		case models.TransferStageDownloading:
+			if archive == nil {
+				archive = &ArchiveInfo{Size: t.BytesTotal, Checksum: t.Checksum}
+			}
+			err := runTargetJob(t, "import", 15, 85,
+				func() (*TransferJobStatus, error) { return GetServerImport(target, t.ServerID) },
+				func() error { return StartServerImport(target, source, t.ServerID, archive) })
+			if err != nil {
+				return err
+			}
+			updateTransfer(t, models.TransferStageImporting, 90)
+
+		case models.TransferStageFinalizing:
+			if err := stopForTransfer(t); err != nil {
+				return err
+			}
+			err := runTargetJob(t, "final sync", 75, 90,
+				func() (*TransferJobStatus, error) { return GetServerSync(target, t.ServerID) },
+				func() error { return StartServerSync(target, source, t.ServerID, true) })
+			if err != nil {
+				return err
+			}
+			updateTransfer(t, models.TransferStageImporting, 90)
+
+		case models.TransferStageImporting:
+			if err := switchTransferNode(t); err != nil {
+				return fmt.Errorf("failed to move server to target node: %w", err)
+			}
+			updateTransfer(t, models.TransferStageCleanup, 95)
+
+		case models.TransferStageCleanup:
+			cleanupTransfer(t, source)
+			now := time.Now()
+			t.CompletedAt = &now
+			updateTransfer(t, models.TransferStageComplete, 100)
+			log.Printf("[Transfer] Transfer %s complete", t.ID)
+			return nil
+
+		default:
+			return nil
+		}
+	}
+}
+
+func stopForTransfer(t *models.Transfer) error {
+	var server models.Server
+	if err := database.DB.Where("id = ?", t.ServerID).First(&server).Error; err != nil {
+		return fmt.Errorf("server not found")
+	}
+	if server.PowerState == "offline" || server.PowerState == "" {
+		return nil
+	}
+	if !t.WasRunning {
+		t.WasRunning = true
+		saveTransfer(t)
+	}
+	log.Printf("[Transfer] Stopping server %s", t.ServerID)
+	if err := SendStopServer(t.ServerID); err != nil && !IsNodeConflict(err) {
+		return fmt.Errorf("failed to stop server: %w", err)
+	}
+	if err := waitForState(t.ServerID, "stopped", transferStopTimeout); err != nil {
+		return fmt.Errorf("failed to stop server: %w", err)
 	}
-	log.Printf("[Transfer] Archive created in %v", time.Since(start))
+	UpdateServerStatus(t.ServerID, models.ServerStatusStopped, "")
+	return nil
+}
 
-	updateTransfer(status, TransferStageUploading, 40)
-	log.Printf("[Transfer] Telling target node to fetch from source node")
-	start = time.Now()
-	err := ImportServerArchive(targetNode, serverID.String(), &sourceNode)
+// preSyncTransfer copies the server's files to the target while it keeps
+// running. Passes repeat while they still copy a lot, so the final sync
+// after the stop only has a small delta left.
+func preSyncTransfer(t *models.Transfer, source, target *models.Node) error {
+	for pass := 1; pass <= maxPreSyncPasses; pass++ {
+		err := runTargetJob(t, "pre-sync", 5, 70,
+			func() (*TransferJobStatus, error) { return GetServerSync(target, t.ServerID) },
+			func() error { return StartServerSync(target, source, t.ServerID, false) })
+		if err != nil {
+			return err
+		}
+		log.Printf("[Transfer] Pre-sync pass %d for %s copied %d bytes", pass, t.ServerID, t.BytesTotal)
+		if t.BytesTotal <= preSyncSettledBytes {
+			break
+		}
+	}
+	return nil
+}
+
+// runTargetJob starts a job on the target node and waits for it, reporting
+// its byte progress between from and to. A job the target is still running,
+// e.g. after a panel restart, is waited on instead of being started again.
+func runTargetJob(t *models.Transfer, name string, from, to int, get func() (*TransferJobStatus, error), start func() error) error {
+	status, err := get()
 	if err != nil {
-		DeleteServerArchive(serverID)
-		failTransfer(status, fmt.Errorf("failed to import: %w", err))
-		return
+		return fmt.Errorf("failed to reach target node: %w", err)
 	}
-	log.Printf("[Transfer] Transfer completed in %v", time.Since(start))
+	if status == nil || status.Stage == "done" || status.Stage == "failed" {
+		if err := start(); err != nil {
+			return fmt.Errorf("failed to start %s: %w", name, err)
+		}
+	}
+	t.BytesDone, t.BytesTotal = 0, 0
+
+	failures := 0
+	for {
+		time.Sleep(transferPollInterval)
+		status, err := get()
+		if err != nil {
+			if failures++; failures >= 15 {
+				return fmt.Errorf("lost contact with target node: %w", err)
+			}
+			continue
+		}
+		failures = 0
+		if status == nil {
+			return fmt.Errorf("target node lost the %s", name)
+		}
 
-	updateTransfer(status, TransferStageCleanup, 85)
-	DeleteServerArchive(serverID)
-	SendDeleteServer(serverID)
+		t.BytesDone, t.BytesTotal = status.BytesDone, status.BytesTotal
+		switch status.Stage {
+		case "done":
+			t.BytesDone = t.BytesTotal
+			return nil
+		case "failed":
+			return fmt.Errorf("%s failed: %s", name, status.Error)
+		}
+
+		progress := from
+		if t.BytesTotal > 0 {
+			progress += int(int64(to-from) * t.BytesDone / t.BytesTotal)
+		}
+		updateTransfer(t, t.Stage, progress)
+	}
+}
 
-	updateTransfer(status, TransferStageImporting, 95)
-	newPorts := AllocatePortsForNode(uuid.MustParse(status.ToNodeID), server.Ports)
-	database.DB.Model(&models.Server{}).Where("id = ?", serverID).Updates(map[string]interface{}{
-		"node_id": status.ToNodeID,
-		"ports":   newPorts,
+// switchTransferNode points the server at the target node with allocations
+// from the target's pool and frees the ones it held on the source. A server
+// that already moved is left as it is.
+func switchTransferNode(t *models.Transfer) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		var server models.Server
+		if err := tx.Where("id = ?", t.ServerID).First(&server).Error; err != nil {
+			return err
+		}
+		if server.NodeID == t.ToNodeID {
+			return nil
+		}
+		var ports []models.ServerPort
+		json.Unmarshal(server.Ports, &ports)
+		if err := releaseAllocations(tx, server.ID); err != nil {
+			return err
+		}
+		assigned, err := assignAllocations(tx, t.ToNodeID, server.ID, ports)
+		if err != nil {
+			return err
+		}
+		portsJSON, _ := json.Marshal(assigned)
+		return tx.Model(&models.Server{}).Where("id = ? AND node_id = ?", t.ServerID, t.FromNodeID).Updates(map[string]interface{}{
+			"node_id": t.ToNodeID,
+			"ports":   portsJSON,
+		}).Error
 	})
+}
 
-	updateTransfer(status, TransferStageComplete, 100)
+// cleanupTransfer removes the server from the source node and starts it on
+// the target if it was running. Failures here no longer undo the transfer.
+func cleanupTransfer(t *models.Transfer, source *models.Node) {
+	if t.Mode != models.TransferModeSync {
+		if err := DeleteServerArchive(source, t.ServerID); err != nil {
+			log.Printf("[Transfer] failed to delete archive on %s: %v", source.Name, err)
+		}
+	}
+	if err := sendToNode(source, "DELETE", fmt.Sprintf("/api/servers/%s", t.ServerID), nil); err != nil {
+		log.Printf("[Transfer] failed to delete server %s from %s: %v", t.ServerID, source.Name, err)
+	}
+	if t.WasRunning {
+		if err := SendStartServer(t.ServerID); err != nil {
+			log.Printf("[Transfer] failed to start server %s on target: %v", t.ServerID, err)
+		}
+	}
+}
+
+// failTransfer rolls a transfer back: the partial import and the archive are
+// removed and the server is started again on the source if it was running.
+// Once the database points at the target, the transfer is finished instead.
+func failTransfer(t *models.Transfer, source, target *models.Node, cause error) {
+	log.Printf("[Transfer] Transfer %s failed at stage %s: %v", t.ID, t.Stage, cause)
+
+	t.Error = cause.Error()
 	now := time.Now()
-	transfersMu.Lock()
-	status.CompletedAt = &now
-	transfersMu.Unlock()
-
-	go func() {
-		time.Sleep(5 * time.Minute)
-		transfersMu.Lock()
-		delete(transfers, status.ID)
-		transfersMu.Unlock()
-	}()
+	t.CompletedAt = &now
+
+	var server models.Server
+	if err := database.DB.Where("id = ?", t.ServerID).First(&server).Error; err == nil && server.NodeID == t.ToNodeID {
+		if source != nil {
+			cleanupTransfer(t, source)
+		}
+		updateTransfer(t, models.TransferStageComplete, 100)
+		return
+	}
+
+	if target != nil && t.Stage != models.TransferStagePending {
+		if err := sendToNode(target, "DELETE", fmt.Sprintf("/api/servers/%s", t.ServerID), nil); err != nil {
+			log.Printf("[Transfer] failed to remove partial import from %s: %v", target.Name, err)
+		}
+	}
+	if source != nil {
+		if t.Mode != models.TransferModeSync {
+			if err := DeleteServerArchive(source, t.ServerID); err != nil && !isNodeNotFound(err) {
+				log.Printf("[Transfer] failed to delete archive on %s: %v", source.Name, err)
+			}
+		}
+		if t.WasRunning {
+			if err := SendStartServer(t.ServerID); err != nil {
+				log.Printf("[Transfer] failed to restart server %s on source: %v", t.ServerID, err)
+			}
+		}
+	}
+
+	updateTransfer(t, models.TransferStageFailed, t.Progress)
+}
+
+func isNodeNotFound(err error) bool {
+	var nodeErr *NodeError
+	return errors.As(err, &nodeErr) && nodeErr.StatusCode == http.StatusNotFound
 }
@@ -0,0 +1,297 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"birdactyl-panel-backend/internal/models"
+)
+
+type VariableValidationError struct {
+	Fields map[string]string
+}
+
+func (e *VariableValidationError) Error() string {
+	return "invalid variables"
+}
+
+type VariableSchema struct {
+	models.PackageVariable
+	Type     string   `json:"type"`
+	Required bool     `json:"required"`
+	Nullable bool     `json:"nullable"`
+	Min      *float64 `json:"min,omitempty"`
+	Max      *float64 `json:"max,omitempty"`
+	Pattern  string   `json:"pattern,omitempty"`
+	Options  []string `json:"options,omitempty"`
+	URL      bool     `json:"url,omitempty"`
+}
+
+type variableRules struct {
+	required bool
+	nullable bool
+	kind     string
+	min      *float64
+	max      *float64
+	pattern  *regexp.Regexp
+	options  []string
+	url      bool
+}
+
+func parseVariableRules(rules string) (*variableRules, error) {
+	r := &variableRules{kind: "string"}
+	parts := strings.Split(rules, "|")
+	for i := 0; i < len(parts); i++ {
+		part := strings.TrimSpace(parts[i])
+		if part == "" {
+			continue
+		}
+		name, arg, _ := strings.Cut(part, ":")
+		switch name {
+		case "required":
+			r.required = true
+		case "nullable":
+			r.nullable = true
+		case "string", "integer", "boolean":
+			r.kind = name
+		case "min", "max":
+			n, err := strconv.ParseFloat(arg, 64)
+			if err != nil {
+				return nil, fmt.Errorf("rule %s needs a numeric argument", name)
+			}
+			if name == "min" {
+				r.min = &n
+			} else {
+				r.max = &n
+			}
+		case "regex":
+			arg = strings.Join(append([]string{arg}, parts[i+1:]...), "|")
+			i = len(parts)
+			re, err := compileRuleRegex(arg)
+			if err != nil {
+				return nil, fmt.Errorf("invalid regex rule: %v", err)
+			}
+			r.pattern = re
+		case "in":
+			if arg == "" {
+				return nil, fmt.Errorf("rule in needs at least one option")
+			}
+			r.options = strings.Split(arg, ",")
+		case "url":
+			r.url = true
+		default:
+			return nil, fmt.Errorf("unknown rule %q", name)
+		}
+	}
+	return r, nil
+}
+
+func compileRuleRegex(expr string) (*regexp.Regexp, error) {
+	if len(expr) >= 2 && expr[0] == '/' {
+		end := strings.LastIndex(expr, "/")
+		if end > 0 {
+			flags := expr[end+1:]
+			expr = expr[1:end]
+			if flags != "" {
+				expr = "(?" + flags + ")" + expr
+			}
+		}
+	}
+	return regexp.Compile(expr)
+}
+
+func (r *variableRules) check(value string) string {
+	if value == "" {
+		if r.required && !r.nullable {
+			return "This field is required."
+		}
+		return ""
+	}
+
+	var number float64
+	switch r.kind {
+	case "integer":
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return "Must be an integer."
+		}
+		number = float64(n)
+	case "boolean":
+		switch strings.ToLower(value) {
+		case "true", "false", "1", "0":
+		default:
+			return "Must be true or false."
+		}
+	}
+
+	if r.kind == "integer" {
+		if r.min != nil && number < *r.min {
+			return fmt.Sprintf("Must be at least %s.", formatRuleNumber(*r.min))
+		}
+		if r.max != nil && number > *r.max {
+			return fmt.Sprintf("Must be at most %s.", formatRuleNumber(*r.max))
+		}
+	} else {
+		length := float64(len([]rune(value)))
+		if r.min != nil && length < *r.min {
+			return fmt.Sprintf("Must be at least %s characters.", formatRuleNumber(*r.min))
+		}
+		if r.max != nil && length > *r.max {
+			return fmt.Sprintf("Must be at most %s characters.", formatRuleNumber(*r.max))
+		}
+	}
+
+	if r.pattern != nil && !r.pattern.MatchString(value) {
+		return "Has an invalid format."
+	}
+	if len(r.options) > 0 {
+		found := false
+		for _, opt := range r.options {
+			if value == opt {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return "Must be one of: " + strings.Join(r.options, ", ") + "."
+		}
+	}
+	if r.url {
+		u, err := url.ParseRequestURI(value)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return "Must be a valid URL."
+		}
+	}
+	return ""
+}
+
+func formatRuleNumber(n float64) string {
+	return strconv.FormatFloat(n, 'f', -1, 64)
+}
+
+func ValidatePackageVariables(vars []models.PackageVariable) error {
+	for _, v := range vars {
+		if v.Name == "" {
+			return fmt.Errorf("variable name is required")
+		}
+		if _, err := parseVariableRules(v.Rules); err != nil {
+			return fmt.Errorf("variable %s: %v", v.Name, err)
+		}
+	}
+	return nil
+}
+
+func ValidateServerVariables(pkg *models.Package, values, current map[string]string, isAdmin bool) error {
+	var pkgVars []models.PackageVariable
+	json.Unmarshal(pkg.Variables, &pkgVars)
+
+	fields := make(map[string]string)
+	known := make(map[string]bool, len(pkgVars))
+	for _, pv := range pkgVars {
+		known[pv.Name] = true
+
+		effective := pv.Default
+		if v, ok := current[pv.Name]; ok {
+			effective = v
+		}
+		value, provided := values[pv.Name]
+		if !provided {
+			value = effective
+		}
+
+		if provided && value != effective && !pv.UserEditable && !isAdmin {
+			fields[pv.Name] = "This variable cannot be changed."
+			continue
+		}
+
+		kept, _ := SanitizeVariableRules(pv.Rules)
+		rules, err := parseVariableRules(kept)
+		if err != nil {
+			continue
+		}
+		if msg := rules.check(value); msg != "" {
+			fields[pv.Name] = msg
+		}
+	}
+
+	if !isAdmin {
+		for name := range values {
+			if !known[name] {
+				fields[name] = "Unknown variable."
+			}
+		}
+	}
+
+	if len(fields) > 0 {
+		return &VariableValidationError{Fields: fields}
+	}
+	return nil
+}
+
+// KeepLockedVariables copies the current values of the package's
+// non-editable variables into values, so a user saving their variables
+// cannot drop values only an admin may change.
+func KeepLockedVariables(pkg *models.Package, values, current map[string]string) {
+	var pkgVars []models.PackageVariable
+	json.Unmarshal(pkg.Variables, &pkgVars)
+
+	for _, pv := range pkgVars {
+		if pv.UserEditable {
+			continue
+		}
+		if v, ok := current[pv.Name]; ok {
+			values[pv.Name] = v
+		} else {
+			delete(values, pv.Name)
+		}
+	}
+}
+
+func GetVariableSchemas(pkg *models.Package) []VariableSchema {
+	var pkgVars []models.PackageVariable
+	json.Unmarshal(pkg.Variables, &pkgVars)
+
+	schemas := make([]VariableSchema, 0, len(pkgVars))
+	for _, pv := range pkgVars {
+		schema := VariableSchema{PackageVariable: pv, Type: "string"}
+		if rules, err := parseVariableRules(pv.Rules); err == nil {
+			schema.Type = rules.kind
+			schema.Required = rules.required
+			schema.Nullable = rules.nullable
+			schema.Min = rules.min
+			schema.Max = rules.max
+			schema.Options = rules.options
+			schema.URL = rules.url
+			if rules.pattern != nil {
+				schema.Pattern = rules.pattern.String()
+			}
+		}
+		schemas = append(schemas, schema)
+	}
+	return schemas
+}
+
+func SanitizeVariableRules(rules string) (string, []string) {
+	var kept, dropped []string
+	parts := strings.Split(rules, "|")
+	for i := 0; i < len(parts); i++ {
+		part := strings.TrimSpace(parts[i])
+		if part == "" {
+			continue
+		}
+		if strings.HasPrefix(part, "regex:") {
+			part = strings.Join(parts[i:], "|")
+			i = len(parts)
+		}
+		if _, err := parseVariableRules(part); err != nil {
+			dropped = append(dropped, part)
+			continue
+		}
+		kept = append(kept, part)
+	}
+	return strings.Join(kept, "|"), dropped
+}
@@ -0,0 +1,214 @@
+package state
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"birdactyl-panel-backend/internal/config"
+	"birdactyl-panel-backend/internal/database"
+	"birdactyl-panel-backend/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	notifyChannel   = "birdactyl_state"
+	maxNotifyBytes  = 7900
+	listenRetry     = 2 * time.Second
+	entrySweepEvery = time.Minute
+)
+
+// envelope carries a message through the single Postgres notification
+// channel. Payload is base64 in JSON, so messages can hold about 5 KB.
+type envelope struct {
+	Channel string `json:"c"`
+	Payload []byte `json:"p"`
+}
+
+type databaseBackend struct {
+	dsn    string
+	hub    *hub
+	ctx    context.Context
+	cancel context.CancelFunc
+	once   sync.Once
+}
+
+func newDatabaseBackend(cfg *config.DatabaseConfig) (*databaseBackend, error) {
+	if cfg.Driver != "postgres" {
+		return nil, fmt.Errorf("the database state backend needs postgres for LISTEN/NOTIFY, not %s", cfg.Driver)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	b := &databaseBackend{dsn: database.PostgresDSN(cfg), hub: newHub(), ctx: ctx, cancel: cancel}
+
+	conn, err := b.listenConn()
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	go b.listen(conn)
+	go b.sweep()
+	return b, nil
+}
+
+func (b *databaseBackend) listenConn() (*pgx.Conn, error) {
+	conn, err := pgx.Connect(b.ctx, b.dsn)
+	if err != nil {
+		return nil, fmt.Errorf("state listener: %w", err)
+	}
+	if _, err := conn.Exec(b.ctx, "LISTEN "+notifyChannel); err != nil {
+		conn.Close(context.Background())
+		return nil, fmt.Errorf("state listener: %w", err)
+	}
+	return conn, nil
+}
+
+// listen delivers notifications to local subscribers, reconnecting when the
+// connection drops. Messages sent while it is down are lost.
+func (b *databaseBackend) listen(conn *pgx.Conn) {
+	for {
+		for {
+			n, err := conn.WaitForNotification(b.ctx)
+			if err != nil {
+				break
+			}
+			var env envelope
+			if json.Unmarshal([]byte(n.Payload), &env) == nil {
+				b.hub.deliver(env.Channel, env.Payload)
+			}
+		}
+		conn.Close(context.Background())
+
+		for {
+			if b.ctx.Err() != nil {
+				return
+			}
+			time.Sleep(listenRetry)
+			var err error
+			if conn, err = b.listenConn(); err == nil {
+				break
+			}
+			log.Printf("[state] %v", err)
+		}
+	}
+}
+
+func (b *databaseBackend) sweep() {
+	ticker := time.NewTicker(entrySweepEvery)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-b.ctx.Done():
+			return
+		case now := <-ticker.C:
+			database.DB.Where("expires_at < ?", now).Delete(&models.StateEntry{})
+		}
+	}
+}
+
+func live(tx *gorm.DB) *gorm.DB {
+	return tx.Where("expires_at IS NULL OR expires_at > ?", time.Now())
+}
+
+func expiry(ttl time.Duration) *time.Time {
+	if ttl <= 0 {
+		return nil
+	}
+	t := time.Now().Add(ttl)
+	return &t
+}
+
+func (b *databaseBackend) Get(key string) ([]byte, error) {
+	var e models.StateEntry
+	err := live(database.DB.Where("id = ?", key)).First(&e).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound
+	}
+	return e.Value, err
+}
+
+func (b *databaseBackend) Set(key string, value []byte, ttl time.Duration) error {
+	if value == nil {
+		value = []byte{}
+	}
+	return database.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"value", "expires_at"}),
+	}).Create(&models.StateEntry{ID: key, Value: value, ExpiresAt: expiry(ttl)}).Error
+}
+
+func (b *databaseBackend) Delete(key string) error {
+	return database.DB.Where("id = ?", key).Delete(&models.StateEntry{}).Error
+}
+
+func (b *databaseBackend) List(prefix string) (map[string][]byte, error) {
+	var entries []models.StateEntry
+	if err := live(database.DB.Where("id LIKE ?", prefix+"%")).Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	result := make(map[string][]byte, len(entries))
+	for _, e := range entries {
+		result[e.ID] = e.Value
+	}
+	return result, nil
+}
+
+// Update makes sure the row exists and then locks it for the rest of the
+// transaction, so concurrent updates from any instance run one at a time.
+func (b *databaseBackend) Update(key string, ttl time.Duration, fn func(current []byte) ([]byte, error)) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		expired := time.Unix(0, 0)
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&models.StateEntry{ID: key, Value: []byte{}, ExpiresAt: &expired}).Error; err != nil {
+			return err
+		}
+		var e models.StateEntry
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", key).First(&e).Error; err != nil {
+			return err
+		}
+		var cur []byte
+		if e.ExpiresAt == nil || e.ExpiresAt.After(time.Now()) {
+			cur = e.Value
+		}
+		next, err := fn(cur)
+		if errors.Is(err, ErrUnchanged) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if next == nil {
+			return tx.Where("id = ?", key).Delete(&models.StateEntry{}).Error
+		}
+		return tx.Model(&models.StateEntry{}).Where("id = ?", key).
+			Updates(map[string]interface{}{"value": next, "expires_at": expiry(ttl)}).Error
+	})
+}
+
+func (b *databaseBackend) Publish(channel string, payload []byte) error {
+	data, err := json.Marshal(envelope{Channel: channel, Payload: payload})
+	if err != nil {
+		return err
+	}
+	if len(data) > maxNotifyBytes {
+		return ErrPayloadTooLarge
+	}
+	return database.DB.Exec("SELECT pg_notify(?, ?)", notifyChannel, string(data)).Error
+}
+
+func (b *databaseBackend) Subscribe(channel string) *Subscription {
+	return b.hub.add(channel)
+}
+
+func (b *databaseBackend) Shared() bool { return true }
+
+func (b *databaseBackend) Close() error {
+	b.once.Do(b.cancel)
+	return nil
+}
@@ -0,0 +1,147 @@
+package state
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+type memoryEntry struct {
+	value   []byte
+	expires time.Time
+}
+
+func (e memoryEntry) expired(now time.Time) bool {
+	return !e.expires.IsZero() && now.After(e.expires)
+}
+
+type memoryBackend struct {
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+	hub     *hub
+	stop    chan struct{}
+	once    sync.Once
+}
+
+func newMemoryBackend() *memoryBackend {
+	b := &memoryBackend{
+		entries: make(map[string]memoryEntry),
+		hub:     newHub(),
+		stop:    make(chan struct{}),
+	}
+	go b.sweep()
+	return b
+}
+
+func (b *memoryBackend) sweep() {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-b.stop:
+			return
+		case now := <-ticker.C:
+			b.mu.Lock()
+			for k, e := range b.entries {
+				if e.expired(now) {
+					delete(b.entries, k)
+				}
+			}
+			b.mu.Unlock()
+		}
+	}
+}
+
+func (b *memoryBackend) get(key string) []byte {
+	e, ok := b.entries[key]
+	if !ok || e.expired(time.Now()) {
+		return nil
+	}
+	return e.value
+}
+
+func (b *memoryBackend) set(key string, value []byte, ttl time.Duration) {
+	if value == nil {
+		delete(b.entries, key)
+		return
+	}
+	e := memoryEntry{value: append([]byte{}, value...)}
+	if ttl > 0 {
+		e.expires = time.Now().Add(ttl)
+	}
+	b.entries[key] = e
+}
+
+func (b *memoryBackend) Get(key string) ([]byte, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if v := b.get(key); v != nil {
+		return append([]byte{}, v...), nil
+	}
+	return nil, ErrNotFound
+}
+
+func (b *memoryBackend) Set(key string, value []byte, ttl time.Duration) error {
+	if value == nil {
+		value = []byte{}
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.set(key, value, ttl)
+	return nil
+}
+
+func (b *memoryBackend) Delete(key string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.entries, key)
+	return nil
+}
+
+func (b *memoryBackend) List(prefix string) (map[string][]byte, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := time.Now()
+	result := make(map[string][]byte)
+	for k, e := range b.entries {
+		if strings.HasPrefix(k, prefix) && !e.expired(now) {
+			result[k] = append([]byte{}, e.value...)
+		}
+	}
+	return result, nil
+}
+
+func (b *memoryBackend) Update(key string, ttl time.Duration, fn func(current []byte) ([]byte, error)) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var cur []byte
+	if v := b.get(key); v != nil {
+		cur = append([]byte{}, v...)
+	}
+	next, err := fn(cur)
+	if errors.Is(err, ErrUnchanged) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	b.set(key, next, ttl)
+	return nil
+}
+
+func (b *memoryBackend) Publish(channel string, payload []byte) error {
+	b.hub.deliver(channel, payload)
+	return nil
+}
+
+func (b *memoryBackend) Subscribe(channel string) *Subscription {
+	return b.hub.add(channel)
+}
+
+func (b *memoryBackend) Shared() bool { return false }
+
+func (b *memoryBackend) Close() error {
+	b.once.Do(func() { close(b.stop) })
+	return nil
+}
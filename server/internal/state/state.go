@@ -0,0 +1,180 @@
+// Package state holds panel state that has to be shared between panel
+// instances: short-lived key/value entries and a publish/subscribe bus.
+// The memory backend keeps everything in the process, the database backend
+// stores entries in the panel database and uses Postgres LISTEN/NOTIFY for
+// messages so several instances can run behind a load balancer.
+package state
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"birdactyl-panel-backend/internal/config"
+)
+
+var (
+	ErrNotFound        = errors.New("state: key not found")
+	ErrPayloadTooLarge = errors.New("state: message too large")
+	ErrInvalidPrefix   = errors.New("state: prefixes cannot contain % or _")
+	// ErrUnchanged can be returned by an Update function to leave the entry,
+	// including its expiry, as it is.
+	ErrUnchanged = errors.New("state: unchanged")
+)
+
+// Backend stores shared entries and passes messages between instances.
+// Entries with a zero TTL never expire.
+type Backend interface {
+	Get(key string) ([]byte, error)
+	Set(key string, value []byte, ttl time.Duration) error
+	Delete(key string) error
+	List(prefix string) (map[string][]byte, error)
+	// Update atomically replaces the entry with what fn returns. fn gets nil
+	// for a missing entry, and returning nil deletes it.
+	// Returning ErrUnchanged keeps it as it is.
+	Update(key string, ttl time.Duration, fn func(current []byte) ([]byte, error)) error
+	Publish(channel string, payload []byte) error
+	Subscribe(channel string) *Subscription
+	Shared() bool
+	Close() error
+}
+
+var (
+	current  Backend = newMemoryBackend()
+	instance         = func() string {
+		host, _ := os.Hostname()
+		return fmt.Sprintf("%s-%d", host, os.Getpid())
+	}()
+)
+
+// Init switches to the configured backend. It must run after the database
+// is connected and before anything uses shared state.
+func Init(cfg *config.Config) error {
+	switch cfg.State.Backend {
+	case config.StateBackendMemory:
+		return nil
+	case config.StateBackendDatabase:
+		b, err := newDatabaseBackend(&cfg.Database)
+		if err != nil {
+			return err
+		}
+		current.Close()
+		current = b
+		return nil
+	default:
+		return fmt.Errorf("unknown state backend %q", cfg.State.Backend)
+	}
+}
+
+// InstanceID names this panel process among the instances sharing state.
+func InstanceID() string { return instance }
+
+// Shared reports whether state is visible to other panel instances.
+func Shared() bool { return current.Shared() }
+
+func Get(key string) ([]byte, error) { return current.Get(key) }
+
+func Set(key string, value []byte, ttl time.Duration) error { return current.Set(key, value, ttl) }
+
+func Delete(key string) error { return current.Delete(key) }
+
+func List(prefix string) (map[string][]byte, error) {
+	if strings.ContainsAny(prefix, "%_") {
+		return nil, ErrInvalidPrefix
+	}
+	return current.List(prefix)
+}
+
+func Update(key string, ttl time.Duration, fn func(current []byte) ([]byte, error)) error {
+	return current.Update(key, ttl, fn)
+}
+
+func Publish(channel string, payload []byte) error { return current.Publish(channel, payload) }
+
+func Subscribe(channel string) *Subscription { return current.Subscribe(channel) }
+
+func Close() error { return current.Close() }
+
+// Acquire takes or renews a lease on key for owner. It returns false while
+// someone else holds it.
+func Acquire(key, owner string, ttl time.Duration) (bool, error) {
+	acquired := false
+	err := Update(key, ttl, func(cur []byte) ([]byte, error) {
+		if cur != nil && string(cur) != owner {
+			return nil, ErrUnchanged
+		}
+		acquired = true
+		return []byte(owner), nil
+	})
+	return acquired, err
+}
+
+// Release gives up a lease if owner still holds it.
+func Release(key, owner string) error {
+	return Update(key, 0, func(cur []byte) ([]byte, error) {
+		if string(cur) == owner {
+			return nil, nil
+		}
+		return nil, ErrUnchanged
+	})
+}
+
+// Subscription receives the messages published on one channel. Messages are
+// dropped when the subscriber falls behind.
+type Subscription struct {
+	C       chan []byte
+	channel string
+	hub     *hub
+	once    sync.Once
+}
+
+func (s *Subscription) Close() {
+	s.once.Do(func() { s.hub.remove(s) })
+}
+
+// hub fans messages out to the subscriptions of this process.
+type hub struct {
+	mu   sync.Mutex
+	subs map[string]map[*Subscription]struct{}
+}
+
+func newHub() *hub {
+	return &hub{subs: make(map[string]map[*Subscription]struct{})}
+}
+
+func (h *hub) add(channel string) *Subscription {
+	s := &Subscription{C: make(chan []byte, 256), channel: channel, hub: h}
+	h.mu.Lock()
+	if h.subs[channel] == nil {
+		h.subs[channel] = make(map[*Subscription]struct{})
+	}
+	h.subs[channel][s] = struct{}{}
+	h.mu.Unlock()
+	return s
+}
+
+func (h *hub) remove(s *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if subs := h.subs[s.channel]; subs != nil {
+		delete(subs, s)
+		if len(subs) == 0 {
+			delete(h.subs, s.channel)
+		}
+	}
+	close(s.C)
+}
+
+func (h *hub) deliver(channel string, payload []byte) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for s := range h.subs[channel] {
+		select {
+		case s.C <- payload:
+		default:
+		}
+	}
+}
@@ -15,6 +15,7 @@ import (
 	"birdactyl-panel-backend/internal/plugins"
 	"birdactyl-panel-backend/internal/routes"
 	"birdactyl-panel-backend/internal/services"
+	"birdactyl-panel-backend/internal/state"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/recover"
@@ -49,7 +50,21 @@ func main() {
 	}
 	logger.Success("Database connected (%s)", cfg.Database.Driver)
 
+	if err := state.Init(cfg); err != nil {
+		logger.Fatal("State backend failed: %v", err)
+	}
+	logger.Success("State backend: %s (instance %s)", cfg.State.Backend, state.InstanceID())
+
+	if err := services.BackfillPackageRevisions(); err != nil {
+		logger.Error("Package revision backfill failed: %v", err)
+	}
+	if err := services.BackfillAllocations(); err != nil {
+		logger.Error("Allocation backfill failed: %v", err)
+	}
+
 	services.InitScheduler()
+	services.StartNodeSync()
+	services.StartTransferWorker()
 
 	if err := plugins.StartServer(cfg.Plugins.Address); err != nil {
 		logger.Error("Plugin server failed: %v", err)
@@ -66,6 +81,7 @@ func main() {
 
 		plugins.StartScheduler()
 		plugins.StartHealthCheck()
+		plugins.StartEventRelay()
 		plugins.LoadPlugins(cfg.Plugins.Directory)
 	}
 
@@ -103,7 +119,10 @@ func main() {
 		close(stopSessionCleanup)
 		middleware.StopCleanup()
 		services.StopScheduler()
+		services.StopNodeSync()
+		services.StopTransferWorker()
 		plugins.StopHealthCheck()
+		plugins.StopEventRelay()
 		plugins.StopScheduler()
 		if plugins.GetContainerManager().IsRunning() {
 			plugins.GetContainerManager().Shutdown()
@@ -111,6 +130,7 @@ func main() {
 			plugins.GetProcessManager().StopAll()
 		}
 		plugins.StopServer()
+		state.Close()
 		database.Close()
 		app.Shutdown()
 	}()